@@ -0,0 +1,256 @@
+package bench
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ollamacli/internal/client"
+)
+
+// DefaultSeed is sent with every request so runs produce the same output
+const DefaultSeed = 42
+
+// DefaultPrompts are used when no prompt set is given
+var DefaultPrompts = []string{
+	"Explain what a hash map is in two sentences.",
+	"Write a haiku about autumn.",
+	"List three uses of the Go programming language.",
+}
+
+// Options configures a benchmark
+type Options struct {
+	Client client.Provider
+	Models []string
+	// Prompts are sent in turn to each model (default: DefaultPrompts)
+	Prompts []string
+	// Runs is how many times each prompt is sent to each model (default 1)
+	Runs int
+	// Concurrency is how many requests run at once against a model (default 1)
+	Concurrency int
+	// Warmup requests are sent to each model first and not measured
+	Warmup int
+	// Seed fixes the sampling seed (default DefaultSeed)
+	Seed int
+	// ModelOptions are sent with every request, e.g. num_predict to cap
+	// the length of replies
+	ModelOptions map[string]interface{}
+	// Cold unloads each model first to measure a cold load
+	Cold bool
+	// Generate uses /api/generate instead of /api/chat
+	Generate bool
+	// Progress, when set, is called after each measured request
+	Progress func(Sample)
+}
+
+// Sample is the measurement of one request
+type Sample struct {
+	Model  string
+	Prompt string
+	// TimeToFirstToken is measured from sending the request to the first
+	// streamed chunk
+	TimeToFirstToken time.Duration
+	// Total is measured from sending the request to the end of the stream
+	Total time.Duration
+	// Stats are the counts and timings reported by the server
+	Stats client.Stats
+	Cold  bool
+	Err   error
+}
+
+// TokensPerSecond prefers the server's eval timing and otherwise estimates
+// from the time between the first chunk and the end of the stream
+func (s Sample) TokensPerSecond() float64 {
+	if rate := s.Stats.TokensPerSecond(); rate > 0 {
+		return rate
+	}
+	if streaming := s.Total - s.TimeToFirstToken; streaming > 0 {
+		return float64(s.Stats.CompletionTokens) / streaming.Seconds()
+	}
+	return 0
+}
+
+// Run benchmarks every model in turn and summarizes the measurements
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if len(opts.Models) == 0 {
+		return nil, fmt.Errorf("no models to benchmark")
+	}
+	if len(opts.Prompts) == 0 {
+		opts.Prompts = DefaultPrompts
+	}
+	if opts.Runs <= 0 {
+		opts.Runs = 1
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Seed == 0 {
+		opts.Seed = DefaultSeed
+	}
+
+	report := &Report{Concurrency: opts.Concurrency, Seed: opts.Seed, Created: time.Now()}
+	for _, model := range opts.Models {
+		result, err := runModel(ctx, opts, model)
+		if err != nil {
+			return nil, err
+		}
+		report.Models = append(report.Models, *result)
+	}
+	return report, nil
+}
+
+func runModel(ctx context.Context, opts Options, model string) (*ModelReport, error) {
+	var cold *Sample
+	if opts.Cold {
+		err := client.UnloadModel(ctx, opts.Client, model)
+		switch {
+		case errors.Is(err, client.ErrUnsupported):
+			// Backends without unload only get warm measurements
+		case err != nil:
+			return nil, err
+		default:
+			sample := measure(ctx, opts, model, opts.Prompts[0])
+			sample.Cold = true
+			cold = &sample
+		}
+	}
+
+	for i := 0; i < opts.Warmup; i++ {
+		if sample := measure(ctx, opts, model, opts.Prompts[i%len(opts.Prompts)]); sample.Err != nil {
+			return nil, fmt.Errorf("warmup of %s failed: %w", model, sample.Err)
+		}
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var samples []Sample
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for prompt := range jobs {
+				sample := measure(ctx, opts, model, prompt)
+				mu.Lock()
+				samples = append(samples, sample)
+				if opts.Progress != nil {
+					opts.Progress(sample)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+send:
+	for run := 0; run < opts.Runs; run++ {
+		for _, prompt := range opts.Prompts {
+			select {
+			case jobs <- prompt:
+			case <-ctx.Done():
+				break send
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summarize(model, samples, cold), nil
+}
+
+// measure sends one prompt and times the streamed reply
+func measure(ctx context.Context, opts Options, model, prompt string) Sample {
+	sample := Sample{Model: model, Prompt: prompt}
+	options := map[string]interface{}{"seed": opts.Seed}
+	for key, value := range opts.ModelOptions {
+		options[key] = value
+	}
+
+	start := time.Now()
+	firstChunk := func(text string) {
+		if sample.TimeToFirstToken == 0 && text != "" {
+			sample.TimeToFirstToken = time.Since(start)
+		}
+	}
+
+	if opts.Generate {
+		respCh, err := opts.Client.GenerateStream(ctx, client.GenerateRequest{Model: model, Prompt: prompt, Options: options})
+		if err != nil {
+			sample.Err = err
+			return sample
+		}
+		for resp := range respCh {
+			if resp.Err != nil {
+				sample.Err = resp.Err
+				break
+			}
+			firstChunk(resp.Response + resp.Thinking)
+			if resp.Done {
+				sample.Stats = resp.Stats()
+			}
+		}
+	} else {
+		req := client.ChatRequest{
+			Model:    model,
+			Messages: []client.ChatMessage{{Role: client.RoleUser, Content: prompt}},
+			Options:  options,
+		}
+		respCh, err := opts.Client.ChatStream(ctx, req)
+		if err != nil {
+			sample.Err = err
+			return sample
+		}
+		for resp := range respCh {
+			if resp.Err != nil {
+				sample.Err = resp.Err
+				break
+			}
+			firstChunk(resp.Message.Content + resp.Message.Thinking)
+			if resp.Done {
+				sample.Stats = resp.Stats()
+			}
+		}
+	}
+
+	sample.Total = time.Since(start)
+	sample.Stats.TimeToFirstToken = sample.TimeToFirstToken
+	return sample
+}
+
+// LoadPrompts reads a prompt set: a JSON array of strings, or a text file
+// with one prompt per line where blank lines and lines starting with # are
+// skipped
+func LoadPrompts(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompts: %w", err)
+	}
+
+	var prompts []string
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &prompts); err != nil {
+			return nil, fmt.Errorf("invalid prompts file %s: %w", path, err)
+		}
+	} else {
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				prompts = append(prompts, line)
+			}
+		}
+	}
+
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("no prompts in %s", path)
+	}
+	return prompts, nil
+}
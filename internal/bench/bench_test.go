@@ -0,0 +1,184 @@
+package bench
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestRun(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{
+		{Name: "llama2", Replies: ollamatest.TextReplies("one two three four"), LoadDuration: 2 * time.Second},
+		{Name: "mistral", Replies: ollamatest.TextReplies("five six")},
+	}})
+	// A retryable status that outlasts the one retry fails a single request
+	srv.Inject(ollamatest.Fault{Path: "/api/chat", Model: "mistral", Status: 503, Times: 2})
+
+	c := client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond})
+	report, err := Run(context.Background(), Options{
+		Client:       c,
+		Models:       []string{"llama2", "mistral"},
+		Prompts:      []string{"first", "second"},
+		Runs:         2,
+		Concurrency:  2,
+		Warmup:       1,
+		Cold:         true,
+		ModelOptions: map[string]interface{}{"num_predict": 16},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	llama := report.Model("llama2")
+	if llama == nil || llama.Requests != 4 || llama.Errors != 0 {
+		t.Fatalf("unexpected llama2 report: %+v", llama)
+	}
+	// The fake reports the model's LoadDuration when it was unloaded and
+	// 1ms once loaded, and 10ms per generated token
+	if llama.ColdLoadMs != 2000 || llama.WarmLoadMs != 1 {
+		t.Errorf("expected 2000ms cold and 1ms warm load, got %v and %v", llama.ColdLoadMs, llama.WarmLoadMs)
+	}
+	if llama.TokensPerSecond.P50 != 100 {
+		t.Errorf("expected 100 tok/s, got %v", llama.TokensPerSecond.P50)
+	}
+	if llama.TimeToFirstMs.P50 <= 0 || llama.TotalMs.P99 < llama.TimeToFirstMs.P50 {
+		t.Errorf("unexpected latencies: %+v %+v", llama.TimeToFirstMs, llama.TotalMs)
+	}
+
+	// The unload, cold and warmup requests are not measured; the fault
+	// fails the cold request to mistral, so no cold load is reported
+	mistral := report.Model("mistral")
+	if mistral == nil || mistral.Requests != 4 || mistral.Errors != 0 || mistral.ColdLoadMs != 0 {
+		t.Fatalf("unexpected mistral report: %+v", mistral)
+	}
+
+	for _, req := range srv.RequestsTo("/api/chat") {
+		var body ollamatest.ChatRequest
+		if err := req.Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Options["seed"] != float64(DefaultSeed) || body.Options["num_predict"] != float64(16) {
+			t.Errorf("expected the fixed seed and model options, got %v", body.Options)
+		}
+	}
+
+	var out strings.Builder
+	if err := report.WriteTable(&out); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "TTFT P95") || !strings.Contains(out.String(), "2.00s") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+}
+
+func TestRunCountsErrors(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{
+		{Name: "llama2", Replies: ollamatest.TextReplies("ok")},
+	}})
+	// The first attempt and its retry fail, so exactly one run errors
+	srv.Inject(ollamatest.Fault{Path: "/api/generate", Status: 503, Message: "server busy", Times: 2})
+
+	c := client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond})
+	report, err := Run(context.Background(), Options{Client: c, Models: []string{"llama2"}, Prompts: []string{"hi"}, Runs: 4, Generate: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	m := report.Model("llama2")
+	if m.Requests != 4 || m.Errors != 1 || m.ErrorRate != 0.25 || !strings.Contains(m.FirstError, "server busy") {
+		t.Errorf("unexpected report: %+v", m)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[100-i-1] = float64(i + 1)
+	}
+	p := percentiles(values)
+	if p.P50 != 50 || p.P95 != 95 || p.P99 != 99 || p.Mean != 50.5 {
+		t.Errorf("unexpected percentiles: %+v", p)
+	}
+	if p := percentiles([]float64{7}); p.P50 != 7 || p.P99 != 7 {
+		t.Errorf("a single value should be every percentile: %+v", p)
+	}
+	if p := percentiles(nil); p != (Percentiles{}) {
+		t.Errorf("expected zero percentiles, got %+v", p)
+	}
+}
+
+func TestBaselineCompare(t *testing.T) {
+	baseline := &Report{Models: []ModelReport{{
+		Model:           "llama2",
+		TimeToFirstMs:   Percentiles{P50: 100, P95: 200},
+		TokensPerSecond: Percentiles{P50: 50},
+		TotalMs:         Percentiles{P50: 1000, P95: 1500},
+	}}}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.SaveBaseline(path); err != nil {
+		t.Fatalf("SaveBaseline failed: %v", err)
+	}
+	loaded, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline failed: %v", err)
+	}
+
+	current := &Report{Models: []ModelReport{
+		{
+			Model:           "llama2",
+			Requests:        10,
+			Errors:          1,
+			ErrorRate:       0.1,
+			TimeToFirstMs:   Percentiles{P50: 105, P95: 300},
+			TokensPerSecond: Percentiles{P50: 40},
+			TotalMs:         Percentiles{P50: 1050, P95: 1500},
+		},
+		{Model: "phi", TimeToFirstMs: Percentiles{P50: 1}},
+	}}
+	got := map[string]bool{}
+	for _, r := range Compare(loaded, current, 0) {
+		got[r.Metric] = true
+	}
+	// 5% slower is within the default 10% threshold
+	want := map[string]bool{"ttft_p95_ms": true, "tokens_per_second_p50": true, "error_rate": true}
+	if len(got) != len(want) {
+		t.Errorf("expected regressions %v, got %v", want, got)
+	}
+	for metric := range want {
+		if !got[metric] {
+			t.Errorf("expected a %s regression, got %v", metric, got)
+		}
+	}
+
+	if regressions := Compare(loaded, current, 0.6); len(regressions) != 1 || regressions[0].Metric != "error_rate" {
+		t.Errorf("expected only the error rate past a 60%% threshold, got %v", regressions)
+	}
+}
+
+func TestLoadPrompts(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "prompts.txt")
+	os.WriteFile(text, []byte("# warm up\nfirst prompt\n\n  second prompt \n"), 0644)
+	prompts, err := LoadPrompts(text)
+	if err != nil || len(prompts) != 2 || prompts[1] != "second prompt" {
+		t.Errorf("unexpected prompts %q: %v", prompts, err)
+	}
+
+	jsonPath := filepath.Join(dir, "prompts.json")
+	os.WriteFile(jsonPath, []byte(`["one", "two\nlines"]`), 0644)
+	prompts, err = LoadPrompts(jsonPath)
+	if err != nil || len(prompts) != 2 || prompts[1] != "two\nlines" {
+		t.Errorf("unexpected prompts %q: %v", prompts, err)
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	os.WriteFile(empty, []byte("# nothing\n"), 0644)
+	if _, err := LoadPrompts(empty); err == nil {
+		t.Error("expected an error for an empty prompt set")
+	}
+}
@@ -0,0 +1,226 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultThreshold is the relative change Compare flags as a regression
+const DefaultThreshold = 0.10
+
+// Percentiles summarizes one metric across the measured requests
+type Percentiles struct {
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Mean float64 `json:"mean"`
+}
+
+// ModelReport is the summary for one model. Times are in milliseconds.
+type ModelReport struct {
+	Model           string      `json:"model"`
+	Requests        int         `json:"requests"`
+	Errors          int         `json:"errors"`
+	ErrorRate       float64     `json:"error_rate"`
+	TimeToFirstMs   Percentiles `json:"time_to_first_token_ms"`
+	TokensPerSecond Percentiles `json:"tokens_per_second"`
+	TotalMs         Percentiles `json:"total_ms"`
+	// ColdLoadMs is the load time after unloading the model; zero when not
+	// measured
+	ColdLoadMs float64 `json:"cold_load_ms,omitempty"`
+	// WarmLoadMs is the median load time of the measured requests
+	WarmLoadMs float64 `json:"warm_load_ms"`
+	// FirstError keeps one error message to explain a non-zero error rate
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// Report is the result of a benchmark, and the format of baseline files
+type Report struct {
+	Created     time.Time     `json:"created"`
+	Concurrency int           `json:"concurrency"`
+	Seed        int           `json:"seed"`
+	Models      []ModelReport `json:"models"`
+}
+
+func summarize(model string, samples []Sample, cold *Sample) *ModelReport {
+	result := &ModelReport{Model: model, Requests: len(samples)}
+	var ttft, rates, totals, loads []float64
+	for _, s := range samples {
+		if s.Err != nil {
+			result.Errors++
+			if result.FirstError == "" {
+				result.FirstError = s.Err.Error()
+			}
+			continue
+		}
+		ttft = append(ttft, ms(s.TimeToFirstToken))
+		rates = append(rates, s.TokensPerSecond())
+		totals = append(totals, ms(s.Total))
+		loads = append(loads, ms(s.Stats.LoadDuration))
+	}
+	if result.Requests > 0 {
+		result.ErrorRate = float64(result.Errors) / float64(result.Requests)
+	}
+	result.TimeToFirstMs = percentiles(ttft)
+	result.TokensPerSecond = percentiles(rates)
+	result.TotalMs = percentiles(totals)
+	result.WarmLoadMs = percentiles(loads).P50
+	if cold != nil && cold.Err == nil {
+		result.ColdLoadMs = ms(cold.Stats.LoadDuration)
+	}
+	return result
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// percentiles uses the nearest-rank method, so every reported value is one
+// that was actually measured
+func percentiles(values []float64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	return Percentiles{P50: rank(50), P95: rank(95), P99: rank(99), Mean: sum / float64(len(sorted))}
+}
+
+// WriteTable prints the report as an aligned table
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tREQS\tERRORS\tTTFT P50\tTTFT P95\tTTFT P99\tTOK/S P50\tTOTAL P50\tTOTAL P95\tTOTAL P99\tLOAD COLD\tLOAD WARM")
+	for _, m := range r.Models {
+		cold := "-"
+		if m.ColdLoadMs > 0 {
+			cold = formatMs(m.ColdLoadMs)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%s\t%s\t%s\t%.1f\t%s\t%s\t%s\t%s\t%s\n",
+			m.Model, m.Requests, m.ErrorRate*100,
+			formatMs(m.TimeToFirstMs.P50), formatMs(m.TimeToFirstMs.P95), formatMs(m.TimeToFirstMs.P99),
+			m.TokensPerSecond.P50,
+			formatMs(m.TotalMs.P50), formatMs(m.TotalMs.P95), formatMs(m.TotalMs.P99),
+			cold, formatMs(m.WarmLoadMs))
+	}
+	return tw.Flush()
+}
+
+func formatMs(v float64) string {
+	if v >= 1000 {
+		return fmt.Sprintf("%.2fs", v/1000)
+	}
+	return fmt.Sprintf("%.0fms", v)
+}
+
+// Model returns the summary for a model, or nil
+func (r *Report) Model(name string) *ModelReport {
+	for i := range r.Models {
+		if r.Models[i].Model == name {
+			return &r.Models[i]
+		}
+	}
+	return nil
+}
+
+// SaveBaseline writes the report as JSON for later comparison
+func (r *Report) SaveBaseline(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// LoadBaseline reads a report saved with SaveBaseline
+func LoadBaseline(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Regression is a metric that got worse than the baseline by more than the
+// threshold
+type Regression struct {
+	Model    string  `json:"model"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	// Change is relative to the baseline, positive when the metric got worse
+	Change float64 `json:"change"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %s %.1f -> %.1f (%.0f%% worse)", r.Model, r.Metric, r.Baseline, r.Current, r.Change*100)
+}
+
+// Compare flags metrics of current that are worse than baseline by more
+// than threshold (a fraction, DefaultThreshold when zero or negative).
+// Models missing from the baseline are skipped.
+func Compare(baseline, current *Report, threshold float64) []Regression {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+
+	var regressions []Regression
+	for _, cur := range current.Models {
+		base := baseline.Model(cur.Model)
+		if base == nil {
+			continue
+		}
+		check := func(metric string, old, new float64, higherIsWorse bool) {
+			if old <= 0 {
+				return
+			}
+			change := (new - old) / old
+			if !higherIsWorse {
+				change = -change
+			}
+			if change > threshold {
+				regressions = append(regressions, Regression{Model: cur.Model, Metric: metric, Baseline: old, Current: new, Change: change})
+			}
+		}
+		check("ttft_p50_ms", base.TimeToFirstMs.P50, cur.TimeToFirstMs.P50, true)
+		check("ttft_p95_ms", base.TimeToFirstMs.P95, cur.TimeToFirstMs.P95, true)
+		check("tokens_per_second_p50", base.TokensPerSecond.P50, cur.TokensPerSecond.P50, false)
+		check("total_p50_ms", base.TotalMs.P50, cur.TotalMs.P50, true)
+		check("total_p95_ms", base.TotalMs.P95, cur.TotalMs.P95, true)
+		check("cold_load_ms", base.ColdLoadMs, cur.ColdLoadMs, true)
+		// Any new errors count, whatever the threshold
+		if cur.ErrorRate > base.ErrorRate {
+			regressions = append(regressions, Regression{
+				Model:    cur.Model,
+				Metric:   "error_rate",
+				Baseline: base.ErrorRate,
+				Current:  cur.ErrorRate,
+				Change:   cur.ErrorRate - base.ErrorRate,
+			})
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,244 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/modelfile"
+	"ollamacli/internal/session"
+)
+
+const (
+	UndoCommand   = "/undo"
+	RetryCommand  = "/retry"
+	EditCommand   = "/edit"
+	BranchCommand = "/branch"
+)
+
+// Branch is one line of a conversation, kept in transcripts and sessions
+type Branch = session.Branch
+
+// syncBranch records the active history in the current branch, starting
+// the tree on first use
+func (ic *InteractiveChat) syncBranch() {
+	if len(ic.branches) == 0 {
+		ic.branches = []Branch{{ID: 1}}
+		ic.branch = 1
+	}
+	ic.branches[ic.branch-1].Messages = ic.GetHistory()
+}
+
+// lastUserIndex returns the index of the latest user message, or -1
+func (ic *InteractiveChat) lastUserIndex() int {
+	for i := len(ic.messages) - 1; i >= 0; i-- {
+		if ic.messages[i].Role == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
+// userIndex returns the index of the nth user message, counting from 1
+func (ic *InteractiveChat) userIndex(n int) int {
+	for i, msg := range ic.messages {
+		if msg.Role == "user" {
+			n--
+			if n == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// undo drops the last exchange: the latest user message and everything after it
+func (ic *InteractiveChat) undo() error {
+	i := ic.lastUserIndex()
+	if i < 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	prompt := ic.messages[i].Content
+	ic.messages = ic.messages[:i]
+	if n := len(ic.turnEndpoints); n > 0 {
+		ic.turnEndpoints = ic.turnEndpoints[:n-1]
+	}
+	_, err := fmt.Fprintf(ic.writer, "Removed the last exchange: %q\n", preview(prompt))
+	return err
+}
+
+// retry regenerates the last answer, optionally with another model or
+// temperature for this one reply: /retry [model] [temperature]
+func (ic *InteractiveChat) retry(ctx context.Context, args []string) error {
+	var model string
+	var temperature interface{}
+	for _, arg := range args {
+		if _, err := strconv.ParseFloat(arg, 64); err == nil && temperature == nil {
+			// A number is the temperature, validated as /set does
+			t, err := modelfile.ParseParameter("temperature", arg)
+			if err != nil {
+				return err
+			}
+			temperature = t
+		} else if model == "" {
+			model = arg
+		} else {
+			return fmt.Errorf("usage: /retry [model] [temperature]")
+		}
+	}
+
+	i := ic.lastUserIndex()
+	if i < 0 {
+		return fmt.Errorf("nothing to retry")
+	}
+
+	if model != "" && model != ic.model {
+		if ic.client != nil {
+			if err := ic.ensureModel(ctx, model); err != nil {
+				return err
+			}
+		}
+		defer func(previous string) { ic.model = previous }(ic.model)
+		ic.model = model
+	}
+	if temperature != nil {
+		defer func(previous map[string]interface{}) { ic.options = previous }(ic.options)
+		options := map[string]interface{}{"temperature": temperature}
+		for key, value := range ic.options {
+			if key != "temperature" {
+				options[key] = value
+			}
+		}
+		ic.options = options
+	}
+
+	return ic.regenerate(ctx, i, ic.messages[i])
+}
+
+// edit rewrites the nth user message and regenerates from there. Without
+// arguments it lists the user messages to choose from.
+func (ic *InteractiveChat) edit(ctx context.Context, command string) error {
+	rest := strings.TrimSpace(strings.TrimPrefix(command, EditCommand))
+	if rest == "" {
+		n := 0
+		for _, msg := range ic.messages {
+			if msg.Role == "user" {
+				n++
+				fmt.Fprintf(ic.writer, "  %d: %s\n", n, preview(msg.Content))
+			}
+		}
+		if n == 0 {
+			return fmt.Errorf("no messages to edit")
+		}
+		_, err := fmt.Fprintln(ic.writer, "Use /edit <number> <new text> to rewrite a message.")
+		return err
+	}
+
+	number, text, _ := strings.Cut(rest, " ")
+	n, err := strconv.Atoi(number)
+	text = strings.TrimSpace(text)
+	if err != nil || text == "" {
+		return fmt.Errorf("usage: /edit <number> <new text>")
+	}
+	i := ic.userIndex(n)
+	if i < 0 {
+		return fmt.Errorf("no user message %d", n)
+	}
+
+	msg := ic.messages[i]
+	msg.Content = text
+	return ic.regenerate(ctx, i, msg)
+}
+
+// regenerate forks a branch that replaces the history from index at with
+// msg and asks the model to answer it. If the reply fails, the
+// conversation is left as it was.
+func (ic *InteractiveChat) regenerate(ctx context.Context, at int, msg client.ChatMessage) error {
+	ic.syncBranch()
+	branches, branch, messages := append([]Branch(nil), ic.branches...), ic.branch, ic.messages
+
+	// Alternatives for the same message are siblings: attach to the
+	// furthest ancestor that still shares the history before at
+	parent := ic.branch
+	for b := ic.branches[parent-1]; b.Parent != 0 && b.ForkAt >= at; b = ic.branches[parent-1] {
+		parent = b.Parent
+	}
+	ic.branches = append(ic.branches, Branch{ID: len(ic.branches) + 1, Parent: parent, ForkAt: at})
+	ic.branch = len(ic.branches)
+	ic.messages = append(append(make([]client.ChatMessage, 0, at+1), ic.messages[:at]...), msg)
+
+	if err := ic.completeTurn(ctx, at+1); err != nil {
+		ic.branches, ic.branch, ic.messages = branches, branch, messages
+		return err
+	}
+	ic.syncBranch()
+	return nil
+}
+
+// branchCommand handles /branch list|checkout <id>
+func (ic *InteractiveChat) branchCommand(args []string) error {
+	switch {
+	case len(args) == 0 || (args[0] == "list" && len(args) == 1):
+		return ic.branchList()
+	case args[0] == "checkout" && len(args) == 2:
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id < 1 || id > len(ic.branches) {
+			return fmt.Errorf("no branch %s (see /branch list)", args[1])
+		}
+		ic.syncBranch()
+		ic.branch = id
+		ic.SetHistory(ic.branches[id-1].Messages)
+		_, err = fmt.Fprintf(ic.writer, "Switched to branch %d (%d messages)\n", id, len(ic.messages))
+		return err
+	}
+	return fmt.Errorf("usage: /branch [list] | checkout <id>")
+}
+
+func (ic *InteractiveChat) branchList() error {
+	if len(ic.branches) == 0 {
+		_, err := fmt.Fprintln(ic.writer, "No branches yet; /retry and /edit create them.")
+		return err
+	}
+	ic.syncBranch()
+
+	// Children are listed under their parent, indented by depth
+	var walk func(parent, depth int)
+	walk = func(parent, depth int) {
+		for _, b := range ic.branches {
+			if b.Parent != parent {
+				continue
+			}
+			marker := " "
+			if b.ID == ic.branch {
+				marker = "*"
+			}
+			origin := "original"
+			if b.Parent != 0 {
+				origin = fmt.Sprintf("from %d at message %d", b.Parent, b.ForkAt+1)
+			}
+			last := ""
+			for i := len(b.Messages) - 1; i >= 0; i-- {
+				if b.Messages[i].Role == "assistant" && b.Messages[i].Content != "" {
+					last = ": " + strconv.Quote(preview(b.Messages[i].Content))
+					break
+				}
+			}
+			fmt.Fprintf(ic.writer, "%s %s%d  %s, %d messages%s\n", marker, strings.Repeat("  ", depth), b.ID, origin, len(b.Messages), last)
+			walk(b.ID, depth+1)
+		}
+	}
+	walk(0, 0)
+	_, err := fmt.Fprintln(ic.writer)
+	return err
+}
+
+// preview shortens a message to one line for listings
+func preview(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 50 {
+		return string(r[:47]) + "..."
+	}
+	return s
+}
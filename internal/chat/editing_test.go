@@ -0,0 +1,180 @@
+package chat
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestUndoCommand(t *testing.T) {
+	var out strings.Builder
+	ic := &InteractiveChat{
+		writer: &out,
+		messages: []client.ChatMessage{
+			{Role: "user", Content: "Q1"},
+			{Role: "assistant", Content: "A1"},
+			{Role: "user", Content: "Q2"},
+			{Role: "assistant", ToolCalls: []client.ToolCall{{}}},
+			{Role: "tool", Content: "result"},
+			{Role: "assistant", Content: "A2"},
+		},
+	}
+
+	if err := ic.handleCommand(context.Background(), "/undo"); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if len(ic.messages) != 2 || !strings.Contains(out.String(), `"Q2"`) {
+		t.Errorf("expected the whole last exchange removed, got %+v (%q)", ic.messages, out.String())
+	}
+	ic.handleCommand(context.Background(), "/undo")
+	if err := ic.handleCommand(context.Background(), "/undo"); err == nil {
+		t.Error("expected an error with nothing left to undo")
+	}
+}
+
+func TestRetryEditAndBranches(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{
+		{Name: "llama2", Replies: ollamatest.TextReplies("A1", "A2", "A3")},
+		{Name: "mistral", Replies: ollamatest.TextReplies("M1")},
+	}})
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond}),
+		writer:   &out,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+	}
+	last := func() string { return ic.messages[len(ic.messages)-1].Content }
+
+	if err := ic.sendMessage(context.Background(), "Q1"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	if err := ic.handleCommand(context.Background(), "/retry"); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if len(ic.messages) != 2 || last() != "A2" || ic.branch != 2 {
+		t.Fatalf("expected the answer replaced on branch 2, got %+v on %d", ic.messages, ic.branch)
+	}
+
+	// Another model and temperature apply to the retried reply only
+	if err := ic.handleCommand(context.Background(), "/retry mistral 0.9"); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	req, _ := srv.LastRequest("/api/chat")
+	var body ollamatest.ChatRequest
+	req.Decode(&body)
+	if body.Model != "mistral" || body.Options["temperature"] != 0.9 || last() != "M1" {
+		t.Errorf("unexpected retry request: %+v", body)
+	}
+	if ic.model != "llama2" || ic.options != nil {
+		t.Errorf("retry overrides should not stick: model=%s options=%v", ic.model, ic.options)
+	}
+
+	// The temperature is validated before anything is sent
+	requests := len(srv.RequestsTo("/api/chat"))
+	for _, bad := range []string{"/retry -3", "/retry nan", "/retry mistral +Inf"} {
+		if err := ic.handleCommand(context.Background(), bad); err == nil || !strings.Contains(err.Error(), "temperature") {
+			t.Errorf("%s: expected an invalid temperature error, got %v", bad, err)
+		}
+	}
+	if len(srv.RequestsTo("/api/chat")) != requests || last() != "M1" {
+		t.Errorf("invalid retry reached the server or changed the conversation")
+	}
+
+	if err := ic.handleCommand(context.Background(), "/edit 1 Q1 rephrased"); err != nil {
+		t.Fatalf("edit failed: %v", err)
+	}
+	if ic.messages[0].Content != "Q1 rephrased" || last() != "A3" || ic.branch != 4 {
+		t.Fatalf("expected the edited question answered on branch 4, got %+v", ic.messages)
+	}
+
+	out.Reset()
+	if err := ic.handleCommand(context.Background(), "/branch list"); err != nil {
+		t.Fatalf("branch list failed: %v", err)
+	}
+	for _, want := range []string{"  1  original, 2 messages: \"A1\"", "    2  from 1 at message 1", "    3  from 1 at message 1", "*   4  from 1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in branch list:\n%s", want, out.String())
+		}
+	}
+
+	if err := ic.handleCommand(context.Background(), "/branch checkout 1"); err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+	if last() != "A1" || ic.messages[0].Content != "Q1" {
+		t.Errorf("expected the original conversation, got %+v", ic.messages)
+	}
+
+	// A failed retry leaves the conversation and branches as they were
+	srv.Inject(ollamatest.Fault{Path: "/api/chat", Status: 400, Times: 1})
+	if err := ic.handleCommand(context.Background(), "/retry"); err == nil {
+		t.Fatal("expected the retry to fail")
+	}
+	if last() != "A1" || len(ic.branches) != 4 || ic.branch != 1 {
+		t.Errorf("failed retry changed the conversation: %+v, %d branches", ic.messages, len(ic.branches))
+	}
+
+	// Saved transcripts keep the whole tree
+	path := filepath.Join(t.TempDir(), "tree.json")
+	if err := ic.handleCommand(context.Background(), "/save "+path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded := &InteractiveChat{writer: &out}
+	if err := loaded.loadHistory(context.Background(), path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded.branches) != 4 || loaded.branch != 1 || loaded.branches[3].Messages[0].Content != "Q1 rephrased" {
+		t.Errorf("branches not restored: %+v", loaded.branches)
+	}
+	if err := loaded.handleCommand(context.Background(), "/branch checkout 4"); err != nil || loaded.messages[1].Content != "A3" {
+		t.Errorf("expected to check out a restored branch, got %+v: %v", loaded.messages, err)
+	}
+}
+
+func TestRetryStopsWithREPLContext(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2", Replies: ollamatest.TextReplies("A1", "A2")}}})
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond}),
+		writer:   &out,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+	}
+	if err := ic.sendMessage(context.Background(), "Q1"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	// Ctrl+C cancels the REPL context; regenerating must not outlive it
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, cmd := range []string{"/retry", "/edit 1 Q1 again"} {
+		if err := ic.handleCommand(ctx, cmd); err == nil {
+			t.Errorf("expected %s to stop with the cancelled context", cmd)
+		}
+	}
+	if len(ic.messages) != 2 || ic.messages[1].Content != "A1" || len(srv.RequestsTo("/api/chat")) != 1 {
+		t.Errorf("cancelled commands changed the conversation: %+v", ic.messages)
+	}
+}
+
+func TestEditCommandUsage(t *testing.T) {
+	var out strings.Builder
+	ic := &InteractiveChat{
+		writer:   &out,
+		messages: []client.ChatMessage{{Role: "user", Content: "First"}, {Role: "assistant", Content: "Reply"}},
+	}
+	if err := ic.handleCommand(context.Background(), "/edit"); err != nil || !strings.Contains(out.String(), "1: First") {
+		t.Errorf("expected the user messages listed, got %q: %v", out.String(), err)
+	}
+	if err := ic.handleCommand(context.Background(), "/edit 2 Other"); err == nil {
+		t.Error("expected an error for a missing message")
+	}
+	if err := ic.handleCommand(context.Background(), "/edit one Other"); err == nil {
+		t.Error("expected a usage error")
+	}
+}
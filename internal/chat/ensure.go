@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/peterh/liner"
+	"ollamacli/internal/client"
+)
+
+// EnsureOptions controls what EnsureModel does when a model is missing
+type EnsureOptions struct {
+	Client client.Provider
+	Writer io.Writer
+	// AutoPull pulls missing models without asking
+	AutoPull bool
+	// Confirm asks the user whether to pull; nil means nobody can be asked
+	Confirm func(question string) (bool, error)
+	// TTY repaints pull progress in place
+	TTY bool
+}
+
+// EnsureModel checks that model is installed and, if it is not, offers to
+// pull it (or pulls it straight away with AutoPull). It returns an error
+// matching client.ErrModelNotFound when the model is still missing.
+func EnsureModel(ctx context.Context, model string, opts EnsureOptions) error {
+	installed, err := client.HasModel(ctx, opts.Client, model)
+	if err != nil {
+		return fmt.Errorf("failed to check installed models: %w", err)
+	}
+	if installed {
+		return nil
+	}
+
+	switch {
+	case opts.AutoPull:
+		fmt.Fprintf(opts.Writer, "Model %s is not installed, pulling it now.\n", model)
+	case opts.Confirm != nil:
+		pull, err := opts.Confirm(fmt.Sprintf("Model %s is not installed. Pull it now? [y/N] ", model))
+		if err != nil || !pull {
+			return fmt.Errorf("%w: %s is not installed", client.ErrModelNotFound, model)
+		}
+	default:
+		return fmt.Errorf("%w: %s is not installed (run 'ollamacli pull %s' or pass --auto-pull)",
+			client.ErrModelNotFound, model, model)
+	}
+
+	return pullWithProgress(ctx, opts.Client, opts.Writer, opts.TTY, model)
+}
+
+// pullWithProgress pulls models, rendering their progress
+func pullWithProgress(ctx context.Context, c client.Provider, w io.Writer, tty bool, models ...string) error {
+	fmt.Fprintf(w, "Pulling: %s\n", strings.Join(models, ", "))
+
+	if err := PullModels(ctx, models, PullOptions{Client: c, Writer: w, TTY: tty}); err != nil {
+		return err
+	}
+
+	if len(models) == 1 {
+		fmt.Fprintf(w, "Model pulled successfully!\n\n")
+	} else {
+		fmt.Fprintf(w, "%d models pulled successfully!\n\n", len(models))
+	}
+	return nil
+}
+
+// promptYesNo asks a yes/no question on the REPL input and reports whether
+// the answer was yes
+func promptYesNo(line *liner.State, reader *bufio.Reader, w io.Writer, isTTY bool, question string) (bool, error) {
+	var answer string
+	var err error
+
+	if isTTY && line != nil {
+		answer, err = line.Prompt(question)
+	} else if reader != nil {
+		fmt.Fprint(w, question)
+		answer, err = reader.ReadString('\n')
+		if err == io.EOF && answer != "" {
+			err = nil
+		}
+	} else {
+		return false, fmt.Errorf("no input available for confirmation")
+	}
+	if err != nil {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
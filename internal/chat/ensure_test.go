@@ -0,0 +1,175 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+)
+
+// newModelServer serves /api/tags with the installed models and records
+// pulls, which install the model
+func newModelServer(t *testing.T, installed ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var pulled []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			models := make([]client.Model, 0, len(installed))
+			for _, name := range installed {
+				models = append(models, client.Model{Name: name})
+			}
+			json.NewEncoder(w).Encode(client.ListModelsResponse{Models: models})
+		case "/api/pull":
+			var req client.PullRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			pulled = append(pulled, req.Name)
+			installed = append(installed, req.Name)
+			encoder := json.NewEncoder(w)
+			encoder.Encode(client.PullResponse{Status: "downloading", Total: 10, Completed: 10})
+			encoder.Encode(client.PullResponse{Status: "success"})
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &pulled
+}
+
+func TestEnsureModelInstalled(t *testing.T) {
+	server, pulled := newModelServer(t, "llama3:latest")
+
+	var out strings.Builder
+	err := EnsureModel(context.Background(), "llama3", EnsureOptions{
+		Client: client.New(client.Options{BaseURL: server.URL}),
+		Writer: &out,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(*pulled) != 0 || out.Len() != 0 {
+		t.Errorf("Expected nothing to happen for an installed model, pulled=%v output=%q", *pulled, out.String())
+	}
+}
+
+func TestEnsureModelMissingWithoutPrompt(t *testing.T) {
+	server, pulled := newModelServer(t)
+
+	var out strings.Builder
+	err := EnsureModel(context.Background(), "llama3", EnsureOptions{
+		Client: client.New(client.Options{BaseURL: server.URL}),
+		Writer: &out,
+	})
+	if !errors.Is(err, client.ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "--auto-pull") {
+		t.Errorf("Expected hint about --auto-pull, got: %v", err)
+	}
+	if len(*pulled) != 0 {
+		t.Errorf("Expected no pull, got %v", *pulled)
+	}
+}
+
+func TestEnsureModelAutoPull(t *testing.T) {
+	server, pulled := newModelServer(t)
+
+	var out strings.Builder
+	err := EnsureModel(context.Background(), "llama3", EnsureOptions{
+		Client:   client.New(client.Options{BaseURL: server.URL}),
+		Writer:   &out,
+		AutoPull: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(*pulled) != 1 || (*pulled)[0] != "llama3" {
+		t.Errorf("Expected llama3 to be pulled, got %v", *pulled)
+	}
+	if !strings.Contains(out.String(), "Model pulled successfully") {
+		t.Errorf("Expected pull progress, got: %s", out.String())
+	}
+}
+
+func TestEnsureModelConfirm(t *testing.T) {
+	server, pulled := newModelServer(t)
+	c := client.New(client.Options{BaseURL: server.URL})
+
+	var asked string
+	err := EnsureModel(context.Background(), "llama3", EnsureOptions{
+		Client: c,
+		Writer: &strings.Builder{},
+		Confirm: func(question string) (bool, error) {
+			asked = question
+			return false, nil
+		},
+	})
+	if !errors.Is(err, client.ErrModelNotFound) {
+		t.Errorf("Expected ErrModelNotFound after declining, got: %v", err)
+	}
+	if !strings.Contains(asked, "Pull it now?") {
+		t.Errorf("Expected pull prompt, got %q", asked)
+	}
+
+	err = EnsureModel(context.Background(), "llama3", EnsureOptions{
+		Client:  c,
+		Writer:  &strings.Builder{},
+		Confirm: func(string) (bool, error) { return true, nil },
+	})
+	if err != nil {
+		t.Fatalf("Expected no error after accepting, got: %v", err)
+	}
+	if len(*pulled) != 1 {
+		t.Errorf("Expected one pull, got %v", *pulled)
+	}
+}
+
+func TestModelUseRejectsMissingModel(t *testing.T) {
+	server, _ := newModelServer(t, "llama3:latest")
+
+	ic := &InteractiveChat{
+		client: client.New(client.Options{BaseURL: server.URL}),
+		writer: &strings.Builder{},
+		model:  "llama3",
+	}
+
+	if err := ic.handleCommand(context.Background(), "/model use mistral"); !errors.Is(err, client.ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+	}
+	if ic.model != "llama3" {
+		t.Errorf("Expected model to stay llama3, got %s", ic.model)
+	}
+}
+
+func TestAutoPullStopsWithREPLContext(t *testing.T) {
+	server, pulled := newModelServer(t, "llama3:latest")
+	path := filepath.Join(t.TempDir(), "chat.json")
+	(&Transcript{Version: TranscriptVersion, Model: "phi3"}).Save(path)
+
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: server.URL, Retries: 1}),
+		writer:   &strings.Builder{},
+		model:    "llama3",
+		autoPull: true,
+	}
+
+	// Ctrl+C cancels the REPL context; a pull it offers must stop with it
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, cmd := range []string{"/model use mistral", "/load " + path} {
+		if err := ic.handleCommand(ctx, cmd); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %s to stop with the context, got %v", cmd, err)
+		}
+	}
+	if len(*pulled) != 0 || ic.model != "llama3" {
+		t.Errorf("expected no pull and no model change, got pulled=%v model=%s", *pulled, ic.model)
+	}
+}
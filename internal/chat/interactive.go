@@ -3,37 +3,43 @@ package chat
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/peterh/liner"
 	"golang.org/x/term"
 	"ollamacli/internal/client"
 	"ollamacli/internal/log"
 	"ollamacli/internal/output"
+	"ollamacli/internal/persona"
+	"ollamacli/internal/session"
 )
 
 const (
-	DefaultPrompt    = "> "
-	ExitCommand      = "/exit"
-	HelpCommand      = "/help"
-	ClearCommand     = "/clear"
-	SaveCommand      = "/save"
-	LoadCommand      = "/load"
-	ModelListCommand = "/model list"
-	ModelPullCommand = "/model pull"
-	ModelShowCommand = "/model show"
-	ModelUseCommand  = "/model use"
-	StatusCommand    = "/status"
+	DefaultPrompt      = "> "
+	ExitCommand        = "/exit"
+	HelpCommand        = "/help"
+	ClearCommand       = "/clear"
+	SaveCommand        = "/save"
+	LoadCommand        = "/load"
+	ModelListCommand   = "/model list"
+	ModelPullCommand   = "/model pull"
+	ModelShowCommand   = "/model show"
+	ModelUseCommand    = "/model use"
+	ModelDiffCommand   = "/model diff"
+	ModelUnloadCommand = "/model unload"
+	StatusCommand      = "/status"
+	StatsCommand       = "/stats"
+	ImageCommand       = "/image"
 )
 
 type InteractiveChat struct {
-	client    *client.Client
+	client    client.Provider
 	formatter output.Formatter
 	logger    log.Logger
 	model     string
@@ -43,16 +49,80 @@ type InteractiveChat struct {
 	writer    io.Writer
 	prompt    string
 	isTTY     bool
+	tools     *ToolRegistry
+	maxRounds int
+	images    []string
+	autoPull  bool
+	keepAlive func(model string) client.KeepAlive
+	// think is the /think mode: "", on, off or hide
+	think string
+	// showStats prints token counts and timings after each reply
+	showStats bool
+	// session totals the stats of every reply, shown by /status
+	session        client.Stats
+	sessionReplies int
+	// turnEndpoints records the server that answered each turn
+	turnEndpoints []string
+	// options are the model parameters sent with every request
+	options map[string]interface{}
+	// sessions autosaves the conversation into current; nil disables it
+	sessions *session.Store
+	current  *session.Session
+	// branches are the alternatives kept by /retry and /edit; branch is the
+	// ID of the one in messages. Both are empty until the first fork.
+	branches []Branch
+	branch   int
+	// created is when the conversation started, kept across saves; zero
+	// until it is first saved
+	created time.Time
+	// persona is the one chosen with --persona or /persona use, loaded
+	// from personaDir
+	persona    *persona.Persona
+	personaDir string
 }
 
 type Options struct {
-	Client    *client.Client
+	Client    client.Provider
 	Formatter output.Formatter
 	Logger    log.Logger
 	Model     string
 	Writer    io.Writer
 	Reader    io.Reader
 	Prompt    string
+	// Tools enables tool calling; nil disables it
+	Tools *ToolRegistry
+	// MaxToolRounds limits tool round-trips per user message (default: 8)
+	MaxToolRounds int
+	// AutoPull pulls a missing model without asking
+	AutoPull bool
+	// KeepAlive returns how long to keep a model loaded after each request,
+	// typically from --keep-alive or the config file; nil or an empty value
+	// leaves it to the server
+	KeepAlive func(model string) client.KeepAlive
+	// Think is the initial thinking mode: on, off, hide, or empty for the
+	// server default
+	Think string
+	// Stats prints token counts and timings after each reply
+	Stats bool
+	// ModelOptions are the model parameters sent with every request
+	ModelOptions map[string]interface{}
+	// Resume starts from a saved transcript (chat --resume); its model,
+	// options and thinking mode are used unless Model, ModelOptions or
+	// Think is set
+	Resume *Transcript
+	// Sessions autosaves every turn; nil disables sessions
+	Sessions *session.Store
+	// Session continues a stored session (chat --continue); like Resume,
+	// its settings are used unless set here
+	Session *session.Session
+	// System is the system prompt (--system or --system-file); it takes
+	// precedence over the persona's and a resumed one
+	System string
+	// Persona supplies the system prompt, model and options (--persona);
+	// Model, ModelOptions and System override its settings
+	Persona *persona.Persona
+	// PersonaDir is where /persona finds personas; empty disables it
+	PersonaDir string
 }
 
 func NewInteractiveChat(opts Options) *InteractiveChat {
@@ -65,6 +135,9 @@ func NewInteractiveChat(opts Options) *InteractiveChat {
 	if opts.Prompt == "" {
 		opts.Prompt = DefaultPrompt
 	}
+	if opts.MaxToolRounds == 0 {
+		opts.MaxToolRounds = defaultMaxToolRounds
+	}
 
 	// Check if stdin is a TTY
 	isTTY := term.IsTerminal(int(os.Stdin.Fd()))
@@ -86,18 +159,59 @@ func NewInteractiveChat(opts Options) *InteractiveChat {
 		reader = bufio.NewReader(opts.Reader)
 	}
 
-	return &InteractiveChat{
-		client:    opts.Client,
-		formatter: opts.Formatter,
-		logger:    opts.Logger,
-		model:     opts.Model,
-		messages:  make([]client.ChatMessage, 0),
-		line:      line,
-		reader:    reader,
-		writer:    opts.Writer,
-		prompt:    opts.Prompt,
-		isTTY:     isTTY,
+	ic := &InteractiveChat{
+		client:     opts.Client,
+		formatter:  opts.Formatter,
+		logger:     opts.Logger,
+		model:      opts.Model,
+		messages:   make([]client.ChatMessage, 0),
+		line:       line,
+		reader:     reader,
+		writer:     opts.Writer,
+		prompt:     opts.Prompt,
+		isTTY:      isTTY,
+		tools:      opts.Tools,
+		maxRounds:  opts.MaxToolRounds,
+		autoPull:   opts.AutoPull,
+		keepAlive:  opts.KeepAlive,
+		think:      opts.Think,
+		showStats:  opts.Stats,
+		options:    opts.ModelOptions,
+		sessions:   opts.Sessions,
+		persona:    opts.Persona,
+		personaDir: opts.PersonaDir,
+	}
+	system := opts.System
+	if p := opts.Persona; p != nil {
+		if p.Model != "" {
+			ic.model = p.Model
+		}
+		if ic.options == nil {
+			ic.options = p.Options
+		}
+		if system == "" {
+			system = p.System
+		}
+	}
+	if opts.Resume != nil {
+		ic.Resume(opts.Resume)
+	}
+	if opts.Session != nil {
+		ic.openSession(opts.Session)
+	}
+	if system != "" {
+		ic.messages = withSystemPrompt(ic.messages, system)
+	}
+	if opts.Model != "" {
+		ic.model = opts.Model
+	}
+	if opts.ModelOptions != nil {
+		ic.options = opts.ModelOptions
 	}
+	if opts.Think != "" {
+		ic.think = opts.Think
+	}
+	return ic
 }
 
 func (ic *InteractiveChat) Start(ctx context.Context) error {
@@ -123,12 +237,25 @@ func (ic *InteractiveChat) Start(ctx context.Context) error {
 		cancel()
 	}()
 
+	// Make sure the model exists before the first message is sent
+	if err := ic.ensureModel(ctx, ic.model); err != nil {
+		return err
+	}
+
 	// Welcome message with colors (only if TTY)
 	if ic.isTTY {
 		fmt.Fprintf(ic.writer, "\033[1;36mInteractive mode with %s\033[0m (type \033[1;33m/help\033[0m for commands, Ctrl+C to exit)\n\n", ic.model)
 	} else {
 		fmt.Fprintf(ic.writer, "Interactive mode with %s (type /help for commands, Ctrl+C to exit)\n\n", ic.model)
 	}
+	if ic.current != nil {
+		fmt.Fprintf(ic.writer, "Continuing session %s (%d messages).\n\n", ic.current.Name, len(ic.messages))
+	} else if len(ic.messages) > 0 {
+		fmt.Fprintf(ic.writer, "Resumed a conversation of %d messages.\n\n", len(ic.messages))
+	}
+	if ic.persona != nil && ic.persona.Greeting != "" {
+		fmt.Fprintf(ic.writer, "%s\n\n", ic.persona.Greeting)
+	}
 
 	// Main chat loop
 	for {
@@ -186,9 +313,10 @@ func (ic *InteractiveChat) Start(ctx context.Context) error {
 
 		// Handle special commands
 		if strings.HasPrefix(input, "/") {
-			if err := ic.handleCommand(input); err != nil {
+			if err := ic.handleCommand(ctx, input); err != nil {
 				fmt.Fprintf(ic.writer, "\033[1;31mError:\033[0m %v\n", err)
 			}
+			ic.autosave()
 			continue
 		}
 
@@ -200,7 +328,7 @@ func (ic *InteractiveChat) Start(ctx context.Context) error {
 	}
 }
 
-func (ic *InteractiveChat) handleCommand(command string) error {
+func (ic *InteractiveChat) handleCommand(ctx context.Context, command string) error {
 	parts := strings.Fields(command)
 	if len(parts) == 0 {
 		return nil
@@ -224,21 +352,68 @@ func (ic *InteractiveChat) handleCommand(command string) error {
 		return ic.modelList()
 	case fullCmd == ModelPullCommand:
 		if len(parts) < 3 {
-			return fmt.Errorf("usage: /model pull <model_name>")
+			return fmt.Errorf("usage: /model pull <model_name>...")
 		}
-		return ic.modelPull(parts[2])
+		return ic.modelPull(ctx, parts[2:])
 	case fullCmd == ModelUseCommand:
 		if len(parts) < 3 {
 			return fmt.Errorf("usage: /model use <model_name>")
 		}
-		return ic.modelUse(parts[2])
+		return ic.modelUse(ctx, parts[2])
 	case fullCmd == ModelShowCommand:
 		if len(parts) < 3 {
 			return fmt.Errorf("usage: /model show <model_name>")
 		}
 		return ic.modelShow(parts[2])
+	case fullCmd == ModelDiffCommand:
+		if len(parts) != 4 {
+			return fmt.Errorf("usage: /model diff <model_a> <model_b>")
+		}
+		return ic.modelDiff(ctx, parts[2], parts[3])
+	case fullCmd == ModelUnloadCommand:
+		if len(parts) > 3 {
+			return fmt.Errorf("usage: /model unload [model_name]")
+		}
+		model := ic.model
+		if len(parts) == 3 {
+			model = parts[2]
+		}
+		return ic.modelUnload(ctx, model)
 	case cmd == StatusCommand:
 		return ic.showStatus()
+	case cmd == ThinkCommand:
+		return ic.thinkCommand(args)
+	case cmd == StatsCommand:
+		return ic.statsCommand(args)
+	case cmd == SessionCommand:
+		return ic.sessionCommand(ctx, args)
+	case cmd == UndoCommand:
+		if len(args) > 0 {
+			return fmt.Errorf("usage: /undo")
+		}
+		return ic.undo()
+	case cmd == RetryCommand:
+		return ic.retry(ctx, args)
+	case cmd == EditCommand:
+		return ic.edit(ctx, command)
+	case cmd == BranchCommand:
+		return ic.branchCommand(args)
+	case cmd == SetCommand || cmd == ShowCommand || cmd == ResetCommand:
+		options, err := optionsCommand(ic.writer, ic.options, command)
+		ic.options = options
+		return err
+	case cmd == SystemCommand:
+		messages, err := systemCommand(ic.writer, ic.messages, command)
+		ic.messages = messages
+		return err
+	case cmd == PersonaCommand:
+		return ic.personaCommand(ctx, args)
+	case cmd == ImageCommand:
+		path := strings.TrimSpace(strings.TrimPrefix(command, ImageCommand))
+		if path == "" {
+			return fmt.Errorf("usage: /image <path>")
+		}
+		return ic.attachImage(ctx, path)
 	case cmd == SaveCommand:
 		return ic.saveCommand(args)
 	case cmd == LoadCommand:
@@ -246,7 +421,7 @@ func (ic *InteractiveChat) handleCommand(command string) error {
 		if len(args) > 0 {
 			filename = args[0]
 		}
-		return ic.loadHistory(filename)
+		return ic.loadHistory(ctx, filename)
 	case cmd == ExitCommand:
 		fmt.Fprintf(ic.writer, "Goodbye! Session ended.\n")
 		os.Exit(0)
@@ -272,14 +447,29 @@ func (ic *InteractiveChat) showHelp() error {
 	help := fmt.Sprintf(`%sAvailable commands:%s
   %s/help%s                    - Show this help message
   %s/clear%s                   - Clear chat history
+  %s/undo%s                    - Remove the last exchange
+  %s/retry%s [model] [temp]    - Regenerate the last answer, optionally with another model or temperature
+  %s/edit%s [n] [text]         - Rewrite your nth message and regenerate from there
+  %s/branch%s list|checkout <id> - List or switch between retried and edited versions
   %s/model list%s              - List available models
-  %s/model pull%s <name>       - Pull a model from registry
+  %s/model pull%s <name>...    - Pull models from registry
   %s/model use%s <name>        - Switch the active model
   %s/model show%s <name>       - Show model information
+  %s/model diff%s <a> <b>      - Compare the Modelfiles of two models
+  %s/model unload%s [name]     - Free the memory used by a model (default: current)
   %s/status%s                  - Show current session status
+  %s/think%s on|off|hide       - Show, disable or collapse model reasoning
+  %s/stats%s [on|off]          - Toggle token counts and speed after each reply
+  %s/set%s <param> <value>     - Set a model parameter, e.g. /set temperature 0.2
+  %s/show%s options            - Show the parameters set with /set
+  %s/reset%s options           - Clear the parameters set with /set
+  %s/system%s <text>|show|clear - Set, show or remove the system prompt
+  %s/persona%s list|use <name> - List personas or switch to one
+  %s/session%s new|list|switch|rename|delete - Manage saved sessions
+  %s/image%s <path>            - Attach an image to the next message
   %s/save%s [filename]         - Save chat history (default: chat_history.json)
   %s/save%s --previous --output <path> - Save the last response to file
-  %s/load%s [filename]         - Load chat history (default: chat_history.json)
+  %s/load%s [filename]         - Resume a saved chat with its model and settings
   %s/exit%s                    - Exit the chat
 
 %sTips:%s
@@ -298,6 +488,21 @@ func (ic *InteractiveChat) showHelp() error {
 		commandColor, resetColor,
 		commandColor, resetColor,
 		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
+		commandColor, resetColor,
 		headerColor, resetColor,
 		tipColor, resetColor,
 		tipColor, resetColor)
@@ -328,39 +533,12 @@ func (ic *InteractiveChat) modelList() error {
 	return nil
 }
 
-func (ic *InteractiveChat) modelPull(modelName string) error {
-	ic.logger.Debug("Pulling model: %s", modelName)
-
-	fmt.Fprintf(ic.writer, "Pulling model: %s\n", modelName)
-
-	req := client.PullRequest{
-		Name:   modelName,
-		Stream: true,
-	}
-
-	respCh, err := ic.client.PullModel(context.Background(), req)
-	if err != nil {
-		return fmt.Errorf("failed to start pull: %w", err)
-	}
-
-	for resp := range respCh {
-		if strings.HasPrefix(resp.Status, "error:") {
-			return fmt.Errorf("pull failed: %s", resp.Status)
-		}
-
-		if resp.Total > 0 && resp.Completed >= 0 {
-			progress := float64(resp.Completed) / float64(resp.Total) * 100
-			fmt.Fprintf(ic.writer, "\r%s %.1f%%", resp.Status, progress)
-		} else {
-			fmt.Fprintf(ic.writer, "\r%s", resp.Status)
-		}
-	}
-
-	fmt.Fprintf(ic.writer, "\nModel pulled successfully!\n\n")
-	return nil
+func (ic *InteractiveChat) modelPull(ctx context.Context, models []string) error {
+	ic.logger.Debug("Pulling models: %v", models)
+	return pullWithProgress(ctx, ic.client, ic.writer, ic.isTTY, models...)
 }
 
-func (ic *InteractiveChat) modelUse(modelName string) error {
+func (ic *InteractiveChat) modelUse(ctx context.Context, modelName string) error {
 	modelName = strings.TrimSpace(modelName)
 	if modelName == "" {
 		return fmt.Errorf("usage: /model use <model_name>")
@@ -382,14 +560,46 @@ func (ic *InteractiveChat) modelUse(modelName string) error {
 		return nil
 	}
 
+	if ic.client != nil {
+		if err := ic.ensureModel(ctx, modelName); err != nil {
+			return err
+		}
+	}
+
 	ic.model = modelName
-	ic.messages = make([]client.ChatMessage, 0)
+	// The system prompt carries over to the new model
+	ic.resetConversation()
 
 	fmt.Fprintf(ic.writer, "%sNow using model:%s %s\n", highlight, reset, modelName)
 	fmt.Fprintf(ic.writer, "Chat history cleared for new model.\n\n")
 	return nil
 }
 
+func (ic *InteractiveChat) modelUnload(ctx context.Context, modelName string) error {
+	if ic.logger != nil {
+		ic.logger.Debug("Unloading model: %s", modelName)
+	}
+
+	if err := client.UnloadModel(ctx, ic.client, modelName); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ic.writer, "Unloaded %s from memory.", modelName)
+	if modelName == ic.model {
+		fmt.Fprintf(ic.writer, " It will be loaded again with your next message.")
+	}
+	fmt.Fprintf(ic.writer, "\n\n")
+	return nil
+}
+
+// modelKeepAlive returns the keep-alive to send for the current model
+func (ic *InteractiveChat) modelKeepAlive() client.KeepAlive {
+	if ic.keepAlive == nil {
+		return ""
+	}
+	return ic.keepAlive(ic.model)
+}
+
 func (ic *InteractiveChat) modelShow(modelName string) error {
 	ic.logger.Debug("Showing model info: %s", modelName)
 
@@ -420,6 +630,15 @@ func (ic *InteractiveChat) modelShow(modelName string) error {
 func (ic *InteractiveChat) showStatus() error {
 	fmt.Fprintln(ic.writer, "\033[1;36mSession Status:\033[0m")
 	fmt.Fprintf(ic.writer, "  \033[1;33mModel:\033[0m %s\n", ic.model)
+	if ic.current != nil {
+		fmt.Fprintf(ic.writer, "  \033[1;33mSession:\033[0m %s\n", ic.current.Name)
+	}
+	if ic.persona != nil {
+		fmt.Fprintf(ic.writer, "  \033[1;33mPersona:\033[0m %s\n", ic.persona.Name)
+	}
+	if prompt := systemPrompt(ic.messages); prompt != "" {
+		fmt.Fprintf(ic.writer, "  \033[1;33mSystem prompt:\033[0m %s\n", preview(prompt))
+	}
 	fmt.Fprintf(ic.writer, "  \033[1;33mTotal messages:\033[0m %d\n", len(ic.messages))
 
 	// Count user and assistant messages separately
@@ -435,12 +654,93 @@ func (ic *InteractiveChat) showStatus() error {
 
 	fmt.Fprintf(ic.writer, "  \033[1;33mUser messages:\033[0m %d\n", userMsgs)
 	fmt.Fprintf(ic.writer, "  \033[1;33mAssistant messages:\033[0m %d\n", assistantMsgs)
+	if len(ic.options) > 0 {
+		fmt.Fprintf(ic.writer, "  \033[1;33mOptions:\033[0m %s\n", strings.Join(formatOptions(ic.options), ", "))
+	}
+	if ic.think != ThinkDefault {
+		fmt.Fprintf(ic.writer, "  \033[1;33mThinking:\033[0m %s\n", ic.think)
+	}
+	if ic.sessionReplies > 0 {
+		fmt.Fprintf(ic.writer, "  \033[1;33mSession totals:\033[0m %s\n", ic.sessionSummary())
+	}
+	if ic.client != nil {
+		writeEndpointStatus(ic.writer, ic.client, ic.turnEndpoints)
+	}
 	fmt.Fprintln(ic.writer)
 	return nil
 }
 
+// maxStatusTurns limits how many turns /status lists endpoints for
+const maxStatusTurns = 10
+
+// writeEndpointStatus shows the server in use and, with an endpoint pool,
+// the health of each endpoint and which one answered the recent turns
+func writeEndpointStatus(w io.Writer, c client.Provider, turns []string) {
+	fmt.Fprintf(w, "  \033[1;33mServer:\033[0m %s\n", c.Endpoint())
+
+	pooled, ok := c.(interface{ Pool() *client.Pool })
+	if !ok || pooled.Pool() == nil {
+		return
+	}
+	pool := pooled.Pool()
+
+	fmt.Fprintln(w, "  \033[1;33mEndpoints:\033[0m")
+	for _, ep := range pool.Status() {
+		state := "healthy"
+		if !ep.Healthy {
+			state = "down"
+			if ep.LastError != "" {
+				state += " (" + ep.LastError + ")"
+			}
+		}
+		fmt.Fprintf(w, "    %s - %s, %d in flight\n", ep.URL, state, ep.Inflight)
+	}
+
+	if len(turns) == 0 {
+		return
+	}
+	start := 0
+	if len(turns) > maxStatusTurns {
+		start = len(turns) - maxStatusTurns
+	}
+	fmt.Fprintln(w, "  \033[1;33mServed by:\033[0m")
+	for i := start; i < len(turns); i++ {
+		fmt.Fprintf(w, "    turn %d: %s\n", i+1, turns[i])
+	}
+}
+
+func (ic *InteractiveChat) attachImage(ctx context.Context, path string) error {
+	if ic.client != nil {
+		if err := client.CheckVision(ctx, ic.client, ic.model); err != nil {
+			return err
+		}
+	}
+
+	image, err := client.EncodeImage(path)
+	if err != nil {
+		return err
+	}
+	ic.images = append(ic.images, image)
+
+	_, err = fmt.Fprintf(ic.writer, "Attached %s to the next message (%d image(s) pending)\n", path, len(ic.images))
+	return err
+}
+
+// resetConversation starts a new, empty conversation with the same model,
+// options and system prompt. The old one stays in its session; the next
+// one gets its own.
+func (ic *InteractiveChat) resetConversation() {
+	// The system prompt is a setting rather than part of the conversation
+	ic.messages = withSystemPrompt(nil, systemPrompt(ic.messages))
+	ic.images = nil
+	ic.turnEndpoints = nil
+	ic.current = nil
+	ic.branches, ic.branch = nil, 0
+	ic.created = time.Time{}
+}
+
 func (ic *InteractiveChat) clearHistory() error {
-	ic.messages = make([]client.ChatMessage, 0)
+	ic.resetConversation()
 	_, err := fmt.Fprintf(ic.writer, "Chat history cleared.\n")
 	return err
 }
@@ -497,14 +797,10 @@ func (ic *InteractiveChat) saveCommand(args []string) error {
 		target = options.filename
 	}
 
-	data, err := json.MarshalIndent(ic.messages, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to encode chat history: %w", err)
-	}
-	if err := os.WriteFile(target, data, 0o644); err != nil {
-		return fmt.Errorf("failed to save chat history: %w", err)
+	if err := ic.Transcript().Save(target); err != nil {
+		return err
 	}
-	_, err = fmt.Fprintf(ic.writer, "Chat history saved to %s\n", target)
+	_, err := fmt.Fprintf(ic.writer, "Chat history saved to %s\n", target)
 	return err
 }
 
@@ -521,62 +817,214 @@ func (ic *InteractiveChat) lastAssistantMessage() (string, error) {
 	return "", fmt.Errorf("no assistant response available to save")
 }
 
-func (ic *InteractiveChat) loadHistory(filename string) error {
-	// TODO: Implement load functionality
-	_, err := fmt.Fprintf(ic.writer, "Load functionality not implemented yet: %s\n", filename)
-	return err
-}
-
 func (ic *InteractiveChat) sendMessage(ctx context.Context, message string) error {
+	turnStart := len(ic.messages)
+
 	// Add user message to history
 	userMsg := client.ChatMessage{
 		Role:    "user",
 		Content: message,
+		Images:  ic.images,
 	}
 	ic.messages = append(ic.messages, userMsg)
+	ic.images = nil
+	// Save the prompt before the reply so it survives a crash mid-stream
+	ic.autosave()
+	defer ic.autosave()
 
+	return ic.completeTurn(ctx, turnStart)
+}
+
+// completeTurn queries the model until it answers without requesting tools.
+// If a request fails, the history is cut back to rollback so the failed
+// turn is not replayed with the next message.
+func (ic *InteractiveChat) completeTurn(ctx context.Context, rollback int) error {
+	maxRounds := ic.maxRounds
+	if maxRounds <= 0 {
+		maxRounds = defaultMaxToolRounds
+	}
+
+	// Re-query the model until it answers without requesting tools
+	for round := 0; ; round++ {
+		assistantMsg, err := ic.streamReply(ctx)
+		if err != nil {
+			ic.messages = ic.messages[:rollback]
+			return err
+		}
+		ic.messages = append(ic.messages, assistantMsg)
+
+		if len(assistantMsg.ToolCalls) == 0 || ic.tools == nil {
+			ic.turnEndpoints = append(ic.turnEndpoints, ic.client.Endpoint())
+			return nil
+		}
+		if round >= maxRounds {
+			// The unanswered tool calls would make every later request invalid
+			ic.messages = ic.messages[:rollback]
+			return fmt.Errorf("model requested tools more than %d times, giving up", maxRounds)
+		}
+
+		ic.runToolCalls(ctx, assistantMsg.ToolCalls)
+	}
+}
+
+// streamReply sends the current history and streams the assistant reply
+func (ic *InteractiveChat) streamReply(ctx context.Context) (client.ChatMessage, error) {
 	// Prepare request
 	req := client.ChatRequest{
-		Model:    ic.model,
-		Messages: ic.messages,
-		Stream:   true,
+		Model:     ic.model,
+		Messages:  ic.modelHistory(),
+		Stream:    true,
+		KeepAlive: ic.modelKeepAlive(),
+		Think:     ic.thinkOption(),
+		Options:   ic.options,
+	}
+	if ic.tools != nil {
+		req.Tools = ic.tools.Definitions()
 	}
 
 	// Send request and handle streaming response
+	start := time.Now()
 	respCh, err := ic.client.ChatStream(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to start chat stream: %w", err)
+		return client.ChatMessage{}, fmt.Errorf("failed to start chat stream: %w", err)
 	}
 
 	// Process streaming responses
-	var assistantMsg client.ChatMessage
-	var responseBuilder strings.Builder
+	var responseBuilder, thinkingBuilder strings.Builder
+	var toolCalls []client.ToolCall
+	var splitter thinkSplitter
+	var firstToken time.Duration
+	var final client.Stats
+	done := false
+	view := &thinkingView{w: ic.writer, mode: ic.think, tty: ic.isTTY}
+
+	// show separates reasoning from the answer and prints both
+	show := func(thinking, content string, print bool) {
+		thinkingBuilder.WriteString(thinking)
+		view.thinking(thinking)
+		if content == "" {
+			return
+		}
+		view.answer()
+		responseBuilder.WriteString(content)
+		if print {
+			fmt.Fprint(ic.writer, content)
+		}
+	}
 
 	for resp := range respCh {
+		if resp.Err != nil {
+			if responseBuilder.Len() > 0 || thinkingBuilder.Len() > 0 {
+				fmt.Fprintln(ic.writer)
+			}
+			return client.ChatMessage{}, resp.Err
+		}
+
+		toolCalls = append(toolCalls, resp.Message.ToolCalls...)
+		thinking, content := splitter.feed(resp.Message.Content)
+		if firstToken == 0 && resp.Message.Content+resp.Message.Thinking != "" {
+			firstToken = time.Since(start)
+		}
+
 		if resp.Done {
 			// Final response
-			if resp.Message.Content != "" {
-				responseBuilder.WriteString(resp.Message.Content)
-			}
+			show(resp.Message.Thinking+thinking, content, false)
+			final = resp.Stats()
+			final.TimeToFirstToken = firstToken
+			done = true
 			break
 		}
 
 		// Stream response chunk
-		chunk := resp.Message.Content
-		responseBuilder.WriteString(chunk)
-		fmt.Fprint(ic.writer, chunk)
+		show(resp.Message.Thinking+thinking, content, true)
+	}
+	// A cancelled stream is closed without an error result
+	if err := ctx.Err(); err != nil && !done {
+		if responseBuilder.Len() > 0 || thinkingBuilder.Len() > 0 {
+			fmt.Fprintln(ic.writer)
+		}
+		return client.ChatMessage{}, err
+	}
+	thinking, content := splitter.flush()
+	show(thinking, content, true)
+	view.answer()
+
+	ic.session.Add(final)
+	ic.sessionReplies++
+	if responseBuilder.Len() > 0 || len(toolCalls) == 0 {
+		fmt.Fprintln(ic.writer)
+		if ic.showStats {
+			fmt.Fprintln(ic.writer, ic.dim(output.StatsLine(final)))
+		}
+		fmt.Fprintln(ic.writer) // Blank line after response for next prompt
 	}
 
-	fmt.Fprintf(ic.writer, "\n\n") // Two new lines after response for next prompt
+	return client.ChatMessage{
+		Role:      client.RoleAssistant,
+		Content:   responseBuilder.String(),
+		ToolCalls: toolCalls,
+		Thinking:  thinkingBuilder.String(),
+	}, nil
+}
 
-	// Add assistant message to history
-	assistantMsg = client.ChatMessage{
-		Role:    "assistant",
-		Content: responseBuilder.String(),
+// runToolCalls executes the requested tools and appends their results to the history
+func (ic *InteractiveChat) runToolCalls(ctx context.Context, calls []client.ToolCall) {
+	for _, call := range calls {
+		result := ic.runToolCall(ctx, call)
+		ic.messages = append(ic.messages, client.ChatMessage{
+			Role:       client.RoleTool,
+			Content:    result,
+			ToolName:   call.Function.Name,
+			ToolCallID: call.ID,
+		})
 	}
-	ic.messages = append(ic.messages, assistantMsg)
+}
 
-	return nil
+func (ic *InteractiveChat) runToolCall(ctx context.Context, call client.ToolCall) string {
+	description := describeToolCall(call)
+
+	tool, ok := ic.tools.Get(call.Function.Name)
+	if !ok {
+		fmt.Fprintf(ic.writer, "Model requested unknown tool: %s\n", description)
+		return fmt.Sprintf("error: unknown tool %q", call.Function.Name)
+	}
+
+	if tool.Confirm {
+		allowed, err := ic.confirm(fmt.Sprintf("Allow tool call %s? [y/N] ", description))
+		if err != nil || !allowed {
+			fmt.Fprintf(ic.writer, "Tool call %s denied.\n", call.Function.Name)
+			return "error: the user denied this tool call"
+		}
+	} else {
+		fmt.Fprintf(ic.writer, "Running tool %s\n", description)
+	}
+
+	result, err := ic.tools.Execute(ctx, call)
+	if err != nil {
+		fmt.Fprintf(ic.writer, "Tool %s failed: %v\n", call.Function.Name, err)
+		return fmt.Sprintf("error: %v", err)
+	}
+	return result
+}
+
+// ensureModel offers to pull model if it is not installed. Without a
+// terminal to ask on, it only pulls when auto-pull is enabled.
+func (ic *InteractiveChat) ensureModel(ctx context.Context, model string) error {
+	opts := EnsureOptions{
+		Client:   ic.client,
+		Writer:   ic.writer,
+		AutoPull: ic.autoPull,
+		TTY:      ic.isTTY,
+	}
+	if ic.isTTY {
+		opts.Confirm = ic.confirm
+	}
+	return EnsureModel(ctx, model, opts)
+}
+
+// confirm asks a yes/no question and reports whether the user answered yes
+func (ic *InteractiveChat) confirm(question string) (bool, error) {
+	return promptYesNo(ic.line, ic.reader, ic.writer, ic.isTTY, question)
 }
 
 func (ic *InteractiveChat) GetHistory() []client.ChatMessage {
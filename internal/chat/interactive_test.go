@@ -1,8 +1,12 @@
 package chat
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +15,7 @@ import (
 	"ollamacli/internal/client"
 	"ollamacli/internal/log"
 	"ollamacli/internal/output"
+	"ollamacli/pkg/ollamatest"
 )
 
 func TestNewInteractiveChat(t *testing.T) {
@@ -100,7 +105,7 @@ func TestHandleCommand(t *testing.T) {
 				t.Errorf("Expected messages to be cleared, got %d", len(ic.messages))
 			}
 		}},
-		{"/load test.json", false, "Load functionality not implemented", nil},
+		{"/load missing.json", true, "", nil},
 		{"/model use new-model", false, "Now using model", func(t *testing.T, ic *InteractiveChat) {
 			if ic.model != "new-model" {
 				t.Errorf("Expected model to switch to 'new-model', got '%s'", ic.model)
@@ -123,7 +128,7 @@ func TestHandleCommand(t *testing.T) {
 				model:    "base-model",
 			}
 
-			err := ic.handleCommand(tc.command)
+			err := ic.handleCommand(context.Background(), tc.command)
 
 			if tc.expectError && err == nil {
 				t.Fatalf("Expected error for command '%s', got none", tc.command)
@@ -192,7 +197,7 @@ func TestSaveCommandSavesHistoryToFile(t *testing.T) {
 
 	cmd := fmt.Sprintf("/save %s", filePath)
 
-	if err := ic.handleCommand(cmd); err != nil {
+	if err := ic.handleCommand(context.Background(), cmd); err != nil {
 		t.Fatalf("handleCommand failed: %v", err)
 	}
 
@@ -201,13 +206,13 @@ func TestSaveCommandSavesHistoryToFile(t *testing.T) {
 		t.Fatalf("expected history file to be created: %v", err)
 	}
 
-	var saved []client.ChatMessage
+	var saved Transcript
 	if err := json.Unmarshal(data, &saved); err != nil {
 		t.Fatalf("failed to unmarshal saved history: %v", err)
 	}
 
-	if len(saved) != len(ic.messages) {
-		t.Fatalf("expected %d messages, got %d", len(ic.messages), len(saved))
+	if saved.Version != TranscriptVersion || len(saved.Messages) != len(ic.messages) {
+		t.Fatalf("expected %d messages in a version %d transcript, got %+v", len(ic.messages), TranscriptVersion, saved)
 	}
 
 	if !strings.Contains(outputBuf.String(), "Chat history saved to") {
@@ -230,7 +235,7 @@ func TestSaveCommandSavesPreviousResponse(t *testing.T) {
 
 	cmd := fmt.Sprintf("/save --previous --output %s", filePath)
 
-	if err := ic.handleCommand(cmd); err != nil {
+	if err := ic.handleCommand(context.Background(), cmd); err != nil {
 		t.Fatalf("handleCommand failed: %v", err)
 	}
 
@@ -263,7 +268,7 @@ func TestSaveCommandPreviousResponseNoAssistant(t *testing.T) {
 
 	cmd := fmt.Sprintf("/save --previous --output %s", filePath)
 
-	if err := ic.handleCommand(cmd); err != nil {
+	if err := ic.handleCommand(context.Background(), cmd); err != nil {
 		t.Fatalf("expected no error when saving without assistant message, got: %v", err)
 	}
 
@@ -302,3 +307,160 @@ func TestShowHelpTTYAwareness(t *testing.T) {
 		t.Error("Expected colored help output in TTY mode")
 	}
 }
+
+func TestImageCommandAttachesToNextMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+	imagePath := filepath.Join(tmpDir, "photo.png")
+	if err := os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0o644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	var chatReq client.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			json.NewEncoder(w).Encode(client.ShowResponse{Capabilities: []string{"completion", "vision"}})
+		case "/api/chat":
+			json.NewDecoder(r.Body).Decode(&chatReq)
+			json.NewEncoder(w).Encode(client.ChatResponse{Message: client.ChatMessage{Role: "assistant", Content: "A cat"}, Done: true})
+		}
+	}))
+	defer server.Close()
+
+	var outputBuf strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: server.URL}),
+		writer:   &outputBuf,
+		model:    "llava",
+		messages: make([]client.ChatMessage, 0),
+	}
+
+	if err := ic.handleCommand(context.Background(), "/image "+imagePath); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	if err := ic.sendMessage(context.Background(), "What is this?"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	if len(chatReq.Messages) != 1 || len(chatReq.Messages[0].Images) != 1 {
+		t.Fatalf("expected image on the user message, got %+v", chatReq.Messages)
+	}
+	if len(ic.images) != 0 {
+		t.Errorf("expected pending images to be cleared, got %d", len(ic.images))
+	}
+}
+
+func TestImageCommandRejectsTextOnlyModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(client.ShowResponse{Capabilities: []string{"completion"}})
+	}))
+	defer server.Close()
+
+	var outputBuf strings.Builder
+	ic := &InteractiveChat{
+		client: client.New(client.Options{BaseURL: server.URL}),
+		writer: &outputBuf,
+		model:  "llama3",
+	}
+
+	err := ic.handleCommand(context.Background(), "/image photo.png")
+	if err == nil || !strings.Contains(err.Error(), "does not support images") {
+		t.Fatalf("expected vision error, got: %v", err)
+	}
+
+	// The capability check stops with the REPL context on Ctrl+C
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ic.handleCommand(ctx, "/image photo.png"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the check to stop with the context, got %v", err)
+	}
+}
+
+func TestStatusShowsEndpointPerTurn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			json.NewEncoder(w).Encode(client.ChatResponse{Message: client.ChatMessage{Role: "assistant", Content: "Hi"}, Done: true})
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	pool, err := client.NewPool(client.PoolOptions{Endpoints: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+
+	var outputBuf strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{Pool: pool}),
+		writer:   &outputBuf,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+	}
+
+	if err := ic.sendMessage(context.Background(), "Hello"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	outputBuf.Reset()
+	if err := ic.handleCommand(context.Background(), "/status"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+
+	output := outputBuf.String()
+	if !strings.Contains(output, "turn 1: "+server.URL) {
+		t.Errorf("expected /status to show the endpoint of turn 1, got: %s", output)
+	}
+	if !strings.Contains(output, server.URL+" - healthy") {
+		t.Errorf("expected /status to list endpoint health, got: %s", output)
+	}
+}
+
+func TestModelUnloadCommand(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}, {Name: "phi3", Loaded: true}}})
+
+	var outputBuf strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &outputBuf,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+		keepAlive: func(model string) client.KeepAlive {
+			return map[string]client.KeepAlive{"llama2": "1h"}[model]
+		},
+	}
+
+	if err := ic.sendMessage(context.Background(), "Hello"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	req, _ := srv.LastRequest("/api/chat")
+	var body ollamatest.ChatRequest
+	req.Decode(&body)
+	if string(body.KeepAlive) != `"1h"` {
+		t.Errorf("expected the configured keep_alive to be sent, got %s", body.KeepAlive)
+	}
+
+	if err := ic.handleCommand(context.Background(), "/model unload"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	if srv.IsLoaded("llama2") {
+		t.Error("expected the current model to be unloaded")
+	}
+	if err := ic.handleCommand(context.Background(), "/model unload phi3"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	if srv.IsLoaded("phi3") {
+		t.Error("expected phi3 to be unloaded")
+	}
+	if !strings.Contains(outputBuf.String(), "Unloaded phi3 from memory.") {
+		t.Errorf("unexpected output: %s", outputBuf.String())
+	}
+
+	// Ctrl+C cancels the REPL context, which stops the unload
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ic.handleCommand(ctx, "/model unload"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the unload to stop with the context, got %v", err)
+	}
+}
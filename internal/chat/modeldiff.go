@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/modelfile"
+)
+
+// DiffModels compares the Modelfiles of two installed models
+func DiffModels(ctx context.Context, c client.Provider, a, b string) ([]modelfile.Change, error) {
+	files := make([]*modelfile.Modelfile, 2)
+	for i, name := range []string{a, b} {
+		resp, err := c.ShowModel(ctx, client.ShowRequest{Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to show model %s: %w", name, err)
+		}
+		files[i], err = modelfile.Parse(strings.NewReader(resp.Modelfile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Modelfile of %s: %w", name, err)
+		}
+	}
+	return modelfile.Diff(files[0], files[1]), nil
+}
+
+func (ic *InteractiveChat) modelDiff(ctx context.Context, a, b string) error {
+	if ic.logger != nil {
+		ic.logger.Debug("Comparing models: %s %s", a, b)
+	}
+
+	changes, err := DiffModels(ctx, ic.client, a, b)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintf(ic.writer, "No differences between %s and %s\n\n", a, b)
+		return nil
+	}
+
+	fmt.Fprintf(ic.writer, "\033[1;36m--- %s\n+++ %s\033[0m\n", a, b)
+	for _, change := range changes {
+		fmt.Fprintln(ic.writer, change)
+	}
+	fmt.Fprintln(ic.writer)
+	return nil
+}
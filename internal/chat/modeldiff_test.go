@@ -0,0 +1,52 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestModelDiffCommand(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{
+		{Name: "coder", Modelfile: "FROM llama3\nPARAMETER temperature 0.7\nPARAMETER stop <|end|>\nSYSTEM \"\"\"You write Go.\"\"\"\n"},
+		{Name: "coder-v2", Modelfile: "FROM llama3\nPARAMETER temperature 0.2\nPARAMETER num_ctx 8192\nSYSTEM \"\"\"You write Go.\"\"\"\n"},
+	}})
+
+	var out strings.Builder
+	ic := &InteractiveChat{client: client.New(client.Options{BaseURL: srv.URL}), writer: &out}
+
+	if err := ic.handleCommand(context.Background(), "/model diff coder coder-v2"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	for _, want := range []string{
+		"~ PARAMETER temperature: 0.7 -> 0.2",
+		"- PARAMETER stop <|end|>",
+		"+ PARAMETER num_ctx 8192",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in %q", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "SYSTEM") || strings.Contains(out.String(), "FROM") {
+		t.Errorf("unchanged directives should not be listed: %q", out.String())
+	}
+
+	out.Reset()
+	if err := ic.handleCommand(context.Background(), "/model diff coder coder"); err != nil || !strings.Contains(out.String(), "No differences") {
+		t.Errorf("expected no differences, got %q (%v)", out.String(), err)
+	}
+	if err := ic.handleCommand(context.Background(), "/model diff coder"); err == nil {
+		t.Error("expected a usage error")
+	}
+
+	// Ctrl+C cancels the REPL context, which stops the lookups
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ic.handleCommand(ctx, "/model diff coder coder-v2"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the diff to stop with the context, got %v", err)
+	}
+}
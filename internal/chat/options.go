@@ -0,0 +1,166 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ollamacli/internal/modelfile"
+)
+
+const (
+	SetCommand   = "/set"
+	ShowCommand  = "/show"
+	ResetCommand = "/reset"
+)
+
+// setOption returns a copy of options with one parameter set from
+// /set <name> <value>. Each argument after stop is a separate stop sequence.
+// The map is copied because transcripts and sessions may share it.
+func setOption(options map[string]interface{}, args []string) (map[string]interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("usage: /set <parameter> <value>")
+	}
+	name := strings.ToLower(args[0])
+
+	var value interface{}
+	if modelfile.IsRepeatable(name) {
+		value = append([]string(nil), args[1:]...)
+	} else if len(args) > 2 {
+		return nil, fmt.Errorf("usage: /set %s <value>", name)
+	} else {
+		v, err := modelfile.ParseParameter(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	}
+
+	updated := make(map[string]interface{}, len(options)+1)
+	for key, v := range options {
+		updated[key] = v
+	}
+	updated[name] = value
+	return updated, nil
+}
+
+// formatOptions lists options as name=value in name order
+func formatOptions(options map[string]interface{}) []string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		value := options[name]
+		switch list := value.(type) {
+		case []string:
+			value = strings.Join(quoteAll(list), " ")
+		case []interface{}:
+			// Lists read back from a transcript or session
+			strs := make([]string, len(list))
+			for j, v := range list {
+				strs[j] = fmt.Sprint(v)
+			}
+			value = strings.Join(quoteAll(strs), " ")
+		}
+		parts[i] = fmt.Sprintf("%s=%v", name, value)
+	}
+	return parts
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+// splitArgs splits a command line on whitespace. An argument in double
+// quotes is a Go string literal, so it may hold spaces and escapes such as
+// \n; this is how /show options prints values.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	for {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line == "" {
+			return args, nil
+		}
+
+		if line[0] != '"' {
+			end := strings.IndexFunc(line, unicode.IsSpace)
+			if end < 0 {
+				end = len(line)
+			}
+			args = append(args, line[:end])
+			line = line[end:]
+			continue
+		}
+
+		end := 1
+		for end < len(line) && line[end] != '"' {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(line) {
+			return nil, fmt.Errorf("unterminated quoted argument: %s", line)
+		}
+		arg, err := strconv.Unquote(line[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted argument %s", line[:end+1])
+		}
+		args = append(args, arg)
+		line = line[end+1:]
+	}
+}
+
+// optionsCommand handles /set, /show options and /reset options for both
+// REPLs, returning the new options. The arguments are taken from the
+// command line with splitArgs.
+func optionsCommand(w io.Writer, options map[string]interface{}, command string) (map[string]interface{}, error) {
+	args, err := splitArgs(command)
+	if err != nil {
+		return options, err
+	}
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case SetCommand:
+		updated, err := setOption(options, args)
+		if err != nil {
+			return options, err
+		}
+		name := strings.ToLower(args[0])
+		fmt.Fprintf(w, "Set %s.\n", formatOptions(map[string]interface{}{name: updated[name]})[0])
+		return updated, nil
+
+	case ShowCommand:
+		if len(args) != 1 || args[0] != "options" {
+			return options, fmt.Errorf("usage: /show options")
+		}
+		if len(options) == 0 {
+			fmt.Fprintln(w, "No options set; the model's defaults apply.")
+			return options, nil
+		}
+		for _, line := range formatOptions(options) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+		return options, nil
+
+	case ResetCommand:
+		if len(args) != 1 || args[0] != "options" {
+			return options, fmt.Errorf("usage: /reset options")
+		}
+		fmt.Fprintln(w, "Options cleared; the model's defaults apply.")
+		return nil, nil
+	}
+	return options, fmt.Errorf("unknown command: %s", cmd)
+}
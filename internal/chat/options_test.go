@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestOptionCommands(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}}})
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &out,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+	}
+
+	for _, cmd := range []string{"/set temperature 0.2", "/set num_ctx 8192", "/set seed 42", "/set stop <|end|> User:"} {
+		if err := ic.handleCommand(context.Background(), cmd); err != nil {
+			t.Fatalf("%s failed: %v", cmd, err)
+		}
+	}
+	for _, cmd := range []string{"/set temperature hot", "/set creativity 1", "/set top_p 2", "/set seed", "/show models"} {
+		if err := ic.handleCommand(context.Background(), cmd); err == nil {
+			t.Errorf("expected %s to fail", cmd)
+		}
+	}
+
+	if err := ic.sendMessage(context.Background(), "Hello"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	req, _ := srv.LastRequest("/api/chat")
+	var body ollamatest.ChatRequest
+	req.Decode(&body)
+	if body.Options["temperature"] != 0.2 || body.Options["num_ctx"] != float64(8192) || body.Options["seed"] != float64(42) {
+		t.Errorf("options not sent: %v", body.Options)
+	}
+	if stops, _ := body.Options["stop"].([]interface{}); len(stops) != 2 || stops[0] != "<|end|>" {
+		t.Errorf("expected two stop sequences, got %v", body.Options["stop"])
+	}
+
+	out.Reset()
+	ic.handleCommand(context.Background(), "/show options")
+	if !strings.Contains(out.String(), "  num_ctx=8192\n") || !strings.Contains(out.String(), `stop="<|end|>" "User:"`) {
+		t.Errorf("unexpected /show options output: %q", out.String())
+	}
+	out.Reset()
+	ic.handleCommand(context.Background(), "/status")
+	if !strings.Contains(out.String(), "num_ctx=8192, seed=42") {
+		t.Errorf("expected options in /status, got %q", out.String())
+	}
+
+	if err := ic.handleCommand(context.Background(), "/reset options"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if len(ic.options) != 0 {
+		t.Errorf("expected options cleared, got %v", ic.options)
+	}
+}
+
+func TestSetQuotedStopSequences(t *testing.T) {
+	var out strings.Builder
+	ic := &InteractiveChat{writer: &out}
+
+	if err := ic.handleCommand(context.Background(), `/set stop "<|im_end|>" "\n\nUser:" "end of turn"`); err != nil {
+		t.Fatalf("/set failed: %v", err)
+	}
+	want := []string{"<|im_end|>", "\n\nUser:", "end of turn"}
+	if got, _ := ic.options["stop"].([]string); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected the quotes removed, got %q", got)
+	}
+
+	// What /show options prints can be given back to /set
+	out.Reset()
+	ic.handleCommand(context.Background(), "/show options")
+	shown := strings.TrimPrefix(strings.TrimSpace(out.String()), "stop=")
+	ic.options = nil
+	if err := ic.handleCommand(context.Background(), "/set stop "+shown); err != nil {
+		t.Fatalf("/set with the /show options value failed: %v", err)
+	}
+	if got, _ := ic.options["stop"].([]string); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("round trip through /show options gave %q", got)
+	}
+
+	if err := ic.handleCommand(context.Background(), `/set stop "open`); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+}
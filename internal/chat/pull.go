@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/output"
+)
+
+// DefaultPullConcurrency is how many models PullModels downloads at once
+const DefaultPullConcurrency = 3
+
+// PullOptions controls PullModels
+type PullOptions struct {
+	Client client.Provider
+	Writer io.Writer
+	// TTY repaints progress in place; otherwise status changes are printed
+	TTY bool
+	// Concurrency limits simultaneous pulls (default 3)
+	Concurrency int
+}
+
+// PullModels pulls several models at once with a combined progress view.
+// Every pull runs to completion; the errors of those that failed are joined.
+func PullModels(ctx context.Context, models []string, opts PullOptions) error {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultPullConcurrency
+	}
+
+	progress := output.NewPullProgress(opts.Writer, opts.TTY)
+	for _, model := range models {
+		progress.Add(model)
+	}
+
+	errs := make([]error, len(models))
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("%s: %w", model, ctx.Err())
+				return
+			}
+			errs[i] = pullOne(ctx, opts.Client, model, progress)
+		}()
+	}
+	wg.Wait()
+	progress.Finish()
+
+	return errors.Join(errs...)
+}
+
+func pullOne(ctx context.Context, c client.Provider, model string, progress *output.PullProgress) error {
+	respCh, err := c.PullModel(ctx, client.PullRequest{Name: model, Stream: true})
+	if err != nil {
+		err = fmt.Errorf("failed to start pull of %s: %w", model, err)
+		progress.Update(model, client.PullResponse{Err: err})
+		return err
+	}
+
+	verified := false
+	for resp := range respCh {
+		progress.Update(model, resp)
+		if resp.Err != nil {
+			return fmt.Errorf("pull of %s failed: %w", model, resp.Err)
+		}
+		verified = resp.Status == client.PullStatusVerified
+	}
+	// A cancelled pull may end without an error result
+	if err := ctx.Err(); err != nil && !verified {
+		progress.Update(model, client.PullResponse{Err: err})
+		return fmt.Errorf("pull of %s failed: %w", model, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/log"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestPullModelsConcurrently(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{
+		Registry:   []ollamatest.Model{{Name: "llama2"}, {Name: "mistral"}, {Name: "phi3"}},
+		ChunkDelay: 20 * time.Millisecond,
+	})
+	c := client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond})
+
+	var out strings.Builder
+	start := time.Now()
+	err := PullModels(context.Background(), []string{"llama2", "mistral", "phi3"}, PullOptions{Client: c, Writer: &out})
+	if err != nil {
+		t.Fatalf("PullModels failed: %v", err)
+	}
+
+	for _, model := range []string{"llama2", "mistral", "phi3"} {
+		if !srv.HasModel(model) {
+			t.Errorf("%s was not pulled", model)
+		}
+		if !strings.Contains(out.String(), model+": done") {
+			t.Errorf("expected a done line for %s in %q", model, out.String())
+		}
+	}
+	// Each pull streams 9 chunks; one after another they would take over 0.5s
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("pulls did not run concurrently (took %v)", elapsed)
+	}
+}
+
+func TestPullModelsReportsEachFailure(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Registry: []ollamatest.Model{{Name: "llama2"}}})
+	c := client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond})
+
+	var out strings.Builder
+	err := PullModels(context.Background(), []string{"nope", "llama2", "missing"}, PullOptions{Client: c, Writer: &out})
+	if err == nil {
+		t.Fatal("expected an error for the unknown models")
+	}
+	for _, model := range []string{"nope", "missing"} {
+		if !strings.Contains(err.Error(), model) {
+			t.Errorf("expected %s in error %q", model, err)
+		}
+	}
+	if !srv.HasModel("llama2") {
+		t.Error("a failing pull should not stop the others")
+	}
+}
+
+func TestPullModelsStopsWhenCancelled(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{
+		Registry:   []ollamatest.Model{{Name: "llama2"}, {Name: "mistral"}},
+		ChunkDelay: 50 * time.Millisecond,
+	})
+	c := client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+	var out strings.Builder
+	err := PullModels(ctx, []string{"llama2", "mistral"}, PullOptions{Client: c, Writer: &out})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the pulls to stop with the context, got %v", err)
+	}
+	for _, model := range []string{"llama2", "mistral"} {
+		if !strings.Contains(err.Error(), model) {
+			t.Errorf("expected %s in error %q", model, err)
+		}
+		if strings.Contains(out.String(), model+": done") {
+			t.Errorf("cancelled pull of %s reported done: %q", model, out.String())
+		}
+	}
+}
+
+func TestModelPullStopsWithREPLContext(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Registry: []ollamatest.Model{{Name: "llama2"}}, ChunkDelay: 50 * time.Millisecond})
+	ic := &InteractiveChat{
+		client: client.New(client.Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond}),
+		logger: log.New("error", false),
+		writer: &strings.Builder{},
+	}
+
+	// Ctrl+C cancels the REPL context; the download must stop with it
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+	if err := ic.handleCommand(ctx, "/model pull llama2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the pull to stop with the context, got %v", err)
+	}
+	if srv.HasModel("llama2") {
+		t.Error("the cancelled pull still installed the model")
+	}
+}
@@ -18,9 +18,13 @@ import (
 	"ollamacli/internal/rag"
 )
 
+// DefaultRAGSystemPrompt is the system prompt for RAG chat unless another
+// is given
+const DefaultRAGSystemPrompt = "You are a helpful assistant. Use the provided context to answer questions accurately. Always respond in the same language as the user's question. If the context doesn't contain relevant information, say so."
+
 // RAGInteractiveChat provides an interactive chat session with RAG support
 type RAGInteractiveChat struct {
-	client    *client.Client
+	client    client.Provider
 	formatter output.Formatter
 	logger    log.Logger
 	model     string
@@ -32,11 +36,19 @@ type RAGInteractiveChat struct {
 	isTTY     bool
 	retriever *rag.Retriever
 	topK      int
+	autoPull  bool
+	keepAlive client.KeepAlive
+	// turnEndpoints records the server that answered each turn
+	turnEndpoints []string
+	// options are the model parameters sent with every request
+	options map[string]interface{}
+	// system is added as the first message of each conversation
+	system string
 }
 
 // RAGOptions contains configuration for RAG interactive chat
 type RAGOptions struct {
-	Client    *client.Client
+	Client    client.Provider
 	Formatter output.Formatter
 	Logger    log.Logger
 	Model     string
@@ -45,6 +57,14 @@ type RAGOptions struct {
 	Prompt    string
 	Retriever *rag.Retriever
 	TopK      int
+	// AutoPull pulls a missing chat or embedding model without asking
+	AutoPull bool
+	// KeepAlive sets how long the chat model stays loaded after each request
+	KeepAlive client.KeepAlive
+	// ModelOptions are the model parameters sent with every request
+	ModelOptions map[string]interface{}
+	// System replaces DefaultRAGSystemPrompt
+	System string
 }
 
 // NewRAGInteractiveChat creates a new RAG interactive chat session
@@ -61,6 +81,9 @@ func NewRAGInteractiveChat(opts RAGOptions) *RAGInteractiveChat {
 	if opts.TopK == 0 {
 		opts.TopK = 3
 	}
+	if opts.System == "" {
+		opts.System = DefaultRAGSystemPrompt
+	}
 
 	// Check if stdin is a TTY
 	isTTY := term.IsTerminal(int(os.Stdin.Fd()))
@@ -91,6 +114,10 @@ func NewRAGInteractiveChat(opts RAGOptions) *RAGInteractiveChat {
 		isTTY:     isTTY,
 		retriever: opts.Retriever,
 		topK:      opts.TopK,
+		autoPull:  opts.AutoPull,
+		keepAlive: opts.KeepAlive,
+		options:   opts.ModelOptions,
+		system:    opts.System,
 	}
 }
 
@@ -120,6 +147,11 @@ func (ic *RAGInteractiveChat) Start(ctx context.Context) error {
 		defer ic.line.Close()
 	}
 
+	// Both the chat model and the embedding model must be installed
+	if err := ic.ensureModels(ctx); err != nil {
+		return err
+	}
+
 	// Print welcome message
 	fmt.Fprintf(ic.writer, "RAG Interactive Chat - Model: %s\n", ic.model)
 	fmt.Fprintf(ic.writer, "Type %s for help, %s to exit\n\n", HelpCommand, ExitCommand)
@@ -186,10 +218,10 @@ func (ic *RAGInteractiveChat) Start(ctx context.Context) error {
 		}
 
 		// Add system message if this is the first message
-		if len(ic.messages) == 0 {
+		if len(ic.messages) == 0 && ic.system != "" {
 			ic.messages = append(ic.messages, client.ChatMessage{
 				Role:    "system",
-				Content: "You are a helpful assistant. Use the provided context to answer questions accurately. Always respond in the same language as the user's question. If the context doesn't contain relevant information, say so.",
+				Content: ic.system,
 			})
 		}
 
@@ -201,9 +233,11 @@ func (ic *RAGInteractiveChat) Start(ctx context.Context) error {
 
 		// Create chat request
 		req := client.ChatRequest{
-			Model:    ic.model,
-			Messages: ic.messages,
-			Stream:   true,
+			Model:     ic.model,
+			Messages:  ic.messages,
+			Stream:    true,
+			KeepAlive: ic.keepAlive,
+			Options:   ic.options,
 		}
 
 		// Send request and stream response
@@ -217,7 +251,12 @@ func (ic *RAGInteractiveChat) Start(ctx context.Context) error {
 
 		// Collect full response
 		var fullResponse strings.Builder
+		var streamErr error
 		for resp := range respCh {
+			if resp.Err != nil {
+				streamErr = resp.Err
+				break
+			}
 			if err := ic.formatter.FormatChatResponse(&resp); err != nil {
 				ic.logger.Warn("Failed to format response: %v", err)
 			}
@@ -228,14 +267,47 @@ func (ic *RAGInteractiveChat) Start(ctx context.Context) error {
 
 		fmt.Fprintln(ic.writer) // New line after response
 
+		if streamErr != nil {
+			fmt.Fprintf(ic.writer, "Error: %v\n", streamErr)
+			// Remove the failed user message
+			ic.messages = ic.messages[:len(ic.messages)-1]
+			continue
+		}
+
 		// Add assistant response to conversation history
 		ic.messages = append(ic.messages, client.ChatMessage{
 			Role:    "assistant",
 			Content: fullResponse.String(),
 		})
+		ic.turnEndpoints = append(ic.turnEndpoints, ic.client.Endpoint())
 	}
 }
 
+// ensureModels offers to pull the chat and embedding models if they are missing
+func (ic *RAGInteractiveChat) ensureModels(ctx context.Context) error {
+	opts := EnsureOptions{
+		Client:   ic.client,
+		Writer:   ic.writer,
+		AutoPull: ic.autoPull,
+		TTY:      ic.isTTY,
+	}
+	if ic.isTTY {
+		opts.Confirm = func(question string) (bool, error) {
+			return promptYesNo(ic.line, ic.reader, ic.writer, ic.isTTY, question)
+		}
+	}
+
+	if err := EnsureModel(ctx, ic.model, opts); err != nil {
+		return err
+	}
+	if ic.retriever != nil {
+		if err := EnsureModel(ctx, ic.retriever.Model(), opts); err != nil {
+			return fmt.Errorf("embedding model: %w", err)
+		}
+	}
+	return nil
+}
+
 // handleCommand processes slash commands
 func (ic *RAGInteractiveChat) handleCommand(ctx context.Context, cmd string) error {
 	parts := strings.Fields(cmd)
@@ -261,6 +333,37 @@ func (ic *RAGInteractiveChat) handleCommand(ctx context.Context, cmd string) err
 		fmt.Fprintf(ic.writer, "Model: %s\n", ic.model)
 		fmt.Fprintf(ic.writer, "Messages in context: %d\n", len(ic.messages))
 		fmt.Fprintf(ic.writer, "RAG Top-K: %d\n", ic.topK)
+		if len(ic.options) > 0 {
+			fmt.Fprintf(ic.writer, "Options: %s\n", strings.Join(formatOptions(ic.options), ", "))
+		}
+		if ic.client != nil {
+			writeEndpointStatus(ic.writer, ic.client, ic.turnEndpoints)
+		}
+		return nil
+
+	case SystemCommand:
+		// The system prompt is only added to the history with the first question
+		messages := ic.messages
+		if len(messages) == 0 {
+			messages = withSystemPrompt(nil, ic.system)
+		}
+		messages, err := systemCommand(ic.writer, messages, cmd)
+		if err != nil {
+			fmt.Fprintf(ic.writer, "Error: %v\n", err)
+			return nil
+		}
+		ic.system = systemPrompt(messages)
+		if len(ic.messages) > 0 {
+			ic.messages = messages
+		}
+		return nil
+
+	case SetCommand, ShowCommand, ResetCommand:
+		options, err := optionsCommand(ic.writer, ic.options, cmd)
+		ic.options = options
+		if err != nil {
+			fmt.Fprintf(ic.writer, "Error: %v\n", err)
+		}
 		return nil
 
 	default:
@@ -277,6 +380,10 @@ Available Commands:
   /exit     - Exit the chat session
   /clear    - Clear conversation history
   /status   - Show current session status
+  /set <param> <value> - Set a model parameter, e.g. /set temperature 0.2
+  /show options        - Show the parameters set with /set
+  /reset options       - Clear the parameters set with /set
+  /system <text>|show|clear - Set, show or remove the system prompt
 
 RAG Features:
   - Each query automatically retrieves relevant context from the knowledge base
@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"ollamacli/internal/session"
+)
+
+const SessionCommand = "/session"
+
+// SessionTranscript converts a stored session to a transcript, the format
+// of `sessions export` that /load and --resume read
+func SessionTranscript(s *session.Session) *Transcript {
+	t := &Transcript{
+		Version:  TranscriptVersion,
+		Created:  s.Created,
+		Model:    s.Model,
+		Options:  s.Options,
+		Think:    s.Think,
+		Messages: s.Messages,
+		Branches: s.Branches,
+		Branch:   s.Branch,
+	}
+	if len(t.Messages) > 0 && t.Messages[0].Role == "system" {
+		t.System = t.Messages[0].Content
+	}
+	return t
+}
+
+// autosave writes the conversation to the session store after every change.
+// The session is created with the first message, so starting and leaving
+// the REPL does not leave empty sessions behind.
+func (ic *InteractiveChat) autosave() {
+	if ic.sessions == nil {
+		return
+	}
+	// Saving must not be cut short by the Ctrl+C that ends the session
+	ctx := context.Background()
+	if ic.current == nil {
+		if len(ic.messages) == 0 {
+			return
+		}
+		sess, err := ic.sessions.Create(ctx, "", ic.model)
+		if err != nil {
+			fmt.Fprintf(ic.writer, "Warning: failed to save session: %v\n", err)
+			return
+		}
+		ic.current = sess
+	}
+
+	ic.current.Model = ic.model
+	ic.current.Think = ic.think
+	ic.current.Options = ic.options
+	if len(ic.branches) > 0 {
+		ic.syncBranch()
+	}
+	ic.current.Messages = ic.GetHistory()
+	ic.current.Branches = append([]Branch(nil), ic.branches...)
+	ic.current.Branch = ic.branch
+	if err := ic.sessions.Save(ctx, ic.current); err != nil {
+		fmt.Fprintf(ic.writer, "Warning: failed to save session %s: %v\n", ic.current.Name, err)
+	}
+}
+
+// openSession continues a stored session
+func (ic *InteractiveChat) openSession(s *session.Session) {
+	ic.Resume(SessionTranscript(s))
+	ic.current = s
+}
+
+// sessionCommand handles /session new|list|switch|rename|delete
+func (ic *InteractiveChat) sessionCommand(ctx context.Context, args []string) error {
+	if ic.sessions == nil {
+		return fmt.Errorf("sessions are not enabled")
+	}
+
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+
+	switch {
+	case sub == "list" && len(args) == 0:
+		return ic.sessionList(ctx)
+
+	case sub == "new" && len(args) <= 1:
+		ic.autosave()
+		ic.resetConversation()
+		if len(args) == 1 {
+			sess, err := ic.sessions.Create(ctx, args[0], ic.model)
+			if err != nil {
+				return err
+			}
+			ic.current = sess
+			_, err = fmt.Fprintf(ic.writer, "Started session %s.\n", sess.Name)
+			return err
+		}
+		_, err := fmt.Fprintln(ic.writer, "Started a new session; it is saved with your first message.")
+		return err
+
+	case sub == "switch" && len(args) == 1:
+		sess, err := ic.sessions.Get(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if sess.Model != "" && sess.Model != ic.model && ic.client != nil {
+			if err := ic.ensureModel(ctx, sess.Model); err != nil {
+				return err
+			}
+		}
+		ic.autosave()
+		ic.openSession(sess)
+		_, err = fmt.Fprintf(ic.writer, "Switched to session %s (%d messages, model: %s)\n", sess.Name, len(sess.Messages), ic.model)
+		return err
+
+	case sub == "rename" && (len(args) == 1 || len(args) == 2):
+		target, newName := "", args[len(args)-1]
+		if len(args) == 2 {
+			target = args[0]
+		} else {
+			// Renaming the current session saves it first if it is new
+			ic.autosave()
+			if ic.current == nil {
+				return fmt.Errorf("the current session has no messages yet; use /session new <name>")
+			}
+			target = ic.current.Name
+		}
+		if err := ic.sessions.Rename(ctx, target, newName); err != nil {
+			return err
+		}
+		if ic.current != nil && (ic.current.Name == target || fmt.Sprint(ic.current.ID) == target) {
+			ic.current.Name = newName
+		}
+		_, err := fmt.Fprintf(ic.writer, "Renamed session %s to %s.\n", target, newName)
+		return err
+
+	case sub == "delete" && len(args) == 1:
+		sess, err := ic.sessions.Get(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if err := ic.sessions.Delete(ctx, args[0]); err != nil {
+			return err
+		}
+		if ic.current != nil && ic.current.ID == sess.ID {
+			// Otherwise the next autosave would bring it back
+			ic.resetConversation()
+			fmt.Fprintln(ic.writer, "Deleted the current session; starting a new one.")
+			return nil
+		}
+		_, err = fmt.Fprintf(ic.writer, "Deleted session %s.\n", sess.Name)
+		return err
+	}
+
+	return fmt.Errorf("usage: /session [list] | new [name] | switch <name> | rename [<name>] <new_name> | delete <name>")
+}
+
+func (ic *InteractiveChat) sessionList(ctx context.Context) error {
+	infos, err := ic.sessions.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		_, err := fmt.Fprintln(ic.writer, "No saved sessions yet.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(ic.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ID\tNAME\tMODEL\tMESSAGES\tUPDATED")
+	for _, info := range infos {
+		marker := " "
+		if ic.current != nil && ic.current.ID == info.ID {
+			marker = "*"
+		}
+		fmt.Fprintf(tw, "%s %d\t%s\t%s\t%d\t%s\n", marker, info.ID, info.Name, info.Model, info.Messages,
+			info.Updated.Local().Format("2006-01-02 15:04"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(ic.writer)
+	return err
+}
@@ -0,0 +1,127 @@
+package chat
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/session"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestSessionAutosaveAndCommands(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{
+		{Name: "llama2", Replies: ollamatest.TextReplies("First answer", "Second answer", "Third answer", "Fourth answer")},
+	}})
+	ctx := context.Background()
+	store, err := session.NewStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer store.Close()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &out,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+		sessions: store,
+	}
+
+	// Nothing is stored until the first message
+	if infos, _ := store.List(ctx); len(infos) != 0 {
+		t.Fatalf("expected no sessions yet, got %+v", infos)
+	}
+	if err := ic.sendMessage(ctx, "Question one"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	latest, err := store.Latest(ctx)
+	if err != nil || len(latest.Messages) != 2 || latest.Messages[1].Content != "First answer" {
+		t.Fatalf("expected the turn to be autosaved, got %+v: %v", latest, err)
+	}
+
+	if err := ic.handleCommand(context.Background(), "/session rename work"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if err := ic.handleCommand(context.Background(), "/session new"); err != nil {
+		t.Fatalf("new failed: %v", err)
+	}
+	if len(ic.messages) != 0 {
+		t.Fatalf("expected an empty conversation, got %d messages", len(ic.messages))
+	}
+	if err := ic.sendMessage(ctx, "Question two"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	out.Reset()
+	if err := ic.handleCommand(context.Background(), "/session list"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "* 2") || !strings.Contains(out.String(), "work") {
+		t.Errorf("expected both sessions with the current one marked, got:\n%s", out.String())
+	}
+
+	// Switching brings back the earlier conversation and carries it on
+	if err := ic.handleCommand(context.Background(), "/session switch work"); err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	if len(ic.messages) != 2 || ic.messages[0].Content != "Question one" {
+		t.Fatalf("expected the work session history, got %+v", ic.messages)
+	}
+	if err := ic.sendMessage(ctx, "Follow up"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	work, err := store.Get(ctx, "work")
+	if err != nil || len(work.Messages) != 4 {
+		t.Fatalf("expected the follow-up saved to work, got %+v: %v", work, err)
+	}
+
+	// Alternatives made with /retry survive reopening the session
+	if err := ic.handleCommand(context.Background(), "/retry"); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	ic.autosave()
+	work, err = store.Get(ctx, "work")
+	if err != nil || len(work.Branches) != 2 || work.Branch != 2 {
+		t.Fatalf("expected both branches saved, got %+v: %v", work, err)
+	}
+	reopened := &InteractiveChat{writer: &out}
+	reopened.openSession(work)
+	if err := reopened.handleCommand(context.Background(), "/branch checkout 1"); err != nil || reopened.messages[3].Content != "Third answer" {
+		t.Errorf("expected the original answer on branch 1, got %+v: %v", reopened.messages, err)
+	}
+	if transcript := SessionTranscript(work); len(transcript.Branches) != 2 || transcript.Branch != 2 {
+		t.Errorf("expected branches in the exported transcript, got %+v", transcript)
+	}
+	if got := reopened.Transcript().Created; !got.Equal(work.Created) {
+		t.Errorf("expected the session's created time %v, got %v", work.Created, got)
+	}
+
+	ic.images = []string{"pending"}
+	if err := ic.handleCommand(context.Background(), "/session delete work"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if len(ic.messages) != 0 || ic.images != nil || ic.turnEndpoints != nil || ic.branches != nil {
+		t.Errorf("expected a fresh conversation after deleting the current session, got %+v", ic)
+	}
+	if _, err := store.Get(ctx, "work"); err == nil {
+		t.Error("expected the session to be deleted")
+	}
+	ic.autosave()
+	if infos, _ := store.List(ctx); len(infos) != 1 {
+		t.Errorf("deleting the current session should not bring it back: %+v", infos)
+	}
+}
+
+func TestSessionCommandWithoutStore(t *testing.T) {
+	ic := &InteractiveChat{writer: &strings.Builder{}}
+	if err := ic.handleCommand(context.Background(), "/session list"); err == nil {
+		t.Error("expected an error when sessions are disabled")
+	}
+}
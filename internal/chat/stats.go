@@ -0,0 +1,51 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ollamacli/internal/output"
+)
+
+// statsCommand toggles the stats line printed after each reply
+func (ic *InteractiveChat) statsCommand(args []string) error {
+	switch {
+	case len(args) == 0:
+		ic.showStats = !ic.showStats
+	case len(args) == 1 && strings.EqualFold(args[0], "on"):
+		ic.showStats = true
+	case len(args) == 1 && strings.EqualFold(args[0], "off"):
+		ic.showStats = false
+	default:
+		return fmt.Errorf("usage: /stats [on|off]")
+	}
+
+	state := "off"
+	if ic.showStats {
+		state = "on"
+	}
+	_, err := fmt.Fprintf(ic.writer, "Stats after each reply: %s\n", state)
+	return err
+}
+
+// sessionSummary describes the totals of every reply so far
+func (ic *InteractiveChat) sessionSummary() string {
+	// The summed time to first token is only meaningful as an average
+	totals := ic.session
+	totals.TimeToFirstToken = 0
+	summary := fmt.Sprintf("%d replies, %s", ic.sessionReplies, output.StatsLine(totals))
+	if ic.session.TimeToFirstToken > 0 {
+		average := ic.session.TimeToFirstToken / time.Duration(ic.sessionReplies)
+		summary += fmt.Sprintf(" | avg first token %s", average.Round(time.Millisecond))
+	}
+	return summary
+}
+
+// dim renders secondary output faintly on terminals
+func (ic *InteractiveChat) dim(s string) string {
+	if !ic.isTTY {
+		return s
+	}
+	return "\033[2m" + s + "\033[0m"
+}
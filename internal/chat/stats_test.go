@@ -0,0 +1,60 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestStatsCommand(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{
+		Name:    "llama2",
+		Replies: ollamatest.TextReplies("one two three four", "five six"),
+	}}})
+
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &out,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+	}
+
+	// Stats are off by default
+	if err := ic.sendMessage(context.Background(), "Count please"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	if strings.Contains(out.String(), "tok/s") {
+		t.Errorf("stats should be off by default: %q", out.String())
+	}
+
+	if err := ic.handleCommand(context.Background(), "/stats"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	out.Reset()
+	if err := ic.sendMessage(context.Background(), "More"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	// The fake server reports 10ms per generated token
+	if !strings.Contains(out.String(), "five six\n") || !strings.Contains(out.String(), "2 tokens at 100.0 tok/s | first token") {
+		t.Errorf("expected a stats line after the reply, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := ic.handleCommand(context.Background(), "/status"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Session totals:\033[0m 2 replies, prompt 9 tokens") || !strings.Contains(out.String(), "6 tokens at 100.0 tok/s") {
+		t.Errorf("expected session totals in /status, got %q", out.String())
+	}
+
+	if err := ic.handleCommand(context.Background(), "/stats off"); err != nil || ic.showStats {
+		t.Errorf("expected /stats off to disable stats (%v)", err)
+	}
+	if err := ic.handleCommand(context.Background(), "/stats sometimes"); err == nil {
+		t.Error("expected a usage error")
+	}
+}
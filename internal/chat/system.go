@@ -0,0 +1,159 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/persona"
+)
+
+const (
+	SystemCommand  = "/system"
+	PersonaCommand = "/persona"
+)
+
+// ReadSystemPrompt returns the system prompt given with --system or
+// --system-file; at most one of them may be set
+func ReadSystemPrompt(text, file string) (string, error) {
+	if file == "" {
+		return strings.TrimSpace(text), nil
+	}
+	if text != "" {
+		return "", fmt.Errorf("use either --system or --system-file, not both")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read system prompt: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// systemPrompt returns the system prompt, kept as the first message
+func systemPrompt(messages []client.ChatMessage) string {
+	if len(messages) > 0 && messages[0].Role == "system" {
+		return messages[0].Content
+	}
+	return ""
+}
+
+// withSystemPrompt returns messages with the system prompt replaced by
+// text, or removed if text is empty. The slice is copied because branches
+// may share it.
+func withSystemPrompt(messages []client.ChatMessage, text string) []client.ChatMessage {
+	if len(messages) > 0 && messages[0].Role == "system" {
+		messages = messages[1:]
+	}
+	updated := make([]client.ChatMessage, 0, len(messages)+1)
+	if text != "" {
+		updated = append(updated, client.ChatMessage{Role: "system", Content: text})
+	}
+	return append(updated, messages...)
+}
+
+// systemCommand handles /system <text>|show|clear for both REPLs, returning
+// the new history. The text is taken verbatim from the command line.
+func systemCommand(w io.Writer, messages []client.ChatMessage, command string) ([]client.ChatMessage, error) {
+	text := strings.TrimSpace(strings.TrimPrefix(command, SystemCommand))
+	switch text {
+	case "":
+		return messages, fmt.Errorf("usage: /system <text> | show | clear")
+	case "show":
+		if prompt := systemPrompt(messages); prompt != "" {
+			fmt.Fprintln(w, prompt)
+		} else {
+			fmt.Fprintln(w, "No system prompt set.")
+		}
+		return messages, nil
+	case "clear":
+		fmt.Fprintln(w, "System prompt cleared.")
+		return withSystemPrompt(messages, ""), nil
+	}
+	fmt.Fprintln(w, "System prompt set.")
+	return withSystemPrompt(messages, text), nil
+}
+
+// usePersona applies a persona: its system prompt and options replace the
+// current ones and its model, if any, becomes the active model. The
+// conversation so far is kept.
+func (ic *InteractiveChat) usePersona(ctx context.Context, p *persona.Persona) error {
+	if p.Model != "" && p.Model != ic.model {
+		if ic.client != nil {
+			if err := ic.ensureModel(ctx, p.Model); err != nil {
+				return err
+			}
+		}
+		ic.model = p.Model
+	}
+	ic.persona = p
+	ic.options = p.Options
+	ic.messages = withSystemPrompt(ic.messages, p.System)
+
+	fmt.Fprintf(ic.writer, "Using persona %s (model: %s)\n", p.Name, ic.model)
+	if p.Greeting != "" {
+		fmt.Fprintf(ic.writer, "\n%s\n", p.Greeting)
+	}
+	_, err := fmt.Fprintln(ic.writer)
+	return err
+}
+
+// personaCommand handles /persona [list] | use <name>
+func (ic *InteractiveChat) personaCommand(ctx context.Context, args []string) error {
+	if ic.personaDir == "" {
+		return fmt.Errorf("personas are not available in this session")
+	}
+
+	switch {
+	case len(args) == 0:
+		if ic.persona == nil {
+			_, err := fmt.Fprintln(ic.writer, "No persona in use; /persona list shows the available ones.")
+			return err
+		}
+		_, err := fmt.Fprintf(ic.writer, "Using persona %s\n", ic.persona.Name)
+		return err
+	case args[0] == "list" && len(args) == 1:
+		return ic.personaList()
+	case args[0] == "use" && len(args) == 2:
+		p, err := persona.Load(ic.personaDir, args[1])
+		if errors.Is(err, persona.ErrNotFound) {
+			return fmt.Errorf("no persona %s (see /persona list)", args[1])
+		}
+		if err != nil {
+			return err
+		}
+		return ic.usePersona(ctx, p)
+	}
+	return fmt.Errorf("usage: /persona [list] | use <name>")
+}
+
+func (ic *InteractiveChat) personaList() error {
+	personas, err := persona.List(ic.personaDir)
+	if err != nil {
+		return err
+	}
+	if len(personas) == 0 {
+		_, err := fmt.Fprintf(ic.writer, "No personas yet; add YAML files to %s.\n", ic.personaDir)
+		return err
+	}
+
+	for _, p := range personas {
+		marker := " "
+		if ic.persona != nil && ic.persona.Name == p.Name {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %s", marker, p.Name)
+		if p.Model != "" {
+			line += " (" + p.Model + ")"
+		}
+		if p.Description != "" {
+			line += " - " + p.Description
+		}
+		fmt.Fprintln(ic.writer, line)
+	}
+	_, err = fmt.Fprintln(ic.writer)
+	return err
+}
@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/log"
+	"ollamacli/internal/persona"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestSystemCommand(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}}})
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &out,
+		model:    "llama2",
+		messages: make([]client.ChatMessage, 0),
+	}
+
+	if err := ic.handleCommand(context.Background(), "/system You are a  terse reviewer."); err != nil {
+		t.Fatalf("/system failed: %v", err)
+	}
+	if err := ic.sendMessage(context.Background(), "Hello"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	req, _ := srv.LastRequest("/api/chat")
+	var body ollamatest.ChatRequest
+	req.Decode(&body)
+	if len(body.Messages) != 2 || body.Messages[0].Role != "system" || body.Messages[0].Content != "You are a  terse reviewer." {
+		t.Errorf("expected the system prompt first, got %+v", body.Messages)
+	}
+
+	// Replacing the prompt keeps the conversation
+	ic.handleCommand(context.Background(), "/system Answer in French.")
+	if len(ic.messages) != 3 || systemPrompt(ic.messages) != "Answer in French." {
+		t.Errorf("expected the prompt replaced in place, got %+v", ic.messages)
+	}
+	out.Reset()
+	ic.handleCommand(context.Background(), "/system show")
+	if out.String() != "Answer in French.\n" {
+		t.Errorf("unexpected /system show output: %q", out.String())
+	}
+
+	// /clear drops the conversation but not the system prompt
+	ic.clearHistory()
+	if len(ic.messages) != 1 || systemPrompt(ic.messages) != "Answer in French." {
+		t.Errorf("expected only the system prompt after /clear, got %+v", ic.messages)
+	}
+
+	ic.handleCommand(context.Background(), "/system clear")
+	if len(ic.messages) != 0 {
+		t.Errorf("expected the system prompt removed, got %+v", ic.messages)
+	}
+	if err := ic.handleCommand(context.Background(), "/system"); err == nil {
+		t.Error("expected /system without text to fail")
+	}
+}
+
+func TestReadSystemPrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.txt")
+	os.WriteFile(path, []byte("Translate into English.\n"), 0o644)
+
+	if prompt, err := ReadSystemPrompt("", path); err != nil || prompt != "Translate into English." {
+		t.Errorf("expected the file contents, got %q, %v", prompt, err)
+	}
+	if prompt, err := ReadSystemPrompt(" Be brief. ", ""); err != nil || prompt != "Be brief." {
+		t.Errorf("expected the text, got %q, %v", prompt, err)
+	}
+	if _, err := ReadSystemPrompt("Be brief.", path); err == nil {
+		t.Error("expected --system with --system-file to fail")
+	}
+	if _, err := ReadSystemPrompt("", filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected a missing file to fail")
+	}
+}
+
+func TestPersonaCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "reviewer.yaml"), []byte(`description: Strict code reviewer
+model: mistral
+system: You review Go code.
+options:
+  temperature: 0.1
+greeting: Paste a diff to review.
+`), 0o644)
+
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}, {Name: "mistral"}}})
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:     client.New(client.Options{BaseURL: srv.URL}),
+		writer:     &out,
+		model:      "llama2",
+		messages:   []client.ChatMessage{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hello!"}},
+		options:    map[string]interface{}{"seed": int64(42)},
+		personaDir: dir,
+	}
+
+	if err := ic.handleCommand(context.Background(), "/persona list"); err != nil {
+		t.Fatalf("/persona list failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "  reviewer (mistral) - Strict code reviewer") {
+		t.Errorf("unexpected /persona list output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := ic.handleCommand(context.Background(), "/persona use reviewer"); err != nil {
+		t.Fatalf("/persona use failed: %v", err)
+	}
+	if ic.model != "mistral" || ic.options["temperature"] != 0.1 || ic.options["seed"] != nil {
+		t.Errorf("persona settings not applied: model %s, options %v", ic.model, ic.options)
+	}
+	if len(ic.messages) != 3 || systemPrompt(ic.messages) != "You review Go code." {
+		t.Errorf("expected the system prompt added to the conversation, got %+v", ic.messages)
+	}
+	if !strings.Contains(out.String(), "Paste a diff to review.") {
+		t.Errorf("expected the greeting, got %q", out.String())
+	}
+
+	out.Reset()
+	ic.handleCommand(context.Background(), "/persona list")
+	if !strings.Contains(out.String(), "* reviewer") {
+		t.Errorf("expected the current persona marked, got %q", out.String())
+	}
+	if err := ic.handleCommand(context.Background(), "/persona use translator"); err == nil || !strings.Contains(err.Error(), "no persona translator") {
+		t.Errorf("expected a missing persona error, got %v", err)
+	}
+}
+
+func TestNewInteractiveChatPersona(t *testing.T) {
+	p := &persona.Persona{
+		Name:    "translator",
+		System:  "Translate into English.",
+		Model:   "mistral",
+		Options: map[string]interface{}{"temperature": 0.3},
+	}
+
+	ic := NewInteractiveChat(Options{Logger: log.New("info", false), Writer: &strings.Builder{}, Persona: p})
+	if ic.model != "mistral" || ic.options["temperature"] != 0.3 || systemPrompt(ic.messages) != "Translate into English." {
+		t.Errorf("persona not applied: model %s, options %v, messages %+v", ic.model, ic.options, ic.messages)
+	}
+
+	// Flags given alongside the persona win
+	ic = NewInteractiveChat(Options{
+		Logger:       log.New("info", false),
+		Writer:       &strings.Builder{},
+		Persona:      p,
+		Model:        "llama2",
+		ModelOptions: map[string]interface{}{"seed": int64(1)},
+		System:       "Translate into German.",
+	})
+	if ic.model != "llama2" || ic.options["temperature"] != nil || systemPrompt(ic.messages) != "Translate into German." {
+		t.Errorf("expected flags to override the persona: model %s, options %v, messages %+v", ic.model, ic.options, ic.messages)
+	}
+}
+
+func TestRAGSystemCommand(t *testing.T) {
+	var out strings.Builder
+	ic := NewRAGInteractiveChat(RAGOptions{Logger: log.New("info", false), Writer: &out})
+	if ic.system != DefaultRAGSystemPrompt {
+		t.Errorf("expected the default RAG prompt, got %q", ic.system)
+	}
+
+	ic.handleCommand(context.Background(), "/system show")
+	if !strings.Contains(out.String(), DefaultRAGSystemPrompt) {
+		t.Errorf("expected /system show to print the default prompt, got %q", out.String())
+	}
+	ic.handleCommand(context.Background(), "/system Answer only from the context.")
+	if ic.system != "Answer only from the context." || len(ic.messages) != 0 {
+		t.Errorf("expected the prompt saved for the first question, got %q, %+v", ic.system, ic.messages)
+	}
+
+	ic = NewRAGInteractiveChat(RAGOptions{Logger: log.New("info", false), Writer: &out, System: "Be brief."})
+	if ic.system != "Be brief." {
+		t.Errorf("expected RAGOptions.System to replace the default, got %q", ic.system)
+	}
+}
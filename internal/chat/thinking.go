@@ -0,0 +1,208 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"ollamacli/internal/client"
+)
+
+const ThinkCommand = "/think"
+
+// Thinking modes set with /think. The default leaves thinking to the server
+// and shows whatever reasoning the model returns.
+const (
+	ThinkDefault = ""
+	ThinkOn      = "on"
+	ThinkOff     = "off"
+	ThinkHide    = "hide"
+)
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkSplitter separates <think>...</think> blocks that some models write
+// into their content from the answer. Tags may be split across chunks; a
+// block is only recognised before the answer starts, so an answer that
+// talks about the tag is left alone.
+type thinkSplitter struct {
+	pending  string
+	inside   bool
+	thought  bool
+	answered bool
+}
+
+// feed returns the thinking and answer text in a chunk, holding back
+// anything that could be the start of a tag
+func (s *thinkSplitter) feed(chunk string) (thinking, content string) {
+	buf := s.pending + chunk
+	s.pending = ""
+
+	for buf != "" {
+		if s.inside {
+			if i := strings.Index(buf, thinkClose); i >= 0 {
+				thinking += buf[:i]
+				buf = buf[i+len(thinkClose):]
+				s.inside = false
+				continue
+			}
+			keep := partialTag(buf, thinkClose)
+			thinking += buf[:len(buf)-keep]
+			s.pending = buf[len(buf)-keep:]
+			return thinking, content
+		}
+
+		if !s.answered {
+			trimmed := strings.TrimLeft(buf, " \t\r\n")
+			if strings.HasPrefix(trimmed, thinkOpen) {
+				s.inside, s.thought = true, true
+				buf = trimmed[len(thinkOpen):]
+				continue
+			}
+			if strings.HasPrefix(thinkOpen, trimmed) {
+				s.pending = buf
+				return thinking, content
+			}
+			s.answered = true
+			// Drop the blank lines models put between thinking and answer
+			if s.thought {
+				buf = trimmed
+			}
+		}
+		content += buf
+		buf = ""
+	}
+	return thinking, content
+}
+
+// flush returns text held back at the end of the stream
+func (s *thinkSplitter) flush() (thinking, content string) {
+	pending := s.pending
+	s.pending = ""
+	if s.inside {
+		return pending, ""
+	}
+	if s.thought {
+		pending = strings.TrimLeft(pending, " \t\r\n")
+	}
+	return "", pending
+}
+
+// partialTag returns the length of the longest suffix of s that begins tag
+func partialTag(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// thinkingView prints a reply's thinking dimmed ahead of the answer, or
+// collapses it to a one-line summary in hide mode
+type thinkingView struct {
+	w       io.Writer
+	mode    string
+	tty     bool
+	started time.Time
+	shown   bool
+	done    bool
+}
+
+func (v *thinkingView) dim(s string) string {
+	if !v.tty {
+		return s
+	}
+	return "\033[2m" + s + "\033[0m"
+}
+
+// thinking shows a chunk of reasoning
+func (v *thinkingView) thinking(chunk string) {
+	if chunk == "" || v.done {
+		return
+	}
+	if !v.shown {
+		v.shown = true
+		v.started = time.Now()
+		if v.mode != ThinkHide {
+			fmt.Fprintln(v.w, v.dim("Thinking..."))
+		} else if v.tty {
+			fmt.Fprint(v.w, v.dim("Thinking..."))
+		}
+	}
+	if v.mode != ThinkHide {
+		fmt.Fprint(v.w, v.dim(chunk))
+	}
+}
+
+// answer ends the thinking section before the first chunk of the answer
+func (v *thinkingView) answer() {
+	if !v.shown || v.done {
+		return
+	}
+	v.done = true
+	if v.mode != ThinkHide {
+		fmt.Fprintf(v.w, "\n%s\n\n", v.dim("...done thinking."))
+		return
+	}
+	summary := fmt.Sprintf("Thought for %s (/think on to show)", time.Since(v.started).Round(100*time.Millisecond))
+	if v.tty {
+		fmt.Fprintf(v.w, "\r\033[K%s\n\n", v.dim(summary))
+	} else {
+		fmt.Fprintf(v.w, "%s\n\n", summary)
+	}
+}
+
+// thinkCommand shows or changes the thinking mode
+func (ic *InteractiveChat) thinkCommand(args []string) error {
+	if len(args) == 0 {
+		mode := ic.think
+		if mode == ThinkDefault {
+			mode = "server default"
+		}
+		_, err := fmt.Fprintf(ic.writer, "Thinking: %s\n", mode)
+		return err
+	}
+
+	switch mode := strings.ToLower(args[0]); {
+	case len(args) > 1:
+	case mode == ThinkOn:
+		ic.think = mode
+		_, err := fmt.Fprintln(ic.writer, "Thinking enabled and shown.")
+		return err
+	case mode == ThinkOff:
+		ic.think = mode
+		_, err := fmt.Fprintln(ic.writer, "Thinking disabled.")
+		return err
+	case mode == ThinkHide:
+		ic.think = mode
+		_, err := fmt.Fprintln(ic.writer, "Thinking enabled but collapsed; it is still kept in saved transcripts.")
+		return err
+	}
+	return fmt.Errorf("usage: /think on|off|hide")
+}
+
+// thinkOption returns the think field to send for the current mode
+func (ic *InteractiveChat) thinkOption() *bool {
+	if ic.think == ThinkDefault {
+		return nil
+	}
+	think := ic.think != ThinkOff
+	return &think
+}
+
+// modelHistory returns the messages to send back to the model. Earlier
+// reasoning is left out: it would use up context, and models are trained
+// without it.
+func (ic *InteractiveChat) modelHistory() []client.ChatMessage {
+	history := make([]client.ChatMessage, len(ic.messages))
+	for i, msg := range ic.messages {
+		msg.Thinking = ""
+		history[i] = msg
+	}
+	return history
+}
@@ -0,0 +1,127 @@
+package chat
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestThinkSplitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		thinking string
+		content  string
+	}{
+		{"no tags", []string{"Hello ", "world"}, "", "Hello world"},
+		{"whole block", []string{"<think>plan</think>\n\nAnswer"}, "plan", "Answer"},
+		{"split tags", []string{"\n<thi", "nk>step one", " step two</th", "ink>", "\n\nThe ", "answer"}, "step one step two", "The answer"},
+		{"tag in answer", []string{"Use ", "<think> tags"}, "", "Use <think> tags"},
+		{"unterminated", []string{"<think>still going"}, "still going", ""},
+		{"lone bracket", []string{"<", "b>bold</b>"}, "", "<b>bold</b>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s thinkSplitter
+			var thinking, content strings.Builder
+			for _, chunk := range tt.chunks {
+				th, c := s.feed(chunk)
+				thinking.WriteString(th)
+				content.WriteString(c)
+			}
+			th, c := s.flush()
+			thinking.WriteString(th)
+			content.WriteString(c)
+
+			if thinking.String() != tt.thinking || content.String() != tt.content {
+				t.Errorf("got thinking %q content %q, want %q and %q", thinking.String(), content.String(), tt.thinking, tt.content)
+			}
+		})
+	}
+}
+
+func TestThinkingKeptOutOfHistory(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{
+		Name:         "deepseek-r1",
+		Capabilities: []string{"completion", "thinking"},
+		Replies: []ollamatest.Reply{
+			{Thinking: "The user greets me.", Content: "Hello!"},
+			{Content: "<think>Inline reasoning.</think>\n\nStill here."},
+		},
+	}}})
+
+	var out strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &out,
+		model:    "deepseek-r1",
+		messages: make([]client.ChatMessage, 0),
+	}
+
+	if err := ic.handleCommand(context.Background(), "/think on"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	if err := ic.sendMessage(context.Background(), "Hi"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Thinking...\nThe user greets me.\n...done thinking.\n\nHello!") {
+		t.Errorf("expected thinking before the answer, got %q", out.String())
+	}
+
+	if err := ic.handleCommand(context.Background(), "/think hide"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	out.Reset()
+	if err := ic.sendMessage(context.Background(), "Still there?"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	if strings.Contains(out.String(), "Inline reasoning") || !strings.Contains(out.String(), "Thought for") {
+		t.Errorf("expected hidden thinking to be collapsed, got %q", out.String())
+	}
+
+	// The second request carries no earlier reasoning
+	req, _ := srv.LastRequest("/api/chat")
+	var body ollamatest.ChatRequest
+	req.Decode(&body)
+	if body.Think == nil || !*body.Think {
+		t.Errorf("expected think=true, got %v", body.Think)
+	}
+	for _, msg := range body.Messages {
+		if msg.Thinking != "" {
+			t.Errorf("thinking was sent back to the model: %+v", msg)
+		}
+	}
+
+	history := ic.GetHistory()
+	if history[1].Thinking != "The user greets me." || history[3].Thinking != "Inline reasoning." || history[3].Content != "Still here." {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	// Saved transcripts keep the reasoning
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := ic.handleCommand(context.Background(), "/save "+path); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	saved, err := LoadTranscript(path)
+	if err != nil {
+		t.Fatalf("LoadTranscript failed: %v", err)
+	}
+	if len(saved.Messages) != 4 || saved.Messages[1].Thinking != "The user greets me." {
+		t.Errorf("expected thinking in the saved transcript, got %+v", saved.Messages)
+	}
+
+	if err := ic.handleCommand(context.Background(), "/think off"); err != nil {
+		t.Fatalf("handleCommand failed: %v", err)
+	}
+	if think := ic.thinkOption(); think == nil || *think {
+		t.Errorf("expected think=false after /think off, got %v", think)
+	}
+	if err := ic.handleCommand(context.Background(), "/think maybe"); err == nil {
+		t.Error("expected a usage error")
+	}
+}
@@ -0,0 +1,264 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/rag"
+)
+
+const (
+	// maxToolFileSize caps how much of a file read_file returns to the model
+	maxToolFileSize = 64 * 1024
+	// defaultMaxToolRounds bounds the request/tool/request loop of a single turn
+	defaultMaxToolRounds = 8
+)
+
+// ToolHandler executes a tool call using the arguments supplied by the model
+type ToolHandler func(ctx context.Context, args map[string]interface{}) (string, error)
+
+// RegisteredTool pairs a tool definition sent to the model with its local handler
+type RegisteredTool struct {
+	Definition client.Tool
+	Handler    ToolHandler
+	// Confirm asks the user before every call when set
+	Confirm bool
+}
+
+// ToolRegistry holds the tools the model is allowed to call during a chat
+type ToolRegistry struct {
+	tools map[string]RegisteredTool
+	order []string
+}
+
+// NewToolRegistry creates an empty tool registry
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]RegisteredTool),
+	}
+}
+
+// NewDefaultToolRegistry creates a registry with the built-in tools. The
+// knowledge base search tool is only registered when retriever is not nil.
+func NewDefaultToolRegistry(retriever *rag.Retriever) *ToolRegistry {
+	r := NewToolRegistry()
+	r.Register(readFileTool())
+	r.Register(listDirectoryTool())
+	if retriever != nil {
+		r.Register(searchKnowledgeBaseTool(retriever, 3))
+	}
+	return r
+}
+
+// Register adds a tool, replacing any tool with the same name
+func (r *ToolRegistry) Register(tool RegisteredTool) {
+	name := tool.Definition.Function.Name
+	if tool.Definition.Type == "" {
+		tool.Definition.Type = "function"
+	}
+	if _, exists := r.tools[name]; !exists {
+		r.order = append(r.order, name)
+	}
+	r.tools[name] = tool
+}
+
+// Get returns the registered tool with the given name
+func (r *ToolRegistry) Get(name string) (RegisteredTool, bool) {
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Names returns the registered tool names in registration order
+func (r *ToolRegistry) Names() []string {
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// Definitions returns the tool definitions to send with a chat request
+func (r *ToolRegistry) Definitions() []client.Tool {
+	defs := make([]client.Tool, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tools[name].Definition)
+	}
+	return defs
+}
+
+// Execute runs the handler for a tool call requested by the model
+func (r *ToolRegistry) Execute(ctx context.Context, call client.ToolCall) (string, error) {
+	tool, ok := r.tools[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", call.Function.Name)
+	}
+	return tool.Handler(ctx, call.Function.Arguments)
+}
+
+// describeToolCall renders a tool call as name(arguments) for confirmation prompts
+func describeToolCall(call client.ToolCall) string {
+	args, err := json.Marshal(call.Function.Arguments)
+	if err != nil || call.Function.Arguments == nil {
+		args = []byte("{}")
+	}
+	return fmt.Sprintf("%s(%s)", call.Function.Name, args)
+}
+
+func stringArg(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name]
+	if !ok {
+		return "", fmt.Errorf("missing argument: %s", name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %s must be a string", name)
+	}
+	return s, nil
+}
+
+func intArg(args map[string]interface{}, name string, fallback int) int {
+	switch v := args[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		var n int
+		if _, err := fmt.Sscanf(v, "%d", &n); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func readFileTool() RegisteredTool {
+	return RegisteredTool{
+		Definition: client.Tool{
+			Type: "function",
+			Function: client.ToolFunction{
+				Name:        "read_file",
+				Description: "Read the contents of a text file on the local machine",
+				Parameters: client.ToolParameters{
+					Type:     "object",
+					Required: []string{"path"},
+					Properties: map[string]client.ToolProperty{
+						"path": {Type: "string", Description: "Path of the file to read"},
+					},
+				},
+			},
+		},
+		Confirm: true,
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			path, err := stringArg(args, "path")
+			if err != nil {
+				return "", err
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file %s: %w", path, err)
+			}
+
+			if len(data) > maxToolFileSize {
+				return string(data[:maxToolFileSize]) + "\n[truncated]", nil
+			}
+			return string(data), nil
+		},
+	}
+}
+
+func listDirectoryTool() RegisteredTool {
+	return RegisteredTool{
+		Definition: client.Tool{
+			Type: "function",
+			Function: client.ToolFunction{
+				Name:        "list_directory",
+				Description: "List the files and directories in a directory on the local machine",
+				Parameters: client.ToolParameters{
+					Type: "object",
+					Properties: map[string]client.ToolProperty{
+						"path": {Type: "string", Description: "Directory to list (default: current directory)"},
+					},
+				},
+			},
+		},
+		Confirm: true,
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			path := "."
+			if _, ok := args["path"]; ok {
+				p, err := stringArg(args, "path")
+				if err != nil {
+					return "", err
+				}
+				if p != "" {
+					path = p
+				}
+			}
+
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to list directory %s: %w", path, err)
+			}
+
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				name := entry.Name()
+				if entry.IsDir() {
+					name += string(filepath.Separator)
+				}
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			if len(names) == 0 {
+				return "(empty directory)", nil
+			}
+			return strings.Join(names, "\n"), nil
+		},
+	}
+}
+
+func searchKnowledgeBaseTool(retriever *rag.Retriever, defaultLimit int) RegisteredTool {
+	return RegisteredTool{
+		Definition: client.Tool{
+			Type: "function",
+			Function: client.ToolFunction{
+				Name:        "search_knowledge_base",
+				Description: "Search the local knowledge base for passages relevant to a query",
+				Parameters: client.ToolParameters{
+					Type:     "object",
+					Required: []string{"query"},
+					Properties: map[string]client.ToolProperty{
+						"query": {Type: "string", Description: "What to search for"},
+						"limit": {Type: "integer", Description: "Maximum number of passages to return"},
+					},
+				},
+			},
+		},
+		Confirm: true,
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			query, err := stringArg(args, "query")
+			if err != nil {
+				return "", err
+			}
+
+			limit := intArg(args, "limit", defaultLimit)
+			if limit <= 0 {
+				limit = defaultLimit
+			}
+
+			result, err := retriever.RetrieveContext(ctx, query, limit)
+			if err != nil {
+				return "", err
+			}
+			if result == "" {
+				return "No relevant documents found.", nil
+			}
+			return result, nil
+		},
+	}
+}
@@ -0,0 +1,229 @@
+package chat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+)
+
+func TestToolRegistryBuiltins(t *testing.T) {
+	registry := NewDefaultToolRegistry(nil)
+
+	names := registry.Names()
+	if len(names) != 2 || names[0] != "read_file" || names[1] != "list_directory" {
+		t.Fatalf("unexpected built-in tools: %v", names)
+	}
+
+	for _, def := range registry.Definitions() {
+		if def.Type != "function" {
+			t.Errorf("expected tool type 'function', got '%s'", def.Type)
+		}
+	}
+}
+
+func TestToolRegistryExecute(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(filePath, []byte("hello tools"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "sub"), 0o755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	registry := NewDefaultToolRegistry(nil)
+	ctx := context.Background()
+
+	content, err := registry.Execute(ctx, client.ToolCall{Function: client.ToolCallFunction{
+		Name:      "read_file",
+		Arguments: map[string]interface{}{"path": filePath},
+	}})
+	if err != nil {
+		t.Fatalf("read_file failed: %v", err)
+	}
+	if content != "hello tools" {
+		t.Errorf("expected file content, got %q", content)
+	}
+
+	listing, err := registry.Execute(ctx, client.ToolCall{Function: client.ToolCallFunction{
+		Name:      "list_directory",
+		Arguments: map[string]interface{}{"path": tmpDir},
+	}})
+	if err != nil {
+		t.Fatalf("list_directory failed: %v", err)
+	}
+	if !strings.Contains(listing, "notes.txt") || !strings.Contains(listing, "sub"+string(filepath.Separator)) {
+		t.Errorf("unexpected listing: %q", listing)
+	}
+
+	if _, err := registry.Execute(ctx, client.ToolCall{Function: client.ToolCallFunction{Name: "rm_rf"}}); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+
+	if _, err := registry.Execute(ctx, client.ToolCall{Function: client.ToolCallFunction{Name: "read_file"}}); err == nil {
+		t.Error("expected error for missing path argument")
+	}
+}
+
+func TestSendMessageRunsToolLoop(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "answer.txt")
+	if err := os.WriteFile(filePath, []byte("42"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	var requests []client.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		if len(requests) == 1 {
+			encoder.Encode(client.ChatResponse{
+				Message: client.ChatMessage{
+					Role: "assistant",
+					ToolCalls: []client.ToolCall{{Function: client.ToolCallFunction{
+						Name:      "read_file",
+						Arguments: map[string]interface{}{"path": filePath},
+					}}},
+				},
+			})
+			encoder.Encode(client.ChatResponse{Done: true})
+			return
+		}
+
+		encoder.Encode(client.ChatResponse{Message: client.ChatMessage{Role: "assistant", Content: "The answer is 42"}})
+		encoder.Encode(client.ChatResponse{Done: true})
+	}))
+	defer server.Close()
+
+	var outputBuf strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: server.URL}),
+		writer:   &outputBuf,
+		reader:   bufio.NewReader(strings.NewReader("y\n")),
+		model:    "test-model",
+		messages: make([]client.ChatMessage, 0),
+		tools:    NewDefaultToolRegistry(nil),
+	}
+
+	if err := ic.sendMessage(context.Background(), "What is in answer.txt?"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 chat requests, got %d", len(requests))
+	}
+	if len(requests[0].Tools) != 2 {
+		t.Errorf("expected tool definitions in request, got %d", len(requests[0].Tools))
+	}
+
+	second := requests[1].Messages
+	if len(second) != 3 {
+		t.Fatalf("expected user, assistant and tool messages, got %d", len(second))
+	}
+	if second[2].Role != "tool" || second[2].Content != "42" || second[2].ToolName != "read_file" {
+		t.Errorf("unexpected tool message: %+v", second[2])
+	}
+
+	last := ic.messages[len(ic.messages)-1]
+	if last.Role != "assistant" || last.Content != "The answer is 42" {
+		t.Errorf("unexpected final message: %+v", last)
+	}
+
+	if !strings.Contains(outputBuf.String(), "Allow tool call read_file") {
+		t.Errorf("expected confirmation prompt, got: %s", outputBuf.String())
+	}
+}
+
+func TestSendMessageDeniedToolCall(t *testing.T) {
+	calls := 0
+	var toolResult string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		calls++
+
+		encoder := json.NewEncoder(w)
+		if calls == 1 {
+			encoder.Encode(client.ChatResponse{
+				Message: client.ChatMessage{Role: "assistant", ToolCalls: []client.ToolCall{{
+					Function: client.ToolCallFunction{Name: "list_directory"},
+				}}},
+				Done: true,
+			})
+			return
+		}
+
+		toolResult = req.Messages[len(req.Messages)-1].Content
+		encoder.Encode(client.ChatResponse{Message: client.ChatMessage{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	defer server.Close()
+
+	var outputBuf strings.Builder
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: server.URL}),
+		writer:   &outputBuf,
+		reader:   bufio.NewReader(strings.NewReader("n\n")),
+		model:    "test-model",
+		messages: make([]client.ChatMessage, 0),
+		tools:    NewDefaultToolRegistry(nil),
+	}
+
+	if err := ic.sendMessage(context.Background(), "list files"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+
+	if !strings.Contains(toolResult, "denied") {
+		t.Errorf("expected denial to be reported to the model, got %q", toolResult)
+	}
+}
+
+func TestSendMessageGivesUpAfterMaxToolRounds(t *testing.T) {
+	tmpDir := t.TempDir()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// The model never stops asking for tools
+		json.NewEncoder(w).Encode(client.ChatResponse{
+			Message: client.ChatMessage{Role: "assistant", ToolCalls: []client.ToolCall{{
+				Function: client.ToolCallFunction{Name: "list_directory", Arguments: map[string]interface{}{"path": tmpDir}},
+			}}},
+			Done: true,
+		})
+	}))
+	defer server.Close()
+
+	ic := &InteractiveChat{
+		client:    client.New(client.Options{BaseURL: server.URL}),
+		writer:    &strings.Builder{},
+		reader:    bufio.NewReader(strings.NewReader("y\ny\n")),
+		model:     "test-model",
+		messages:  []client.ChatMessage{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hello"}},
+		tools:     NewDefaultToolRegistry(nil),
+		maxRounds: 1,
+	}
+
+	if err := ic.sendMessage(context.Background(), "list files"); err == nil || !strings.Contains(err.Error(), "giving up") {
+		t.Fatalf("expected the tool loop to give up, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 chat requests, got %d", calls)
+	}
+	// The abandoned turn must not leave unanswered tool calls behind
+	if len(ic.messages) != 2 || ic.messages[1].Content != "Hello" {
+		t.Errorf("expected the turn rolled back, got %+v", ic.messages)
+	}
+}
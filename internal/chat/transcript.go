@@ -0,0 +1,146 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"ollamacli/internal/client"
+)
+
+// TranscriptVersion is the schema version written by /save. Version 0 is
+// the plain JSON array of messages saved by earlier releases.
+const TranscriptVersion = 1
+
+// Transcript is a saved chat session: the messages plus what is needed to
+// carry on where it left off
+type Transcript struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Model   string    `json:"model,omitempty"`
+	// System is the system prompt, also kept as the first message
+	System  string                 `json:"system,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Think is the /think mode
+	Think    string               `json:"think,omitempty"`
+	Messages []client.ChatMessage `json:"messages"`
+	// Branches keeps every version made with /retry and /edit; Branch is
+	// the ID of the one in Messages
+	Branches []Branch `json:"branches,omitempty"`
+	Branch   int      `json:"branch,omitempty"`
+}
+
+// LoadTranscript reads a transcript saved with /save, including the plain
+// message arrays written before transcripts were versioned
+func LoadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat history: %w", err)
+	}
+
+	var t Transcript
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &t.Messages); err != nil {
+			return nil, fmt.Errorf("invalid chat history %s: %w", path, err)
+		}
+	} else if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid chat history %s: %w", path, err)
+	}
+
+	for i, b := range t.Branches {
+		if b.ID != i+1 {
+			return nil, fmt.Errorf("invalid chat history %s: branch %d is out of order", path, b.ID)
+		}
+	}
+	if len(t.Branches) > 0 && (t.Branch < 1 || t.Branch > len(t.Branches)) {
+		return nil, fmt.Errorf("invalid chat history %s: no branch %d", path, t.Branch)
+	}
+	if t.Version > TranscriptVersion {
+		return nil, fmt.Errorf("chat history %s has version %d; this version of ollamacli reads up to %d", path, t.Version, TranscriptVersion)
+	}
+	if t.System == "" && len(t.Messages) > 0 && t.Messages[0].Role == "system" {
+		t.System = t.Messages[0].Content
+	}
+	if t.System != "" && (len(t.Messages) == 0 || t.Messages[0].Role != "system") {
+		t.Messages = append([]client.ChatMessage{{Role: "system", Content: t.System}}, t.Messages...)
+	}
+	return &t, nil
+}
+
+// Save writes the transcript as indented JSON
+func (t *Transcript) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode chat history: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save chat history: %w", err)
+	}
+	return nil
+}
+
+// Transcript captures the current session. Created is stamped the first
+// time a conversation is saved and kept from then on.
+func (ic *InteractiveChat) Transcript() *Transcript {
+	if len(ic.branches) > 0 {
+		ic.syncBranch()
+	}
+	if ic.created.IsZero() {
+		ic.created = time.Now().UTC()
+	}
+	t := &Transcript{
+		Version:  TranscriptVersion,
+		Created:  ic.created,
+		Model:    ic.model,
+		Options:  ic.options,
+		Think:    ic.think,
+		Messages: ic.GetHistory(),
+		Branches: append([]Branch(nil), ic.branches...),
+		Branch:   ic.branch,
+	}
+	if len(t.Messages) > 0 && t.Messages[0].Role == "system" {
+		t.System = t.Messages[0].Content
+	}
+	return t
+}
+
+// Resume restores a saved session: its history and branches, model,
+// options and thinking mode. Settings missing from the transcript are left unchanged.
+func (ic *InteractiveChat) Resume(t *Transcript) {
+	ic.SetHistory(t.Messages)
+	if t.Model != "" {
+		ic.model = t.Model
+	}
+	if t.Options != nil {
+		ic.options = t.Options
+	}
+	if t.Think != "" {
+		ic.think = t.Think
+	}
+	ic.images = nil
+	ic.turnEndpoints = nil
+	ic.branches = append([]Branch(nil), t.Branches...)
+	ic.branch = t.Branch
+	ic.created = t.Created
+}
+
+func (ic *InteractiveChat) loadHistory(ctx context.Context, filename string) error {
+	t, err := LoadTranscript(filename)
+	if err != nil {
+		return err
+	}
+	if t.Model != "" && t.Model != ic.model && ic.client != nil {
+		if err := ic.ensureModel(ctx, t.Model); err != nil {
+			return err
+		}
+	}
+
+	ic.Resume(t)
+	// The loaded conversation is saved as a new session
+	ic.current = nil
+	_, err = fmt.Fprintf(ic.writer, "Loaded %d messages from %s (model: %s)\n", len(t.Messages), filename, ic.model)
+	return err
+}
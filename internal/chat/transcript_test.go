@@ -0,0 +1,166 @@
+package chat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/log"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestLoadCommandResumesSession(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{
+		{Name: "llama2"},
+		{Name: "mistral", Capabilities: []string{"completion", "thinking"}, Replies: ollamatest.TextReplies("Rome.")},
+	}})
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	var out strings.Builder
+	saved := &InteractiveChat{
+		writer:  &out,
+		model:   "mistral",
+		think:   ThinkHide,
+		options: map[string]interface{}{"temperature": 0.2},
+		messages: []client.ChatMessage{
+			{Role: "system", Content: "Answer briefly."},
+			{Role: "user", Content: "Capital of France?"},
+			{Role: "assistant", Content: "Paris.", Thinking: "Easy one."},
+		},
+	}
+	if err := saved.handleCommand(context.Background(), "/save "+path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	ic := &InteractiveChat{
+		client:   client.New(client.Options{BaseURL: srv.URL}),
+		writer:   &out,
+		model:    "llama2",
+		messages: []client.ChatMessage{{Role: "user", Content: "unrelated"}},
+	}
+	if err := ic.handleCommand(context.Background(), "/load "+path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Loaded 3 messages from") {
+		t.Errorf("expected a load confirmation, got %q", out.String())
+	}
+	if ic.model != "mistral" || ic.think != ThinkHide || ic.options["temperature"] != 0.2 {
+		t.Errorf("settings not restored: model=%s think=%s options=%v", ic.model, ic.think, ic.options)
+	}
+	if len(ic.messages) != 3 || ic.messages[2].Thinking != "Easy one." {
+		t.Fatalf("history not restored: %+v", ic.messages)
+	}
+
+	// The conversation carries on with the restored model and settings
+	if err := ic.sendMessage(context.Background(), "And Italy?"); err != nil {
+		t.Fatalf("sendMessage failed: %v", err)
+	}
+	req, _ := srv.LastRequest("/api/chat")
+	var body ollamatest.ChatRequest
+	if err := req.Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Model != "mistral" || len(body.Messages) != 4 || body.Messages[0].Content != "Answer briefly." || body.Options["temperature"] != 0.2 {
+		t.Errorf("unexpected request after load: %+v", body)
+	}
+}
+
+func TestResumeKeepsFlagSettings(t *testing.T) {
+	saved := &Transcript{
+		Version:  TranscriptVersion,
+		Model:    "mistral",
+		Options:  map[string]interface{}{"temperature": 0.2, "seed": 7},
+		Think:    ThinkHide,
+		Messages: []client.ChatMessage{{Role: "user", Content: "Hi"}},
+	}
+
+	// Settings from the command line win over the transcript's
+	ic := NewInteractiveChat(Options{
+		Logger:       log.New("info", false),
+		Writer:       &strings.Builder{},
+		Resume:       saved,
+		ModelOptions: map[string]interface{}{"temperature": 0.9},
+		Think:        ThinkOff,
+	})
+	if ic.model != "mistral" || ic.think != ThinkOff || ic.options["temperature"] != 0.9 || ic.options["seed"] != nil {
+		t.Errorf("flags should override the transcript: model=%s think=%s options=%v", ic.model, ic.think, ic.options)
+	}
+
+	// Without flags the transcript's settings are used
+	ic = NewInteractiveChat(Options{Logger: log.New("info", false), Writer: &strings.Builder{}, Resume: saved})
+	if ic.think != ThinkHide || ic.options["temperature"] != 0.2 {
+		t.Errorf("transcript settings not restored: think=%s options=%v", ic.think, ic.options)
+	}
+}
+
+func TestTranscriptKeepsCreated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	original := &Transcript{Version: TranscriptVersion, Created: created, Messages: []client.ChatMessage{{Role: "user", Content: "Hi"}}}
+	if err := original.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	ic := &InteractiveChat{writer: &out, model: "llama2"}
+	if err := ic.loadHistory(context.Background(), path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	// Saving the loaded conversation again keeps when it started
+	if err := ic.handleCommand(context.Background(), "/save "+path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	saved, err := LoadTranscript(path)
+	if err != nil || !saved.Created.Equal(created) {
+		t.Fatalf("expected created %v to be kept, got %+v: %v", created, saved, err)
+	}
+	if got := ic.Transcript().Created; !got.Equal(created) {
+		t.Errorf("expected created %v, got %v", created, got)
+	}
+
+	// A new conversation gets its own time, fixed from its first save
+	ic.clearHistory()
+	first := ic.Transcript().Created
+	if !first.After(created) {
+		t.Fatalf("expected a new created time after /clear, got %v", first)
+	}
+	if second := ic.Transcript().Created; !second.Equal(first) {
+		t.Errorf("created changed between saves: %v, then %v", first, second)
+	}
+}
+
+func TestLoadTranscriptFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	// Files saved before transcripts were versioned hold only the messages
+	legacy := filepath.Join(dir, "legacy.json")
+	os.WriteFile(legacy, []byte(`[{"role":"system","content":"Be terse."},{"role":"user","content":"Hi"}]`), 0o644)
+	tr, err := LoadTranscript(legacy)
+	if err != nil {
+		t.Fatalf("LoadTranscript failed: %v", err)
+	}
+	if tr.Version != 0 || tr.Model != "" || tr.System != "Be terse." || len(tr.Messages) != 2 {
+		t.Errorf("unexpected legacy transcript: %+v", tr)
+	}
+
+	// A system prompt saved only in the envelope becomes the first message
+	envelope := filepath.Join(dir, "envelope.json")
+	os.WriteFile(envelope, []byte(`{"version":1,"model":"llama2","system":"Be terse.","messages":[{"role":"user","content":"Hi"}]}`), 0o644)
+	tr, err = LoadTranscript(envelope)
+	if err != nil {
+		t.Fatalf("LoadTranscript failed: %v", err)
+	}
+	if len(tr.Messages) != 2 || tr.Messages[0].Role != "system" || tr.Messages[0].Content != "Be terse." {
+		t.Errorf("expected the system prompt to lead the history, got %+v", tr.Messages)
+	}
+
+	future := filepath.Join(dir, "future.json")
+	os.WriteFile(future, []byte(`{"version":99,"messages":[]}`), 0o644)
+	if _, err := LoadTranscript(future); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("expected a version error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// BlobExists reports whether the server already has the blob with the given
+// digest (e.g. "sha256:abc...")
+func (c *Client) BlobExists(ctx context.Context, digest string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout, TimeoutOverall, "/api/blobs")
+	defer cancel()
+
+	req, err := c.newRawRequest(ctx, "HEAD", "/api/blobs/"+digest, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err = timeoutCause(ctx, err); err != nil {
+		return false, fmt.Errorf("request failed: %w", transportError("/api/blobs", err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, newAPIError(resp.StatusCode, "/api/blobs", nil)
+	}
+}
+
+// CreateBlob uploads the contents of body as the blob with the given digest.
+// The server verifies the digest, so body must be the exact file contents.
+// Uploads can be large, so only the connect timeout applies.
+func (c *Client) CreateBlob(ctx context.Context, digest string, body io.Reader) error {
+	req, err := c.newRawRequest(ctx, "POST", "/api/blobs/"+digest, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", transportError("/api/blobs", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respData, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, "/api/blobs", respData)
+	}
+
+	return nil
+}
+
+// newRawRequest builds an authenticated request whose body is not JSON
+func (c *Client) newRawRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return req, nil
+}
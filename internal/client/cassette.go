@@ -0,0 +1,402 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCassetteMiss is returned in replay mode when no recorded interaction
+// matches a request
+var ErrCassetteMiss = errors.New("no recorded response")
+
+// Interaction is one request and its complete response, stored as a
+// cassette file. Credentials and other request headers are not recorded.
+type Interaction struct {
+	Request    CassetteRequest  `json:"request"`
+	Response   CassetteResponse `json:"response"`
+	RecordedAt time.Time        `json:"recorded_at"`
+}
+
+type CassetteRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	// Body holds JSON request bodies; other bodies are recorded by digest
+	// and, for uploads, size
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodyDigest  string          `json:"body_digest,omitempty"`
+	BodySize    int64           `json:"body_size,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+}
+
+// CassetteResponse keeps a response in the most readable form: Body for a
+// single JSON document, Chunks for an NDJSON stream and Text for anything else
+type CassetteResponse struct {
+	StatusCode  int               `json:"status_code"`
+	ContentType string            `json:"content_type,omitempty"`
+	Body        json.RawMessage   `json:"body,omitempty"`
+	Chunks      []json.RawMessage `json:"chunks,omitempty"`
+	Text        string            `json:"text,omitempty"`
+}
+
+// bytes rebuilds the response body as it was sent
+func (r CassetteResponse) bytes() []byte {
+	switch {
+	case r.Chunks != nil:
+		var buf bytes.Buffer
+		for _, chunk := range r.Chunks {
+			buf.Write(chunk)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes()
+	case r.Body != nil:
+		return r.Body
+	}
+	return []byte(r.Text)
+}
+
+// newCassetteRequest captures req and its body
+func newCassetteRequest(req *http.Request, body []byte) CassetteRequest {
+	recorded := CassetteRequest{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Query:       req.URL.RawQuery,
+		ContentType: req.Header.Get("Content-Type"),
+	}
+	if len(body) == 0 {
+		return recorded
+	}
+	if canonical, ok := canonicalJSON(body); ok {
+		recorded.Body = canonical
+	} else {
+		sum := sha256.Sum256(body)
+		recorded.BodyDigest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return recorded
+}
+
+// streamedBody reports whether a request body is an upload, such as a model
+// blob, that may be gigabytes and must not be held in memory
+func streamedBody(req *http.Request) bool {
+	return req.Body != nil && req.Header.Get("Content-Type") == "application/octet-stream"
+}
+
+// digestReader hashes a body as it is read
+type digestReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	size int64
+}
+
+func newDigestReader(body io.ReadCloser) *digestReader {
+	return &digestReader{ReadCloser: body, hash: sha256.New()}
+}
+
+func (r *digestReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+	return n, err
+}
+
+// record adds the digest and size of what was read to a cassette request
+func (r *digestReader) record(recorded *CassetteRequest) {
+	if r.size > 0 {
+		recorded.BodyDigest = "sha256:" + hex.EncodeToString(r.hash.Sum(nil))
+		recorded.BodySize = r.size
+	}
+}
+
+// key identifies equivalent requests regardless of JSON key order
+func (r CassetteRequest) key() string {
+	body := r.BodyDigest
+	if r.Body != nil {
+		body = string(r.Body)
+	}
+	return r.Method + " " + r.Path + "?" + r.Query + " " + body
+}
+
+func newCassetteResponse(resp *http.Response, body []byte) CassetteResponse {
+	recorded := CassetteResponse{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if chunks, ok := splitNDJSON(body); ok && (len(chunks) > 1 || strings.Contains(recorded.ContentType, "ndjson")) {
+		recorded.Chunks = chunks
+	} else if json.Valid(body) && len(bytes.TrimSpace(body)) > 0 {
+		recorded.Body = json.RawMessage(bytes.TrimSpace(body))
+	} else {
+		recorded.Text = string(body)
+	}
+	return recorded
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys
+func canonicalJSON(data []byte) (json.RawMessage, bool) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, false
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	return canonical, true
+}
+
+// splitNDJSON splits a body into its JSON values, one per line
+func splitNDJSON(body []byte) ([]json.RawMessage, bool) {
+	var chunks []json.RawMessage
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, false
+		}
+		chunks = append(chunks, json.RawMessage(line))
+	}
+	return chunks, len(chunks) > 0
+}
+
+// RecordMiddleware saves every request and its full response to a cassette
+// file in dir. Streams are passed through as they arrive and written out once
+// the caller closes the body.
+func RecordMiddleware(dir string) Middleware {
+	recorder := &recorder{dir: dir}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var body []byte
+			var upload *digestReader
+			if streamedBody(req) {
+				upload = newDigestReader(req.Body)
+				req = req.Clone(req.Context())
+				req.Body = upload
+			} else if req.Body != nil {
+				data, err := io.ReadAll(req.Body)
+				req.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				body = data
+				req = req.Clone(req.Context())
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return resp, err
+			}
+
+			request := newCassetteRequest(req, body)
+			resp.Body = &recordingBody{
+				ReadCloser: resp.Body,
+				save: func(data []byte) {
+					// The server has read the upload by the time it responds
+					if upload != nil {
+						upload.record(&request)
+					}
+					recorder.save(Interaction{
+						Request:    request,
+						Response:   newCassetteResponse(resp, data),
+						RecordedAt: time.Now().UTC(),
+					})
+				},
+			}
+			return resp, nil
+		})
+	}
+}
+
+type recorder struct {
+	mu   sync.Mutex
+	dir  string
+	next int
+}
+
+// save writes an interaction as the next numbered cassette file. Recording
+// errors must not break the request being recorded, so they are dropped.
+func (r *recorder) save(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return
+	}
+	if r.next == 0 {
+		// Continue numbering after cassettes from earlier runs
+		existing, _ := filepath.Glob(filepath.Join(r.dir, "*.json"))
+		r.next = len(existing)
+	}
+	r.next++
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return
+	}
+	name := fmt.Sprintf("%04d-%s%s.json", r.next, strings.ToLower(interaction.Request.Method),
+		strings.ReplaceAll(interaction.Request.Path, "/", "-"))
+	os.WriteFile(filepath.Join(r.dir, name), append(data, '\n'), 0644)
+}
+
+// recordingBody copies everything the caller reads and saves it once
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	save func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.once.Do(func() { b.save(b.buf.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.once.Do(func() { b.save(b.buf.Bytes()) })
+	return b.ReadCloser.Close()
+}
+
+// ReplayTransport answers requests from the cassettes in dir without
+// contacting a server. Matching requests are served in recorded order; once
+// they are used up the last one is repeated.
+func ReplayTransport(dir string) http.RoundTripper {
+	return &replayer{dir: dir}
+}
+
+type replayer struct {
+	once    sync.Once
+	dir     string
+	loadErr error
+
+	mu     sync.Mutex
+	queues map[string][]Interaction
+	served map[string]int
+}
+
+// LoadCassettes reads the interactions in dir in recorded order
+func LoadCassettes(dir string) ([]Interaction, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no cassettes found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	interactions := make([]Interaction, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	return interactions, nil
+}
+
+func (r *replayer) load() {
+	interactions, err := LoadCassettes(r.dir)
+	if err != nil {
+		r.loadErr = err
+		return
+	}
+
+	r.queues = make(map[string][]Interaction)
+	r.served = make(map[string]int)
+	for _, interaction := range interactions {
+		// Normalize hand-edited bodies so they match regardless of formatting
+		if interaction.Request.Body != nil {
+			if canonical, ok := canonicalJSON(interaction.Request.Body); ok {
+				interaction.Request.Body = canonical
+			}
+		}
+		key := interaction.Request.key()
+		r.queues[key] = append(r.queues[key], interaction)
+	}
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.once.Do(r.load)
+	if r.loadErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCassetteMiss, r.loadErr)
+	}
+
+	var request CassetteRequest
+	if streamedBody(req) {
+		upload := newDigestReader(req.Body)
+		_, err := io.Copy(io.Discard, upload)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request = newCassetteRequest(req, nil)
+		upload.record(&request)
+	} else if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request = newCassetteRequest(req, data)
+	} else {
+		request = newCassetteRequest(req, nil)
+	}
+
+	key := request.key()
+
+	r.mu.Lock()
+	queue := r.queues[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s in %s", ErrCassetteMiss, req.Method, req.URL.Path, r.dir)
+	}
+	index := r.served[key]
+	if index >= len(queue) {
+		index = len(queue) - 1
+	}
+	r.served[key]++
+	recorded := queue[index].Response
+	r.mu.Unlock()
+
+	data := recorded.bytes()
+	header := make(http.Header)
+	if recorded.ContentType != "" {
+		header.Set("Content-Type", recorded.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
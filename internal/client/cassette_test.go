@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models":[{"name":"llama2:latest"}]}`))
+		case "/api/chat":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Write([]byte(`{"message":{"role":"assistant","content":"Hel"},"done":false}` + "\n"))
+			w.Write([]byte(`{"message":{"role":"assistant","content":"lo"},"done":false}` + "\n"))
+			w.Write([]byte(`{"message":{"role":"assistant","content":""},"done":true,"eval_count":2}` + "\n"))
+		case "/api/show":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model 'missing' not found"}`))
+		}
+	}))
+
+	dir := t.TempDir()
+	recording := New(Options{BaseURL: server.URL, Token: "secret-token", Record: dir})
+	run := func(c *Client) (string, string, error) {
+		models, err := c.ListModels(context.Background())
+		if err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		stream, err := c.ChatStream(context.Background(), ChatRequest{Model: "llama2", Messages: []ChatMessage{{Role: RoleUser, Content: "hi"}}})
+		if err != nil {
+			t.Fatalf("ChatStream() error = %v", err)
+		}
+		var reply strings.Builder
+		for resp := range stream {
+			if resp.Err != nil {
+				t.Fatalf("stream error: %v", resp.Err)
+			}
+			reply.WriteString(resp.Message.Content)
+		}
+		_, showErr := c.ShowModel(context.Background(), ShowRequest{Name: "missing"})
+		return models.Models[0].Name, reply.String(), showErr
+	}
+
+	wantModel, wantReply, wantErr := run(recording)
+	server.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 3 {
+		t.Fatalf("recorded %d cassettes, want 3", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "secret-token") {
+			t.Errorf("%s contains the auth token", file)
+		}
+	}
+	interactions, err := LoadCassettes(dir)
+	if err != nil {
+		t.Fatalf("LoadCassettes() error = %v", err)
+	}
+	if len(interactions[1].Response.Chunks) != 3 {
+		t.Errorf("chat stream recorded as %+v, want 3 chunks", interactions[1].Response)
+	}
+
+	replaying := New(Options{BaseURL: "http://replay.invalid", Replay: dir})
+	gotModel, gotReply, gotErr := run(replaying)
+	if gotModel != wantModel || gotReply != wantReply {
+		t.Errorf("replay = %q %q, recorded %q %q", gotModel, gotReply, wantModel, wantReply)
+	}
+	if !errors.Is(gotErr, ErrModelNotFound) || gotErr.Error() != wantErr.Error() {
+		t.Errorf("replayed error = %v, recorded %v", gotErr, wantErr)
+	}
+}
+
+func TestReplayCassetteFromTestdata(t *testing.T) {
+	c := New(Options{BaseURL: "http://replay.invalid", Replay: filepath.Join("testdata", "cassettes", "generate")})
+
+	stream, err := c.GenerateStream(context.Background(), GenerateRequest{Model: "llama2", Prompt: "Why is the sky blue?"})
+	if err != nil {
+		t.Fatalf("GenerateStream() error = %v", err)
+	}
+
+	var text strings.Builder
+	var final GenerateResponse
+	for resp := range stream {
+		if resp.Err != nil {
+			t.Fatalf("stream error: %v", resp.Err)
+		}
+		text.WriteString(resp.Response)
+		final = resp
+	}
+	if text.String() != "Rayleigh scattering." || !final.Done || final.EvalCount != 3 {
+		t.Errorf("replayed %q, final %+v", text.String(), final)
+	}
+}
+
+func TestReplayMissIsNotRetried(t *testing.T) {
+	c := New(Options{
+		BaseURL:    "http://replay.invalid",
+		Replay:     filepath.Join("testdata", "cassettes", "generate"),
+		RetryDelay: time.Second,
+	})
+
+	start := time.Now()
+	_, err := c.Generate(context.Background(), GenerateRequest{Model: "llama2", Prompt: "something else"})
+	if !errors.Is(err, ErrCassetteMiss) {
+		t.Fatalf("Generate() error = %v, want ErrCassetteMiss", err)
+	}
+	if errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("a cassette miss should not look like an unreachable server: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("miss took %v, it should not be retried", elapsed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,16 +15,54 @@ type Client struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
-	retries    int
-	retryDelay time.Duration
+	// Time limits; see the matching Options fields
+	timeout           time.Duration
+	firstTokenTimeout time.Duration
+	idleTimeout       time.Duration
+	streamTimeout     time.Duration
+	retries           int
+	retryPolicy       RetryPolicy
+	retryPolicies     map[string]RetryPolicy
+	pool              *Pool
+	// sse makes streams parse server-sent events instead of JSON lines
+	sse bool
 }
 
 type Options struct {
-	BaseURL    string
-	Token      string
-	Timeout    time.Duration
-	Retries    int
-	RetryDelay time.Duration
+	BaseURL string
+	Token   string
+	// Timeout is the overall deadline of a non-streaming request (default
+	// 30s, negative for none)
+	Timeout time.Duration
+	// ConnectTimeout limits dialing and the TLS handshake (default: the
+	// transport's own limits)
+	ConnectTimeout time.Duration
+	// FirstTokenTimeout limits the wait for the first chunk of a stream,
+	// which includes loading the model
+	FirstTokenTimeout time.Duration
+	// IdleTimeout limits the pause between chunks of a stream
+	IdleTimeout time.Duration
+	// StreamTimeout is the overall deadline of a streaming request; streams
+	// have no overall deadline by default
+	StreamTimeout time.Duration
+	Retries       int
+	RetryDelay    time.Duration
+	// RetryPolicy replaces the default policy built from Retries and RetryDelay
+	RetryPolicy *RetryPolicy
+	// RetryPolicies overrides the retry policy per endpoint path (e.g. "/api/pull")
+	RetryPolicies map[string]RetryPolicy
+	// Transport is the base transport (default: http.DefaultTransport)
+	Transport http.RoundTripper
+	// Middleware wraps Transport; the first entry sees each request first
+	Middleware []Middleware
+	// Pool spreads requests over several servers; BaseURL defaults to its
+	// first endpoint
+	Pool *Pool
+	// Record saves every request and response as cassettes in this directory
+	Record string
+	// Replay answers requests from the cassettes in this directory instead
+	// of contacting a server
+	Replay string
 }
 
 func New(opts Options) *Client {
@@ -37,17 +76,74 @@ func New(opts Options) *Client {
 		opts.RetryDelay = 5 * time.Second
 	}
 
+	policy := DefaultRetryPolicy()
+	policy.MaxRetries = opts.Retries
+	policy.BaseDelay = opts.RetryDelay
+	if opts.RetryPolicy != nil {
+		policy = *opts.RetryPolicy
+	}
+
+	transport := opts.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+		if opts.ConnectTimeout > 0 {
+			transport = newTransport(opts.ConnectTimeout)
+		}
+	}
+	// Pool health checks go straight to the servers, past the middleware,
+	// so they are neither traced nor recorded; replays have nothing to check
+	probe := transport
+	if opts.Replay != "" {
+		transport = ReplayTransport(opts.Replay)
+		probe = nil
+	}
+	middleware := opts.Middleware
+	if opts.Record != "" {
+		// Innermost, so cassettes hold exactly what went over the wire
+		middleware = append(append([]Middleware(nil), middleware...), RecordMiddleware(opts.Record))
+	}
+	transport = chain(transport, middleware)
+	if opts.Pool != nil {
+		// The pool sits outside the middleware so tracing sees the endpoint
+		// each attempt actually went to
+		if opts.BaseURL == "" {
+			opts.BaseURL = opts.Pool.URL()
+		}
+		transport = opts.Pool.middleware(transport, probe, opts.BaseURL)
+	}
+
 	return &Client{
 		baseURL: opts.BaseURL,
 		token:   opts.Token,
-		httpClient: &http.Client{
-			Timeout: opts.Timeout,
-		},
-		retries:    opts.Retries,
-		retryDelay: opts.RetryDelay,
+		// Deadlines are applied per request by send and the stream watchdog,
+		// since http.Client.Timeout would also cut off long streams
+		httpClient:        &http.Client{Transport: transport},
+		timeout:           opts.Timeout,
+		firstTokenTimeout: opts.FirstTokenTimeout,
+		idleTimeout:       opts.IdleTimeout,
+		streamTimeout:     opts.StreamTimeout,
+		retries:           opts.Retries,
+		retryPolicy:       policy,
+		retryPolicies:     opts.RetryPolicies,
+		pool:              opts.Pool,
 	}
 }
 
+// Pool returns the endpoint pool, or nil when the client talks to one server
+func (c *Client) Pool() *Pool {
+	return c.pool
+}
+
+// Endpoint returns the server that answered the latest request
+func (c *Client) Endpoint() string {
+	if c.pool != nil {
+		if last := c.pool.LastEndpoint(); last != "" {
+			return last
+		}
+	}
+	return c.baseURL
+}
+
 type Model struct {
 	Name         string            `json:"name"`
 	ModifiedAt   time.Time         `json:"modified_at"`
@@ -75,23 +171,35 @@ type ListModelsResponse struct {
 	Models []Model `json:"models"`
 }
 
+// FormatJSON asks the server for any valid JSON. A JSON-Schema document can
+// be assigned to the Format fields instead to constrain the output further.
+var FormatJSON = json.RawMessage(`"json"`)
+
 type GenerateRequest struct {
 	Model    string                 `json:"model"`
 	Prompt   string                 `json:"prompt"`
 	Stream   bool                   `json:"stream,omitempty"`
-	Format   string                 `json:"format,omitempty"`
+	Format   json.RawMessage        `json:"format,omitempty"`
 	Options  map[string]interface{} `json:"options,omitempty"`
 	System   string                 `json:"system,omitempty"`
 	Template string                 `json:"template,omitempty"`
 	Context  []int                  `json:"context,omitempty"`
 	Raw      bool                   `json:"raw,omitempty"`
+	Images   []string               `json:"images,omitempty"`
+	// KeepAlive sets how long the model stays loaded after the request
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+	// Think turns a reasoning model's thinking on or off; nil leaves it to
+	// the server
+	Think *bool `json:"think,omitempty"`
 }
 
 type GenerateResponse struct {
 	Model              string    `json:"model"`
 	CreatedAt          time.Time `json:"created_at"`
 	Response           string    `json:"response"`
+	Thinking           string    `json:"thinking,omitempty"`
 	Done               bool      `json:"done"`
+	DoneReason         string    `json:"done_reason,omitempty"`
 	Context            []int     `json:"context,omitempty"`
 	TotalDuration      int64     `json:"total_duration,omitempty"`
 	LoadDuration       int64     `json:"load_duration,omitempty"`
@@ -99,19 +207,80 @@ type GenerateResponse struct {
 	PromptEvalDuration int64     `json:"prompt_eval_duration,omitempty"`
 	EvalCount          int       `json:"eval_count,omitempty"`
 	EvalDuration       int64     `json:"eval_duration,omitempty"`
+	// Err is set on the final value of a stream that failed
+	Err error `json:"-"`
 }
 
 type ChatRequest struct {
 	Model    string                 `json:"model"`
 	Messages []ChatMessage          `json:"messages"`
 	Stream   bool                   `json:"stream,omitempty"`
-	Format   string                 `json:"format,omitempty"`
+	Format   json.RawMessage        `json:"format,omitempty"`
 	Options  map[string]interface{} `json:"options,omitempty"`
+	Tools    []Tool                 `json:"tools,omitempty"`
+	// KeepAlive sets how long the model stays loaded after the request
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+	// Think turns a reasoning model's thinking on or off; nil leaves it to
+	// the server
+	Think *bool `json:"think,omitempty"`
 }
 
+// Message roles understood by the chat endpoint
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+	// ToolCallID links a tool result to the call it answers (OpenAI backends)
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Images holds base64-encoded images for multimodal models
+	Images []string `json:"images,omitempty"`
+	// Thinking is the reasoning a model produced before its answer
+	Thinking string `json:"thinking,omitempty"`
+}
+
+// Tool describes a function the model may ask the client to call
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  ToolParameters `json:"parameters"`
+}
+
+// ToolParameters is the JSON-Schema object describing a tool's arguments
+type ToolParameters struct {
+	Type       string                  `json:"type"`
+	Required   []string                `json:"required,omitempty"`
+	Properties map[string]ToolProperty `json:"properties"`
+}
+
+type ToolProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// ToolCall is a model request to invoke one of the tools from ChatRequest.Tools
+type ToolCall struct {
+	// ID is set by backends that match results to calls by ID
+	ID       string           `json:"id,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
 }
 
 type ChatResponse struct {
@@ -119,12 +288,15 @@ type ChatResponse struct {
 	CreatedAt          time.Time   `json:"created_at"`
 	Message            ChatMessage `json:"message"`
 	Done               bool        `json:"done"`
+	DoneReason         string      `json:"done_reason,omitempty"`
 	TotalDuration      int64       `json:"total_duration,omitempty"`
 	LoadDuration       int64       `json:"load_duration,omitempty"`
 	PromptEvalCount    int         `json:"prompt_eval_count,omitempty"`
 	PromptEvalDuration int64       `json:"prompt_eval_duration,omitempty"`
 	EvalCount          int         `json:"eval_count,omitempty"`
 	EvalDuration       int64       `json:"eval_duration,omitempty"`
+	// Err is set on the final value of a stream that failed
+	Err error `json:"-"`
 }
 
 type PullRequest struct {
@@ -138,6 +310,8 @@ type PullResponse struct {
 	Digest    string `json:"digest,omitempty"`
 	Total     int64  `json:"total,omitempty"`
 	Completed int64  `json:"completed,omitempty"`
+	// Err is set on the final value of a stream that failed
+	Err error `json:"-"`
 }
 
 type ShowRequest struct {
@@ -145,16 +319,19 @@ type ShowRequest struct {
 }
 
 type ShowResponse struct {
-	License    string       `json:"license,omitempty"`
-	Modelfile  string       `json:"modelfile,omitempty"`
-	Parameters string       `json:"parameters,omitempty"`
-	Template   string       `json:"template,omitempty"`
-	Details    ModelDetails `json:"details,omitempty"`
+	License      string       `json:"license,omitempty"`
+	Modelfile    string       `json:"modelfile,omitempty"`
+	Parameters   string       `json:"parameters,omitempty"`
+	Template     string       `json:"template,omitempty"`
+	Details      ModelDetails `json:"details,omitempty"`
+	Capabilities []string     `json:"capabilities,omitempty"`
 }
 
 type EmbedRequest struct {
 	Model string   `json:"model"`
 	Input []string `json:"input"`
+	// KeepAlive sets how long the model stays loaded after the request
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
 }
 
 type EmbedResponse struct {
@@ -168,10 +345,47 @@ type CreateRequest struct {
 	Path      string `json:"path,omitempty"`
 	Stream    bool   `json:"stream,omitempty"`
 	Quantize  string `json:"quantize,omitempty"`
+	// Files maps file names to the digests of uploaded blobs, for models
+	// made of several files such as safetensors
+	Files map[string]string `json:"files,omitempty"`
 }
 
 type CreateResponse struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	// Err is set on the final value of a stream that failed
+	Err error `json:"-"`
+}
+
+type CopyRequest struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type DeleteRequest struct {
+	Name string `json:"name"`
+}
+
+type PushRequest struct {
+	Name     string `json:"name"`
+	Insecure bool   `json:"insecure,omitempty"`
+	Stream   bool   `json:"stream,omitempty"`
+}
+
+// PushResponse reports push progress; it has the same shape as PullResponse
+type PushResponse struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	// Err is set on the final value of a stream that failed
+	Err error `json:"-"`
+}
+
+type VersionResponse struct {
+	Version string `json:"version"`
 }
 
 func (c *Client) ListModels(ctx context.Context) (*ListModelsResponse, error) {
@@ -180,14 +394,35 @@ func (c *Client) ListModels(ctx context.Context) (*ListModelsResponse, error) {
 	return &result, err
 }
 
-func (c *Client) PullModel(ctx context.Context, req PullRequest) (<-chan PullResponse, error) {
+// ListRunningModels returns the models currently loaded in memory (/api/ps)
+func (c *Client) ListRunningModels(ctx context.Context) (*ListModelsResponse, error) {
+	var result ListModelsResponse
+	err := c.doRequest(ctx, "GET", "/api/ps", nil, &result)
+	return &result, err
+}
+
+func (c *Client) CopyModel(ctx context.Context, req CopyRequest) error {
+	return c.doRequest(ctx, "POST", "/api/copy", req, nil)
+}
+
+func (c *Client) DeleteModel(ctx context.Context, req DeleteRequest) error {
+	return c.doRequest(ctx, "DELETE", "/api/delete", req, nil)
+}
+
+func (c *Client) Version(ctx context.Context) (*VersionResponse, error) {
+	var result VersionResponse
+	err := c.doRequest(ctx, "GET", "/api/version", nil, &result)
+	return &result, err
+}
+
+func (c *Client) PushModel(ctx context.Context, req PushRequest) (<-chan PushResponse, error) {
 	req.Stream = true
-	respCh := make(chan PullResponse)
+	respCh := make(chan PushResponse)
 
 	go func() {
 		defer close(respCh)
-		err := c.streamRequest(ctx, "POST", "/api/pull", req, func(data []byte) error {
-			var resp PullResponse
+		err := c.streamRequest(ctx, "POST", "/api/push", req, func(data []byte) error {
+			var resp PushResponse
 			if err := json.Unmarshal(data, &resp); err != nil {
 				return err
 			}
@@ -199,9 +434,28 @@ func (c *Client) PullModel(ctx context.Context, req PullRequest) (<-chan PullRes
 			return nil
 		})
 		if err != nil {
-			// Send error as status
 			select {
-			case respCh <- PullResponse{Status: fmt.Sprintf("error: %v", err)}:
+			case respCh <- PushResponse{Status: "error", Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return respCh, nil
+}
+
+// PullModel downloads a model, streaming progress. A dropped stream is
+// resumed by repeating the request, which the server continues from the
+// bytes it already has, and a finished pull is verified with ListModels.
+func (c *Client) PullModel(ctx context.Context, req PullRequest) (<-chan PullResponse, error) {
+	req.Stream = true
+	respCh := make(chan PullResponse)
+
+	go func() {
+		defer close(respCh)
+		if err := c.pull(ctx, req, respCh); err != nil {
+			select {
+			case respCh <- PullResponse{Status: "error", Err: err}:
 			case <-ctx.Done():
 			}
 		}
@@ -218,7 +472,13 @@ func (c *Client) ShowModel(ctx context.Context, req ShowRequest) (*ShowResponse,
 
 func (c *Client) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
 	var result GenerateResponse
-	err := c.doRequest(ctx, "POST", "/api/generate", req, &result)
+	// Ollama streams unless told otherwise, and the omitempty Stream field
+	// cannot send an explicit false
+	body := struct {
+		GenerateRequest
+		Stream bool `json:"stream"`
+	}{req, false}
+	err := c.doRequest(ctx, "POST", "/api/generate", body, &result)
 	return &result, err
 }
 
@@ -241,9 +501,8 @@ func (c *Client) GenerateStream(ctx context.Context, req GenerateRequest) (<-cha
 			return nil
 		})
 		if err != nil && err != ctx.Err() {
-			// Send error in response
 			select {
-			case respCh <- GenerateResponse{Response: fmt.Sprintf("Error: %v", err), Done: true}:
+			case respCh <- GenerateResponse{Done: true, Err: err}:
 			case <-ctx.Done():
 			}
 		}
@@ -254,7 +513,11 @@ func (c *Client) GenerateStream(ctx context.Context, req GenerateRequest) (<-cha
 
 func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	var result ChatResponse
-	err := c.doRequest(ctx, "POST", "/api/chat", req, &result)
+	body := struct {
+		ChatRequest
+		Stream bool `json:"stream"`
+	}{req, false}
+	err := c.doRequest(ctx, "POST", "/api/chat", body, &result)
 	return &result, err
 }
 
@@ -277,12 +540,8 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatRe
 			return nil
 		})
 		if err != nil && err != ctx.Err() {
-			// Send error in message
 			select {
-			case respCh <- ChatResponse{
-				Message: ChatMessage{Role: "assistant", Content: fmt.Sprintf("Error: %v", err)},
-				Done:    true,
-			}:
+			case respCh <- ChatResponse{Done: true, Err: err}:
 			case <-ctx.Done():
 			}
 		}
@@ -292,59 +551,97 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatRe
 }
 
 func (c *Client) doRequest(ctx context.Context, method, path string, reqBody, respBody interface{}) error {
-	var body io.Reader
+	var body []byte
 	if reqBody != nil {
 		jsonData, err := json.Marshal(reqBody)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
-		body = bytes.NewBuffer(jsonData)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+		body = jsonData
 	}
 
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
+	policy := c.policyFor(path)
+	idempotent := isIdempotent(method, path)
 
 	var resp *http.Response
-	for attempt := 0; attempt <= c.retries; attempt++ {
-		resp, err = c.httpClient.Do(req)
-		if err == nil && resp.StatusCode < 500 {
+	var err error
+	var attemptCtx context.Context
+	var cancel context.CancelFunc
+	attempt := 0
+	for ; ; attempt++ {
+		// The deadline covers reading the body, so it ends after decoding
+		attemptCtx, cancel = withTimeout(ctx, c.timeout, TimeoutOverall, path)
+		resp, err = c.send(attemptCtx, method, path, body)
+		err = timeoutCause(attemptCtx, err)
+		if !policy.shouldRetry(attempt, idempotent, resp, err) {
 			break
 		}
 
-		if attempt < c.retries {
-			time.Sleep(c.retryDelay)
+		wait := policy.delay(attempt, resp)
+		discard(resp)
+		cancel()
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			return sleepErr
 		}
 	}
+	defer cancel()
 
 	if err != nil {
-		return fmt.Errorf("request failed after %d retries: %w", c.retries, err)
+		// Requests that are not safe to repeat are only sent once
+		if attempt == 0 {
+			return fmt.Errorf("request failed: %w", transportError(path, err))
+		}
+		return fmt.Errorf("request failed after %d attempts: %w", attempt+1, transportError(path, err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		respData, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respData))
+		return newAPIError(resp.StatusCode, path, respData)
 	}
 
 	if respBody != nil {
 		if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+			return fmt.Errorf("failed to decode response: %w", timeoutCause(attemptCtx, err))
 		}
 	}
 
 	return nil
 }
 
+// send performs a single attempt, building a fresh request (and body reader)
+// so that it can be repeated safely
+func (c *Client) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// discard drains and closes a response that will not be used
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
+
 func (c *Client) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
 	var result EmbedResponse
 	err := c.doRequest(ctx, "POST", "/api/embed", req, &result)
@@ -371,7 +668,7 @@ func (c *Client) CreateModel(ctx context.Context, req CreateRequest) (<-chan Cre
 		})
 		if err != nil {
 			select {
-			case respCh <- CreateResponse{Status: fmt.Sprintf("error: %v", err)}:
+			case respCh <- CreateResponse{Status: "error", Err: err}:
 			case <-ctx.Done():
 			}
 		}
@@ -386,45 +683,103 @@ func (c *Client) streamRequest(ctx context.Context, method, path string, reqBody
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	policy := c.policyFor(path)
+	idempotent := isIdempotent(method, path)
+
+	for attempt := 0; ; attempt++ {
+		delivered, retry, err := c.streamAttempt(ctx, attempt, policy, idempotent, method, path, jsonData, handler)
+		if retry {
+			continue
+		}
+		if err == nil || delivered || ctx.Err() != nil || errors.Is(err, ErrTimeout) {
+			return err
+		}
+
+		// Error statuses and cassette misses were already turned down by
+		// the policy in streamAttempt
+		var apiErr *APIError
+		if errors.As(err, &apiErr) || errors.Is(err, ErrCassetteMiss) {
+			return err
+		}
+		// Nothing reached the caller yet, so the stream can be restarted
+		if !policy.shouldRetry(attempt, idempotent, nil, err) {
+			return err
+		}
+		if sleepErr := sleepContext(ctx, policy.delay(attempt, nil)); sleepErr != nil {
+			return sleepErr
+		}
 	}
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+// streamAttempt sends a streaming request once and consumes the response
+// under the stream time limits. retry is set when the attempt failed before
+// reaching the caller and the retry delay has already been waited out.
+func (c *Client) streamAttempt(ctx context.Context, attempt int, policy RetryPolicy, idempotent bool, method, path string, body []byte, handler func([]byte) error) (delivered, retry bool, err error) {
+	attemptCtx, cancel := withTimeout(ctx, c.streamTimeout, TimeoutOverall, path)
+	defer cancel()
+	attemptCtx, watchdog := c.watch(attemptCtx, path)
+	defer watchdog.stop()
+
+	resp, err := c.send(attemptCtx, method, path, body)
+	err = timeoutCause(attemptCtx, err)
+	if policy.shouldRetry(attempt, idempotent, resp, err) {
+		wait := policy.delay(attempt, resp)
+		discard(resp)
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			return false, false, sleepErr
+		}
+		return false, true, nil
 	}
 
-	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return false, false, fmt.Errorf("request failed: %w", transportError(path, err))
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		respData, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respData))
+		resp.Body.Close()
+		return false, false, newAPIError(resp.StatusCode, path, respData)
 	}
 
-	decoder := json.NewDecoder(resp.Body)
+	resp.Body = watchdog.body(resp.Body)
+	delivered, err = c.consumeStream(attemptCtx, path, resp, handler)
+	return delivered, false, timeoutCause(attemptCtx, err)
+}
+
+// consumeStream decodes NDJSON objects from resp and passes them to handler.
+// It reports whether any object was delivered (or an in-stream error was
+// received, which must not be retried) before an error occurred.
+func (c *Client) consumeStream(ctx context.Context, path string, resp *http.Response, handler func([]byte) error) (bool, error) {
+	defer resp.Body.Close()
+
+	delivered := false
+	next := jsonFrames(resp.Body)
+	if c.sse {
+		next = sseFrames(resp.Body)
+	}
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return delivered, ctx.Err()
 		default:
 		}
 
-		var raw json.RawMessage
-		if err := decoder.Decode(&raw); err != nil {
+		raw, err := next()
+		if err != nil {
 			if err == io.EOF {
-				return nil
+				return delivered, nil
 			}
-			return fmt.Errorf("failed to decode stream response: %w", err)
+			return delivered, fmt.Errorf("failed to decode stream response: %w", err)
 		}
 
+		// The server reports failures after the headers as {"error": "..."}
+		if err := streamError(resp.StatusCode, path, raw); err != nil {
+			return true, err
+		}
+
+		delivered = true
 		if err := handler(raw); err != nil {
-			return err
+			return delivered, err
 		}
 	}
-}
\ No newline at end of file
+}
@@ -3,6 +3,8 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -304,4 +306,208 @@ func TestClientErrorHandling(t *testing.T) {
 	if !strings.Contains(err.Error(), "500") {
 		t.Errorf("Expected error to contain status code 500, got: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func TestModelHousekeepingEndpoints(t *testing.T) {
+	expires := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var deleted DeleteRequest
+	var copied CopyRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/ps":
+			json.NewEncoder(w).Encode(ListModelsResponse{Models: []Model{
+				{Name: "llama2", Size: 100, SizeVRAM: 100, ExpiresAt: &expires},
+			}})
+		case "GET /api/version":
+			json.NewEncoder(w).Encode(VersionResponse{Version: "0.5.1"})
+		case "POST /api/copy":
+			json.NewDecoder(r.Body).Decode(&copied)
+		case "DELETE /api/delete":
+			json.NewDecoder(r.Body).Decode(&deleted)
+			if deleted.Name == "missing" {
+				http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			}
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL})
+	ctx := context.Background()
+
+	running, err := client.ListRunningModels(ctx)
+	if err != nil {
+		t.Fatalf("ListRunningModels failed: %v", err)
+	}
+	if len(running.Models) != 1 || running.Models[0].SizeVRAM != 100 || !running.Models[0].ExpiresAt.Equal(expires) {
+		t.Errorf("unexpected running models: %+v", running.Models)
+	}
+
+	version, err := client.Version(ctx)
+	if err != nil || version.Version != "0.5.1" {
+		t.Errorf("unexpected version %+v, err: %v", version, err)
+	}
+
+	if err := client.CopyModel(ctx, CopyRequest{Source: "llama2", Destination: "llama2-backup"}); err != nil {
+		t.Fatalf("CopyModel failed: %v", err)
+	}
+	if copied.Source != "llama2" || copied.Destination != "llama2-backup" {
+		t.Errorf("unexpected copy request: %+v", copied)
+	}
+
+	if err := client.DeleteModel(ctx, DeleteRequest{Name: "llama2-backup"}); err != nil {
+		t.Fatalf("DeleteModel failed: %v", err)
+	}
+	if deleted.Name != "llama2-backup" {
+		t.Errorf("unexpected delete request: %+v", deleted)
+	}
+
+	if err := client.DeleteModel(ctx, DeleteRequest{Name: "missing"}); err == nil {
+		t.Error("Expected error deleting a missing model")
+	}
+}
+
+func TestPushModelStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/push" {
+			t.Errorf("Expected path /api/push, got %s", r.URL.Path)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.Encode(PushResponse{Status: "pushing", Digest: "sha256:abc", Total: 10, Completed: 5})
+		encoder.Encode(PushResponse{Status: "success"})
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL})
+	respCh, err := client.PushModel(context.Background(), PushRequest{Name: "me/llama2"})
+	if err != nil {
+		t.Fatalf("PushModel failed: %v", err)
+	}
+
+	var statuses []string
+	for resp := range respCh {
+		statuses = append(statuses, resp.Status)
+	}
+	if strings.Join(statuses, ",") != "pushing,success" {
+		t.Errorf("unexpected push statuses: %v", statuses)
+	}
+}
+
+func TestBlobEndpoints(t *testing.T) {
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "HEAD" && r.URL.Path == "/api/blobs/sha256:present":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "HEAD":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.URL.Path == "/api/blobs/sha256:new":
+			data, _ := io.ReadAll(r.Body)
+			uploaded = string(data)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL})
+	ctx := context.Background()
+
+	if ok, err := client.BlobExists(ctx, "sha256:present"); err != nil || !ok {
+		t.Errorf("Expected blob to exist, got %v, err: %v", ok, err)
+	}
+	if ok, err := client.BlobExists(ctx, "sha256:absent"); err != nil || ok {
+		t.Errorf("Expected blob to be missing, got %v, err: %v", ok, err)
+	}
+
+	if err := client.CreateBlob(ctx, "sha256:new", strings.NewReader("weights")); err != nil {
+		t.Fatalf("CreateBlob failed: %v", err)
+	}
+	if uploaded != "weights" {
+		t.Errorf("Expected uploaded body 'weights', got %q", uploaded)
+	}
+
+	if err := client.CreateBlob(ctx, "sha256:bad", strings.NewReader("x")); err == nil {
+		t.Error("Expected error for rejected blob")
+	}
+}
+
+func TestAPIErrorSentinels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model 'ghost' not found"}`))
+		case "/api/tags":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/api/chat":
+			json.NewEncoder(w).Encode(ChatResponse{Message: ChatMessage{Content: "Hel"}})
+			w.Write([]byte(`{"error":"model runner has unexpectedly stopped"}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL})
+	ctx := context.Background()
+
+	_, err := client.ShowModel(ctx, ShowRequest{Name: "ghost"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "model 'ghost' not found" || apiErr.Endpoint != "/api/show" {
+		t.Errorf("unexpected APIError: %+v", apiErr)
+	}
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Error("Expected errors.Is(err, ErrModelNotFound)")
+	}
+
+	// A 404 that is not about a model, such as from an old server or a
+	// wrong base path, is not a missing model
+	if _, err := client.Version(ctx); err == nil || errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected a plain 404 not to match ErrModelNotFound, got: %v", err)
+	}
+
+	if _, err := client.ListModels(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got: %v", err)
+	}
+
+	respCh, _ := client.ChatStream(ctx, ChatRequest{Model: "llama2"})
+	var content string
+	var streamErr error
+	for resp := range respCh {
+		content += resp.Message.Content
+		if resp.Err != nil {
+			streamErr = resp.Err
+		}
+	}
+	if content != "Hel" {
+		t.Errorf("Expected error not to be mixed into content, got %q", content)
+	}
+	if !errors.As(streamErr, &apiErr) || apiErr.Message != "model runner has unexpectedly stopped" {
+		t.Errorf("Expected in-stream APIError, got: %v", streamErr)
+	}
+}
+
+func TestConnectionErrorIsServerUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	client := New(Options{BaseURL: server.URL, RetryDelay: time.Millisecond})
+	_, err := client.ListModels(context.Background())
+	if !errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("Expected ErrServerUnavailable, got: %v", err)
+	}
+
+	respCh, _ := client.PullModel(context.Background(), PullRequest{Name: "llama2"})
+	for resp := range respCh {
+		if resp.Err == nil || !errors.Is(resp.Err, ErrServerUnavailable) {
+			t.Errorf("Expected pull stream to report ErrServerUnavailable, got: %+v", resp)
+		}
+	}
+}
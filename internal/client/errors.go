@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched with errors.Is against errors returned by Client
+var (
+	ErrModelNotFound     = errors.New("model not found")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrServerUnavailable = errors.New("server unavailable")
+)
+
+// APIError is returned when the server answers with an error, either as an
+// HTTP error status or as an {"error": ...} object inside a stream
+type APIError struct {
+	StatusCode int
+	Message    string
+	Endpoint   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d from %s: %s", e.StatusCode, e.Endpoint, e.Message)
+}
+
+// Is maps the status code (and, for in-stream errors, the message) to the
+// package sentinels. A 404 is only a missing model when the message is about
+// one; a wrong base path or a proxy answers 404 too.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrModelNotFound:
+		message := strings.ToLower(e.Message)
+		return strings.Contains(message, "model") &&
+			(e.StatusCode == http.StatusNotFound || strings.Contains(message, "not found"))
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrServerUnavailable:
+		return e.StatusCode == http.StatusBadGateway ||
+			e.StatusCode == http.StatusServiceUnavailable ||
+			e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// newAPIError builds an APIError from an error response body, unwrapping the
+// {"error": "..."} object Ollama sends when possible
+func newAPIError(statusCode int, endpoint string, body []byte) *APIError {
+	message := errorMessage(body)
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+
+	return &APIError{StatusCode: statusCode, Message: message, Endpoint: endpoint}
+}
+
+// streamError extracts an in-stream error object, if data is one
+func streamError(statusCode int, endpoint string, data []byte) error {
+	message := errorMessage(data)
+	if message == "" {
+		return nil
+	}
+	return &APIError{StatusCode: statusCode, Message: message, Endpoint: endpoint}
+}
+
+// errorMessage extracts the message from an Ollama {"error": "..."} or an
+// OpenAI {"error": {"message": "..."}} object; it is empty for anything else
+func errorMessage(data []byte) string {
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil || len(payload.Error) == 0 {
+		return ""
+	}
+
+	var message string
+	if err := json.Unmarshal(payload.Error, &message); err == nil {
+		return message
+	}
+
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(payload.Error, &detail); err == nil {
+		return detail.Message
+	}
+	return ""
+}
+
+// ConnectionError is returned when the server could not be reached at all
+type ConnectionError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("cannot reach server for %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConnectionError) Is(target error) bool {
+	return target == ErrServerUnavailable
+}
+
+// transportError wraps a failed HTTP round trip, leaving context errors,
+// timeouts and replay misses alone so callers can tell them apart from an
+// unreachable server
+func transportError(endpoint string, err error) error {
+	if connectTimeout(err) {
+		return &TimeoutError{Kind: TimeoutConnect, Endpoint: endpoint}
+	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCassetteMiss) {
+		return err
+	}
+	return &ConnectionError{Endpoint: endpoint, Err: err}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// CapabilityVision is the capability reported by models that accept images
+const CapabilityVision = "vision"
+
+// maxImageSize guards against accidentally attaching huge files
+const maxImageSize = 20 * 1024 * 1024
+
+// EncodeImage reads an image file and returns it base64-encoded for use in
+// ChatMessage.Images or GenerateRequest.Images
+func EncodeImage(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("image path %s is a directory", path)
+	}
+	if info.Size() > maxImageSize {
+		return "", fmt.Errorf("image %s is too large (%d bytes, max %d)", path, info.Size(), maxImageSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image %s: %w", path, err)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("%s does not look like an image (detected %s)", path, contentType)
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// EncodeImages encodes every path with EncodeImage
+func EncodeImages(paths []string) ([]string, error) {
+	images := make([]string, 0, len(paths))
+	for _, path := range paths {
+		image, err := EncodeImage(path)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// HasCapability reports whether capability is in the list of model capabilities
+func HasCapability(capabilities []string, capability string) bool {
+	for _, c := range capabilities {
+		if strings.EqualFold(c, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckVision returns an error when the server reports that model cannot take
+// images. Servers that do not report capabilities are given the benefit of the doubt.
+func CheckVision(ctx context.Context, p Provider, model string) error {
+	info, err := p.ShowModel(ctx, ShowRequest{Name: model})
+	if err != nil {
+		return fmt.Errorf("failed to check capabilities of %s: %w", model, err)
+	}
+
+	if len(info.Capabilities) == 0 || HasCapability(info.Capabilities, CapabilityVision) {
+		return nil
+	}
+
+	return fmt.Errorf("model %s does not support images (capabilities: %s); try a vision model such as llava",
+		model, strings.Join(info.Capabilities, ", "))
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough of a PNG file for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestEncodeImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	imagePath := filepath.Join(tmpDir, "cat.png")
+	if err := os.WriteFile(imagePath, pngHeader, 0o644); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+
+	encoded, err := EncodeImage(imagePath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if encoded != base64.StdEncoding.EncodeToString(pngHeader) {
+		t.Errorf("unexpected encoding: %s", encoded)
+	}
+
+	textPath := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(textPath, []byte("just text"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := EncodeImage(textPath); err == nil {
+		t.Error("Expected error for non-image file")
+	}
+
+	if _, err := EncodeImages([]string{imagePath, filepath.Join(tmpDir, "missing.png")}); err == nil {
+		t.Error("Expected error for missing image")
+	}
+}
+
+func TestCheckVision(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ShowRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := ShowResponse{Capabilities: []string{"completion"}}
+		switch req.Name {
+		case "llava":
+			resp.Capabilities = []string{"completion", "vision"}
+		case "legacy":
+			resp.Capabilities = nil
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL})
+	ctx := context.Background()
+
+	if err := CheckVision(ctx, client, "llava"); err != nil {
+		t.Errorf("Expected llava to support images, got: %v", err)
+	}
+	if err := CheckVision(ctx, client, "legacy"); err != nil {
+		t.Errorf("Expected servers without capabilities to be allowed, got: %v", err)
+	}
+
+	err := CheckVision(ctx, client, "llama3")
+	if err == nil || !strings.Contains(err.Error(), "does not support images") {
+		t.Errorf("Expected vision error, got: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// importProgressInterval limits how often hashing and upload progress is sent
+const importProgressInterval = 100 * time.Millisecond
+
+// ImportRequest creates a model from weights on the local machine, which
+// works even when the server is remote
+type ImportRequest struct {
+	Name string
+	// From is a GGUF file or a directory of safetensors weights
+	From string
+	// Modelfile adds parameters, a template, a system prompt and so on. Its
+	// FROM line is replaced to point at the uploaded weights; when empty a
+	// Modelfile with only that line is used.
+	Modelfile string
+	Quantize  string
+}
+
+// localBlob is a file to upload and its digest
+type localBlob struct {
+	name   string
+	path   string
+	size   int64
+	digest string
+}
+
+// ImportModel hashes the weights, uploads the blobs the server does not have
+// yet and creates the model from them. Progress for hashing, uploading and
+// creating is streamed as CreateResponse values.
+func (c *Client) ImportModel(ctx context.Context, req ImportRequest) (<-chan CreateResponse, error) {
+	blobs, err := localBlobs(req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	respCh := make(chan CreateResponse)
+	go func() {
+		defer close(respCh)
+		if err := c.importModel(ctx, req, blobs, respCh); err != nil {
+			select {
+			case respCh <- CreateResponse{Status: "error", Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return respCh, nil
+}
+
+func (c *Client) importModel(ctx context.Context, req ImportRequest, blobs []localBlob, out chan<- CreateResponse) error {
+	// With an endpoint pool, every blob and the create go to one server
+	ctx = withPinnedEndpoint(ctx)
+	send := func(resp CreateResponse) error {
+		select {
+		case out <- resp:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for i := range blobs {
+		blob := &blobs[i]
+		digest, err := hashFile(ctx, blob.path, func(done int64) error {
+			return send(CreateResponse{Status: "hashing " + blob.name, Total: blob.size, Completed: done})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", blob.path, err)
+		}
+		blob.digest = digest
+
+		exists, err := c.BlobExists(ctx, digest)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if err := send(CreateResponse{Status: "using existing blob " + blob.name, Digest: digest}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := c.uploadBlob(ctx, *blob, send); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", blob.path, err)
+		}
+	}
+
+	create := CreateRequest{Name: req.Name, Quantize: req.Quantize}
+	if len(blobs) == 1 && strings.EqualFold(filepath.Ext(blobs[0].name), ".gguf") {
+		create.Modelfile = rewriteFrom(req.Modelfile, "@"+blobs[0].digest)
+	} else {
+		create.Files = make(map[string]string, len(blobs))
+		for _, blob := range blobs {
+			create.Files[blob.name] = blob.digest
+		}
+		create.Modelfile = rewriteFrom(req.Modelfile, "")
+	}
+
+	progress, err := c.CreateModel(ctx, create)
+	if err != nil {
+		return err
+	}
+	for resp := range progress {
+		if resp.Err != nil {
+			return resp.Err
+		}
+		if err := send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadBlob sends a file to the server, reporting how much has been read
+func (c *Client) uploadBlob(ctx context.Context, blob localBlob, send func(CreateResponse) error) error {
+	f, err := os.Open(blob.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	status := "uploading " + blob.name
+	if err := send(CreateResponse{Status: status, Digest: blob.digest, Total: blob.size}); err != nil {
+		return err
+	}
+
+	body := &progressReader{r: f, report: func(done int64) error {
+		return send(CreateResponse{Status: status, Digest: blob.digest, Total: blob.size, Completed: done})
+	}}
+	if err := c.CreateBlob(ctx, blob.digest, body); err != nil {
+		return err
+	}
+	return send(CreateResponse{Status: status, Digest: blob.digest, Total: blob.size, Completed: blob.size})
+}
+
+// localBlobs lists the files to upload for a GGUF file or a weights directory
+func localBlobs(from string) ([]localBlob, error) {
+	info, err := os.Stat(from)
+	if err != nil {
+		return nil, fmt.Errorf("cannot import model: %w", err)
+	}
+
+	if !info.IsDir() {
+		return []localBlob{{name: filepath.Base(from), path: from, size: info.Size()}}, nil
+	}
+
+	entries, err := os.ReadDir(from)
+	if err != nil {
+		return nil, fmt.Errorf("cannot import model: %w", err)
+	}
+	var blobs []localBlob
+	hasWeights := false
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("cannot import model: %w", err)
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		hasWeights = hasWeights || ext == ".safetensors" || ext == ".gguf"
+		blobs = append(blobs, localBlob{name: entry.Name(), path: filepath.Join(from, entry.Name()), size: info.Size()})
+	}
+	if !hasWeights {
+		return nil, fmt.Errorf("cannot import model: no .safetensors or .gguf files in %s", from)
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].name < blobs[j].name })
+	return blobs, nil
+}
+
+// hashFile returns the sha256 digest of a file in the form the blob API uses
+func hashFile(ctx context.Context, path string, report func(done int64) error) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	r := &progressReader{r: f, report: report}
+	buf := make([]byte, 1<<20)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := r.Read(buf)
+		h.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// progressReader reports the bytes read so far, at most every
+// importProgressInterval
+type progressReader struct {
+	r      io.Reader
+	done   int64
+	last   time.Time
+	report func(done int64) error
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if now := time.Now(); n > 0 && now.Sub(p.last) >= importProgressInterval {
+		p.last = now
+		if reportErr := p.report(p.done); reportErr != nil {
+			return n, reportErr
+		}
+	}
+	return n, err
+}
+
+// rewriteFrom replaces the FROM line of a Modelfile with one naming source,
+// adding it when missing. An empty source drops the FROM line, for models
+// whose weights are passed as files instead.
+func rewriteFrom(modelfile, source string) string {
+	var lines []string
+	replaced, quoted := false, false
+	// Not a bufio.Scanner: TEMPLATE, SYSTEM and LICENSE lines can be longer
+	// than its token limit
+	for line := range strings.Lines(modelfile) {
+		line = strings.TrimRight(line, "\r\n")
+		fields := strings.Fields(line)
+		inside := quoted
+		// Lines inside a """ block belong to the previous directive
+		if strings.Count(line, `"""`)%2 == 1 {
+			quoted = !quoted
+		}
+		if !inside && len(fields) > 0 && strings.EqualFold(fields[0], "FROM") {
+			if !replaced && source != "" {
+				lines = append(lines, "FROM "+source)
+			}
+			replaced = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !replaced && source != "" {
+		lines = append([]string{"FROM " + source}, lines...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/pkg/ollamatest"
+)
+
+func collectImport(t *testing.T, c *Client, req ImportRequest) []CreateResponse {
+	t.Helper()
+	progress, err := c.ImportModel(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ImportModel failed: %v", err)
+	}
+	var updates []CreateResponse
+	for resp := range progress {
+		if resp.Err != nil {
+			t.Fatalf("import failed: %v", resp.Err)
+		}
+		updates = append(updates, resp)
+	}
+	return updates
+}
+
+func TestImportGGUF(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{})
+	c := New(Options{BaseURL: srv.URL, RetryDelay: time.Millisecond})
+
+	weights := []byte("GGUF local weights")
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	os.WriteFile(path, weights, 0644)
+	sum := sha256.Sum256(weights)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	updates := collectImport(t, c, ImportRequest{
+		Name:      "local",
+		From:      path,
+		Modelfile: "FROM ./model.gguf\nPARAMETER temperature 0.5\nSYSTEM \"\"\"\nFROM here on, be brief\n\"\"\"\n",
+		Quantize:  "q4_K_M",
+	})
+
+	uploaded := false
+	for _, update := range updates {
+		uploaded = uploaded || (update.Status == "uploading model.gguf" && update.Completed == int64(len(weights)))
+	}
+	if !uploaded {
+		t.Errorf("expected upload progress to reach the file size, got %+v", updates)
+	}
+	if data, ok := srv.Blob(digest); !ok || string(data) != string(weights) {
+		t.Fatalf("blob %s was not uploaded", digest)
+	}
+
+	req, _ := srv.LastRequest("/api/create")
+	var create CreateRequest
+	req.Decode(&create)
+	want := "FROM @" + digest + "\nPARAMETER temperature 0.5\nSYSTEM \"\"\"\nFROM here on, be brief\n\"\"\"\n"
+	if create.Modelfile != want || create.Quantize != "q4_K_M" {
+		t.Errorf("unexpected create request: %+v", create)
+	}
+	if !srv.HasModel("local") {
+		t.Error("model was not created")
+	}
+
+	// A second import reuses the blob the server already has
+	updates = collectImport(t, c, ImportRequest{Name: "again", From: path})
+	srv.AssertRequestCount(t, "/api/blobs/"+digest, 3)
+	if !strings.HasPrefix(updates[len(updates)-1].Status, "success") {
+		t.Errorf("expected the import to end with success, got %+v", updates[len(updates)-1])
+	}
+	for _, update := range updates {
+		if strings.HasPrefix(update.Status, "uploading") {
+			t.Errorf("existing blob was uploaded again")
+		}
+	}
+}
+
+func TestImportKeepsToOneEndpoint(t *testing.T) {
+	a, b := ollamatest.New(t, ollamatest.Options{}), ollamatest.New(t, ollamatest.Options{})
+	pool, err := NewPool(PoolOptions{Endpoints: []string{a.URL, b.URL}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	c := New(Options{Pool: pool, RetryDelay: time.Millisecond})
+
+	// Round-robin would otherwise send the upload and the create to
+	// different servers
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	os.WriteFile(path, []byte("GGUF local weights"), 0644)
+	collectImport(t, c, ImportRequest{Name: "local", From: path})
+
+	if !a.HasModel("local") && !b.HasModel("local") {
+		t.Error("model was not created")
+	}
+}
+
+func TestImportSafetensorsDirectory(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{})
+	c := New(Options{BaseURL: srv.URL, RetryDelay: time.Millisecond})
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("tensors"), 0644)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(dir, ".DS_Store"), []byte("junk"), 0644)
+
+	collectImport(t, c, ImportRequest{Name: "st", From: dir})
+
+	req, _ := srv.LastRequest("/api/create")
+	var create CreateRequest
+	req.Decode(&create)
+	if len(create.Files) != 2 || create.Files["model.safetensors"] == "" || create.Files["config.json"] == "" {
+		t.Errorf("expected both files to be referenced, got %v", create.Files)
+	}
+	if create.Modelfile != "" {
+		t.Errorf("expected no Modelfile, got %q", create.Modelfile)
+	}
+
+	if _, err := c.ImportModel(context.Background(), ImportRequest{Name: "x", From: t.TempDir()}); err == nil {
+		t.Error("expected an error for a directory without weights")
+	}
+}
+
+func TestRewriteFromKeepsLongLines(t *testing.T) {
+	license := "LICENSE " + strings.Repeat("x", 100*1024)
+	got := rewriteFrom("FROM ./model.gguf\r\n"+license+"\nPARAMETER temperature 0.5", "@sha256:abc")
+	want := "FROM @sha256:abc\n" + license + "\nPARAMETER temperature 0.5\n"
+	if got != want {
+		t.Errorf("rewriteFrom dropped or changed lines: got %d bytes, want %d", len(got), len(want))
+	}
+	if got := rewriteFrom("", ""); got != "" {
+		t.Errorf("expected an empty Modelfile to stay empty, got %q", got)
+	}
+}
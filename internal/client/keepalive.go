@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeepAlive is how long the server keeps a model in memory after a request:
+// a duration such as "10m" or "1h", a number of seconds, "0" to unload the
+// model right away or a negative value to keep it loaded until the server
+// stops. Empty leaves it to the server, which defaults to 5 minutes.
+type KeepAlive string
+
+const (
+	KeepAliveUnload  KeepAlive = "0"
+	KeepAliveForever KeepAlive = "-1"
+)
+
+// ParseKeepAlive validates a keep-alive value from a flag or config file
+func ParseKeepAlive(s string) (KeepAlive, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		// NaN and infinities parse as numbers but cannot be sent as JSON
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", fmt.Errorf("invalid keep-alive %q: use a duration like 10m, 0 to unload or -1 to keep loaded", s)
+		}
+		return KeepAlive(s), nil
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		return "", fmt.Errorf("invalid keep-alive %q: use a duration like 10m, 0 to unload or -1 to keep loaded", s)
+	}
+	return KeepAlive(s), nil
+}
+
+// MarshalJSON sends plain numbers as seconds, which Ollama accepts along
+// with negative values, and anything else as a duration string
+func (k KeepAlive) MarshalJSON() ([]byte, error) {
+	if n, err := strconv.ParseFloat(string(k), 64); err == nil {
+		return json.Marshal(n)
+	}
+	return json.Marshal(string(k))
+}
+
+// LoadModel loads a model into memory without generating anything, by
+// sending an empty generate request. keepAlive sets how long it stays loaded.
+// The request is streamed, so loading a large model is limited by the
+// first-token timeout rather than the overall request timeout.
+func LoadModel(ctx context.Context, p Provider, model string, keepAlive KeepAlive) error {
+	if _, ok := p.(*OpenAIClient); ok {
+		return fmt.Errorf("cannot load %s: %w", model, ErrUnsupported)
+	}
+	stream, err := p.GenerateStream(ctx, GenerateRequest{Model: model, KeepAlive: keepAlive})
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", model, err)
+	}
+	for resp := range stream {
+		if resp.Err != nil {
+			return fmt.Errorf("failed to load %s: %w", model, resp.Err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to load %s: %w", model, err)
+	}
+	return nil
+}
+
+// UnloadModel frees the memory a loaded model uses
+func UnloadModel(ctx context.Context, p Provider, model string) error {
+	if _, ok := p.(*OpenAIClient); ok {
+		return fmt.Errorf("cannot unload %s: %w", model, ErrUnsupported)
+	}
+	if _, err := p.Generate(ctx, GenerateRequest{Model: model, KeepAlive: KeepAliveUnload}); err != nil {
+		return fmt.Errorf("failed to unload %s: %w", model, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestKeepAliveJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"10m", `"10m"`, false},
+		{"1h30m", `"1h30m"`, false},
+		{"0", `0`, false},
+		{"-1", `-1`, false},
+		{"300", `300`, false},
+		{"soon", "", true},
+		{"NaN", "", true},
+		{"+Inf", "", true},
+		{"-inf", "", true},
+	}
+
+	for _, tt := range tests {
+		keepAlive, err := ParseKeepAlive(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseKeepAlive(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+
+		data, _ := json.Marshal(GenerateRequest{Model: "llama2", KeepAlive: keepAlive})
+		var body map[string]json.RawMessage
+		json.Unmarshal(data, &body)
+		if got := string(body["keep_alive"]); got != tt.want {
+			t.Errorf("keep_alive for %q = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLoadAndUnloadModel(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}}})
+	c := New(Options{BaseURL: srv.URL, Retries: 1, RetryDelay: time.Millisecond})
+	ctx := context.Background()
+
+	if err := LoadModel(ctx, c, "llama2", "1h"); err != nil {
+		t.Fatalf("LoadModel failed: %v", err)
+	}
+	running, err := c.ListRunningModels(ctx)
+	if err != nil {
+		t.Fatalf("ListRunningModels failed: %v", err)
+	}
+	if len(running.Models) != 1 || !running.Models[0].ExpiresAt.Equal(ollamatest.Epoch.Add(time.Hour)) {
+		t.Errorf("expected llama2 to be loaded for an hour, got %+v", running.Models)
+	}
+
+	req, _ := srv.LastRequest("/api/generate")
+	var body ollamatest.GenerateRequest
+	req.Decode(&body)
+	if body.Prompt != "" || string(body.KeepAlive) != `"1h"` {
+		t.Errorf("expected an empty generate request with keep_alive, got %s", req.Body)
+	}
+
+	if err := UnloadModel(ctx, c, "llama2"); err != nil {
+		t.Fatalf("UnloadModel failed: %v", err)
+	}
+	if srv.IsLoaded("llama2") {
+		t.Error("expected llama2 to be unloaded")
+	}
+
+	if err := UnloadModel(ctx, c, "missing"); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+	// Loading may take longer than the overall request timeout
+	slow := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}}, Latency: 100 * time.Millisecond})
+	c = New(Options{BaseURL: slow.URL, Timeout: 20 * time.Millisecond, Retries: 1, RetryDelay: time.Millisecond})
+	if err := LoadModel(ctx, c, "llama2", ""); err != nil {
+		t.Errorf("LoadModel should not be cut off by the request timeout: %v", err)
+	}
+
+	if err := LoadModel(ctx, NewOpenAI(Options{BaseURL: srv.URL}), "llama2", ""); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported for OpenAI backends, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ollamacli/internal/log"
+)
+
+// Middleware wraps the transport used for every request. Middleware listed
+// first in Options.Middleware sees the request first.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// chain wraps base with the middleware, outermost first
+func chain(base http.RoundTripper, middleware []Middleware) http.RoundTripper {
+	transport := base
+	for i := len(middleware) - 1; i >= 0; i-- {
+		transport = middleware[i](transport)
+	}
+	return transport
+}
+
+// TraceMiddleware logs a one-line summary of every request and response at
+// debug level, which is what --verbose shows. Credentials are redacted.
+func TraceMiddleware(logger log.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			logger.Debug("HTTP --> %s %s %s", req.Method, req.URL.Redacted(), formatHeaders(req.Header))
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				logger.Debug("HTTP <-- %s %s failed after %v: %v", req.Method, req.URL.Path, elapsed, err)
+				return resp, err
+			}
+
+			logger.Debug("HTTP <-- %s %s %d %s in %v (content-type: %s, length: %d)",
+				req.Method, req.URL.Path, resp.StatusCode, http.StatusText(resp.StatusCode),
+				elapsed, resp.Header.Get("Content-Type"), resp.ContentLength)
+			return resp, nil
+		})
+	}
+}
+
+// sensitiveHeaders are never written to logs in full
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// formatHeaders renders headers in a stable order with credentials masked
+func formatHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header[name], ",")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = redact(value)
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
+
+// redact keeps the auth scheme (e.g. "Bearer") but masks the credentials
+func redact(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " ****"
+	}
+	return "****"
+}
+
+// HeaderMiddleware adds fixed headers to every request without overriding
+// headers the request already has
+func HeaderMiddleware(headers map[string]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for name, value := range headers {
+				if req.Header.Get(name) == "" {
+					req.Header.Set(name, value)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// RequestIDHeader is the header RequestIDMiddleware sets by default
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware tags each request with a random ID in the given header
+// (RequestIDHeader when empty) so it can be matched with server logs
+func RequestIDMiddleware(header string) Middleware {
+	if header == "" {
+		header = RequestIDHeader
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(header, newRequestID())
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// EndpointMetrics summarizes the requests made to one endpoint. For streaming
+// endpoints the latency is the time until the response headers arrived.
+type EndpointMetrics struct {
+	Requests     int           `json:"requests"`
+	Errors       int           `json:"errors"`
+	TotalLatency time.Duration `json:"total_latency"`
+	MinLatency   time.Duration `json:"min_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+}
+
+// AverageLatency returns the mean latency over all requests
+func (m EndpointMetrics) AverageLatency() time.Duration {
+	if m.Requests == 0 {
+		return 0
+	}
+	return m.TotalLatency / time.Duration(m.Requests)
+}
+
+// LatencyMetrics collects per-endpoint request metrics; it is safe for
+// concurrent use
+type LatencyMetrics struct {
+	mu        sync.Mutex
+	endpoints map[string]*EndpointMetrics
+}
+
+// NewLatencyMetrics creates an empty metrics collector
+func NewLatencyMetrics() *LatencyMetrics {
+	return &LatencyMetrics{endpoints: make(map[string]*EndpointMetrics)}
+}
+
+func (m *LatencyMetrics) record(endpoint string, latency time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.endpoints[endpoint]
+	if !ok {
+		stats = &EndpointMetrics{MinLatency: latency}
+		m.endpoints[endpoint] = stats
+	}
+
+	stats.Requests++
+	stats.TotalLatency += latency
+	if latency < stats.MinLatency {
+		stats.MinLatency = latency
+	}
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
+	}
+	if failed {
+		stats.Errors++
+	}
+}
+
+// Snapshot returns a copy of the metrics keyed by "METHOD /path"
+func (m *LatencyMetrics) Snapshot() map[string]EndpointMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]EndpointMetrics, len(m.endpoints))
+	for endpoint, stats := range m.endpoints {
+		snapshot[endpoint] = *stats
+	}
+	return snapshot
+}
+
+// MetricsMiddleware records the latency and outcome of every request in metrics
+func MetricsMiddleware(metrics *LatencyMetrics) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			failed := err != nil || resp.StatusCode >= 400
+			metrics.record(req.Method+" "+req.URL.Path, time.Since(start), failed)
+			return resp, err
+		})
+	}
+}
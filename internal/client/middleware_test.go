@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Debug(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+func (l *recordingLogger) Info(format string, args ...interface{})  {}
+func (l *recordingLogger) Warn(format string, args ...interface{})  {}
+func (l *recordingLogger) Error(format string, args ...interface{}) {}
+func (l *recordingLogger) SetLevel(levelStr string, verbose bool)   {}
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":"0.5.0"}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	c := New(Options{BaseURL: server.URL, Middleware: []Middleware{tag("first"), tag("second")}})
+	if _, err := c.Version(context.Background()); err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("middleware order = %v, want [first second]", order)
+	}
+}
+
+func TestTraceMiddlewareRedactsAuthorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	c := New(Options{BaseURL: server.URL, Token: "secret-token", Middleware: []Middleware{TraceMiddleware(logger)}})
+	if _, err := c.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	output := strings.Join(logger.lines, "\n")
+	if strings.Contains(output, "secret-token") {
+		t.Errorf("trace output leaked the token: %s", output)
+	}
+	if !strings.Contains(output, "Authorization: Bearer ****") {
+		t.Errorf("trace output missing redacted Authorization header: %s", output)
+	}
+	if !strings.Contains(output, "GET") || !strings.Contains(output, "/api/tags") || !strings.Contains(output, "200") {
+		t.Errorf("trace output missing request summary: %s", output)
+	}
+}
+
+func TestHeaderAndRequestIDMiddleware(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New(Options{
+		BaseURL: server.URL,
+		Middleware: []Middleware{
+			HeaderMiddleware(map[string]string{"X-Team": "research", "Content-Type": "text/plain"}),
+			RequestIDMiddleware(""),
+		},
+	})
+	if _, err := c.ShowModel(context.Background(), ShowRequest{Name: "llama2"}); err != nil {
+		t.Fatalf("ShowModel() error = %v", err)
+	}
+
+	if got.Get("X-Team") != "research" {
+		t.Errorf("X-Team = %q, want research", got.Get("X-Team"))
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, custom headers must not override existing ones", got.Get("Content-Type"))
+	}
+	if len(got.Get(RequestIDHeader)) != 16 {
+		t.Errorf("%s = %q, want a 16 character ID", RequestIDHeader, got.Get(RequestIDHeader))
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/show" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model not found"}`))
+			return
+		}
+		w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	metrics := NewLatencyMetrics()
+	c := New(Options{BaseURL: server.URL, Middleware: []Middleware{MetricsMiddleware(metrics)}})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListModels(context.Background()); err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+	}
+	c.ShowModel(context.Background(), ShowRequest{Name: "missing"})
+
+	snapshot := metrics.Snapshot()
+	tags := snapshot["GET /api/tags"]
+	if tags.Requests != 2 || tags.Errors != 0 {
+		t.Errorf("GET /api/tags = %+v, want 2 requests and no errors", tags)
+	}
+	if tags.MaxLatency < tags.MinLatency || tags.AverageLatency() > tags.MaxLatency {
+		t.Errorf("inconsistent latencies: %+v", tags)
+	}
+	show := snapshot["POST /api/show"]
+	if show.Requests != 1 || show.Errors != 1 {
+		t.Errorf("POST /api/show = %+v, want 1 failed request", show)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"strings"
+)
+
+// NormalizeModelName adds the implicit ":latest" tag so that "llama3" and
+// "llama3:latest" compare equal
+func NormalizeModelName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return name
+	}
+	// A colon after the last slash is a tag; one before it belongs to a registry host
+	if strings.LastIndex(name, ":") <= strings.LastIndex(name, "/") {
+		return name + ":latest"
+	}
+	return name
+}
+
+// HasModel reports whether the named model is installed on the server
+func HasModel(ctx context.Context, p Provider, name string) (bool, error) {
+	model, err := FindModel(ctx, p, name)
+	return model != nil, err
+}
+
+// FindModel returns the installed model with the given name, or nil when it
+// is not installed
+func FindModel(ctx context.Context, p Provider, name string) (*Model, error) {
+	resp, err := p.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	want := NormalizeModelName(name)
+	for i, model := range resp.Models {
+		if NormalizeModelName(model.Name) == want {
+			return &resp.Models[i], nil
+		}
+	}
+	return nil, nil
+}
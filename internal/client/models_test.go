@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeModelName(t *testing.T) {
+	tests := map[string]string{
+		"llama3":                       "llama3:latest",
+		"llama3:8b":                    "llama3:8b",
+		"library/llama3":               "library/llama3:latest",
+		"registry.local:5000/me/model": "registry.local:5000/me/model:latest",
+		"registry.local:5000/me/m:v1":  "registry.local:5000/me/m:v1",
+		"":                             "",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeModelName(input); got != want {
+			t.Errorf("NormalizeModelName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestHasModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ListModelsResponse{Models: []Model{
+			{Name: "llama3:latest"},
+			{Name: "phi3:mini"},
+		}})
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL})
+	ctx := context.Background()
+
+	for name, want := range map[string]bool{"llama3": true, "phi3:mini": true, "phi3": false, "mistral": false} {
+		got, err := HasModel(ctx, client, name)
+		if err != nil {
+			t.Fatalf("HasModel(%q) failed: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("HasModel(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
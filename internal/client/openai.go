@@ -0,0 +1,537 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAIClient implements Provider for servers that speak the OpenAI /v1 API
+// (/v1/chat/completions, /v1/completions, /v1/embeddings and /v1/models).
+// Streams are read as server-sent events and converted to the same
+// ChatResponse and GenerateResponse values the Ollama client produces.
+type OpenAIClient struct {
+	client *Client
+}
+
+// NewOpenAI creates an OpenAI-compatible provider. Options.Token is sent as
+// the API key; a trailing /v1 on BaseURL is optional.
+func NewOpenAI(opts Options) *OpenAIClient {
+	opts.BaseURL = strings.TrimSuffix(strings.TrimRight(opts.BaseURL, "/"), "/v1")
+	c := New(opts)
+	c.sse = true
+	return &OpenAIClient{client: c}
+}
+
+// Endpoint returns the server that answered the latest request
+func (o *OpenAIClient) Endpoint() string {
+	return o.client.Endpoint()
+}
+
+type openAIModel struct {
+	ID      string `json:"id"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openAIContentPart is one element of a multimodal message
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIToolCall struct {
+	// Index identifies the call that a streamed fragment belongs to
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIChoice struct {
+	Index        int                `json:"index"`
+	Message      openAIReplyMessage `json:"message"`
+	Delta        openAIReplyMessage `json:"delta"`
+	Text         string             `json:"text"`
+	FinishReason string             `json:"finish_reason"`
+}
+
+type openAIReplyMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []openAIToolCall `json:"tool_calls"`
+	// ReasoningContent is the thinking of reasoning models (vLLM, DeepSeek)
+	ReasoningContent string `json:"reasoning_content"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// openAIResponse covers chat completions, text completions and their chunks
+type openAIResponse struct {
+	Model   string         `json:"model"`
+	Created int64          `json:"created"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *openAIUsage   `json:"usage"`
+}
+
+func (r *openAIResponse) createdAt() time.Time {
+	if r.Created == 0 {
+		return time.Now()
+	}
+	return time.Unix(r.Created, 0)
+}
+
+// ListModels lists the models served at /v1/models
+func (o *OpenAIClient) ListModels(ctx context.Context) (*ListModelsResponse, error) {
+	var result struct {
+		Data []openAIModel `json:"data"`
+	}
+	if err := o.client.doRequest(ctx, "GET", "/v1/models", nil, &result); err != nil {
+		return nil, err
+	}
+
+	models := make([]Model, len(result.Data))
+	for i, m := range result.Data {
+		models[i] = Model{Name: m.ID}
+		if m.Created > 0 {
+			models[i].ModifiedAt = time.Unix(m.Created, 0)
+		}
+	}
+	return &ListModelsResponse{Models: models}, nil
+}
+
+// ShowModel confirms the model is served; /v1/models carries no details.
+// The list is used because not every server implements /v1/models/{id}.
+func (o *OpenAIClient) ShowModel(ctx context.Context, req ShowRequest) (*ShowResponse, error) {
+	models, err := o.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, model := range models.Models {
+		if model.Name == req.Name {
+			return &ShowResponse{}, nil
+		}
+	}
+	return nil, &APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("model %q not found", req.Name),
+		Endpoint:   "/v1/models",
+	}
+}
+
+// PullModel is not available on OpenAI-compatible servers
+func (o *OpenAIClient) PullModel(ctx context.Context, req PullRequest) (<-chan PullResponse, error) {
+	return nil, fmt.Errorf("cannot pull %s: %w", req.Name, ErrUnsupported)
+}
+
+func (o *OpenAIClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	body, err := openAIChatBody(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var result openAIResponse
+	if err := o.client.doRequest(ctx, "POST", "/v1/chat/completions", body, &result); err != nil {
+		return nil, err
+	}
+
+	resp := &ChatResponse{
+		Model:         result.Model,
+		CreatedAt:     result.createdAt(),
+		Message:       ChatMessage{Role: RoleAssistant},
+		Done:          true,
+		TotalDuration: time.Since(start).Nanoseconds(),
+	}
+	if len(result.Choices) > 0 {
+		reply := result.Choices[0].Message
+		resp.Message.Content = reply.Content
+		resp.Message.Thinking = reply.ReasoningContent
+		if resp.Message.ToolCalls, err = convertToolCalls(reply.ToolCalls); err != nil {
+			return nil, err
+		}
+	}
+	if result.Usage != nil {
+		resp.PromptEvalCount = result.Usage.PromptTokens
+		resp.EvalCount = result.Usage.CompletionTokens
+	}
+	return resp, nil
+}
+
+func (o *OpenAIClient) ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatResponse, error) {
+	body, err := openAIChatBody(req, true)
+	if err != nil {
+		return nil, err
+	}
+	respCh := make(chan ChatResponse)
+
+	go func() {
+		defer close(respCh)
+		send := func(resp ChatResponse) error {
+			select {
+			case respCh <- resp:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		start := time.Now()
+		final := ChatResponse{Model: req.Model, Message: ChatMessage{Role: RoleAssistant}, Done: true}
+		calls := make(map[int]*openAIToolCall)
+
+		err := o.client.streamRequest(ctx, "POST", "/v1/chat/completions", body, func(data []byte) error {
+			var chunk openAIResponse
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				return err
+			}
+			if chunk.Usage != nil {
+				final.PromptEvalCount = chunk.Usage.PromptTokens
+				final.EvalCount = chunk.Usage.CompletionTokens
+			}
+			if chunk.Model != "" {
+				final.Model = chunk.Model
+			}
+
+			for _, choice := range chunk.Choices {
+				mergeToolCalls(calls, choice.Delta.ToolCalls)
+				if choice.Delta.Content == "" && choice.Delta.ReasoningContent == "" {
+					continue
+				}
+				if err := send(ChatResponse{
+					Model:     chunk.Model,
+					CreatedAt: chunk.createdAt(),
+					Message:   ChatMessage{Role: RoleAssistant, Content: choice.Delta.Content, Thinking: choice.Delta.ReasoningContent},
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			final.Message.ToolCalls, err = convertToolCalls(orderedToolCalls(calls))
+		}
+		if err != nil {
+			if err != ctx.Err() {
+				send(ChatResponse{Done: true, Err: err})
+			}
+			return
+		}
+
+		final.CreatedAt = time.Now()
+		final.TotalDuration = time.Since(start).Nanoseconds()
+		send(final)
+	}()
+
+	return respCh, nil
+}
+
+func (o *OpenAIClient) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	start := time.Now()
+	var result openAIResponse
+	if err := o.client.doRequest(ctx, "POST", "/v1/completions", openAICompletionBody(req, false), &result); err != nil {
+		return nil, err
+	}
+
+	resp := &GenerateResponse{
+		Model:         result.Model,
+		CreatedAt:     result.createdAt(),
+		Done:          true,
+		TotalDuration: time.Since(start).Nanoseconds(),
+	}
+	if len(result.Choices) > 0 {
+		resp.Response = result.Choices[0].Text
+	}
+	if result.Usage != nil {
+		resp.PromptEvalCount = result.Usage.PromptTokens
+		resp.EvalCount = result.Usage.CompletionTokens
+	}
+	return resp, nil
+}
+
+func (o *OpenAIClient) GenerateStream(ctx context.Context, req GenerateRequest) (<-chan GenerateResponse, error) {
+	body := openAICompletionBody(req, true)
+	respCh := make(chan GenerateResponse)
+
+	go func() {
+		defer close(respCh)
+		send := func(resp GenerateResponse) error {
+			select {
+			case respCh <- resp:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		start := time.Now()
+		final := GenerateResponse{Model: req.Model, Done: true}
+
+		err := o.client.streamRequest(ctx, "POST", "/v1/completions", body, func(data []byte) error {
+			var chunk openAIResponse
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				return err
+			}
+			if chunk.Usage != nil {
+				final.PromptEvalCount = chunk.Usage.PromptTokens
+				final.EvalCount = chunk.Usage.CompletionTokens
+			}
+			if chunk.Model != "" {
+				final.Model = chunk.Model
+			}
+
+			for _, choice := range chunk.Choices {
+				if choice.Text == "" {
+					continue
+				}
+				if err := send(GenerateResponse{Model: chunk.Model, CreatedAt: chunk.createdAt(), Response: choice.Text}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if err != ctx.Err() {
+				send(GenerateResponse{Done: true, Err: err})
+			}
+			return
+		}
+
+		final.CreatedAt = time.Now()
+		final.TotalDuration = time.Since(start).Nanoseconds()
+		send(final)
+	}()
+
+	return respCh, nil
+}
+
+func (o *OpenAIClient) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	var result struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	body := map[string]interface{}{"model": req.Model, "input": req.Input}
+	if err := o.client.doRequest(ctx, "POST", "/v1/embeddings", body, &result); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+	embeddings := make([][]float64, len(result.Data))
+	for i, item := range result.Data {
+		embeddings[i] = item.Embedding
+	}
+	return &EmbedResponse{Model: result.Model, Embeddings: embeddings}, nil
+}
+
+// openAIOptionNames maps Ollama option names to OpenAI request fields;
+// options without an equivalent are dropped
+var openAIOptionNames = map[string]string{
+	"temperature":       "temperature",
+	"top_p":             "top_p",
+	"top_k":             "top_k",
+	"min_p":             "min_p",
+	"seed":              "seed",
+	"stop":              "stop",
+	"num_predict":       "max_tokens",
+	"presence_penalty":  "presence_penalty",
+	"frequency_penalty": "frequency_penalty",
+}
+
+func applyOpenAIOptions(body map[string]interface{}, options map[string]interface{}) {
+	for name, value := range options {
+		if field, ok := openAIOptionNames[name]; ok {
+			body[field] = value
+		}
+	}
+}
+
+func openAIChatBody(req ChatRequest, stream bool) (map[string]interface{}, error) {
+	messages := make([]openAIMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		converted, err := toOpenAIMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		messages[i] = converted
+	}
+
+	body := map[string]interface{}{
+		"model":    req.Model,
+		"messages": messages,
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = req.Tools
+	}
+	if format := openAIResponseFormat(req.Format); format != nil {
+		body["response_format"] = format
+	}
+	applyOpenAIOptions(body, req.Options)
+	return body, nil
+}
+
+func openAICompletionBody(req GenerateRequest, stream bool) map[string]interface{} {
+	prompt := req.Prompt
+	if req.System != "" && !req.Raw {
+		prompt = req.System + "\n\n" + prompt
+	}
+
+	body := map[string]interface{}{
+		"model":  req.Model,
+		"prompt": prompt,
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	applyOpenAIOptions(body, req.Options)
+	return body
+}
+
+// openAIResponseFormat translates an Ollama format ("json" or a JSON-Schema
+// document) to a response_format object
+func openAIResponseFormat(format json.RawMessage) map[string]interface{} {
+	if len(format) == 0 {
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(format, &name); err == nil {
+		if name == "json" {
+			return map[string]interface{}{"type": "json_object"}
+		}
+		return nil
+	}
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   "response",
+			"schema": format,
+		},
+	}
+}
+
+func toOpenAIMessage(msg ChatMessage) (openAIMessage, error) {
+	out := openAIMessage{Role: msg.Role, Content: msg.Content, ToolCallID: msg.ToolCallID}
+
+	if len(msg.Images) > 0 {
+		parts := []openAIContentPart{{Type: "text", Text: msg.Content}}
+		for _, image := range msg.Images {
+			parts = append(parts, openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: imageDataURL(image)}})
+		}
+		out.Content = parts
+	}
+
+	for i, call := range msg.ToolCalls {
+		args, err := json.Marshal(call.Function.Arguments)
+		if err != nil {
+			return openAIMessage{}, fmt.Errorf("failed to encode arguments of tool call %s: %w", call.Function.Name, err)
+		}
+		converted := openAIToolCall{ID: call.ID, Type: "function"}
+		if converted.ID == "" {
+			converted.ID = fmt.Sprintf("call_%d", i)
+		}
+		converted.Function.Name = call.Function.Name
+		converted.Function.Arguments = string(args)
+		out.ToolCalls = append(out.ToolCalls, converted)
+	}
+	return out, nil
+}
+
+// imageDataURL wraps a base64 image in a data URL with a sniffed media type
+func imageDataURL(image string) string {
+	head := image
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	mediaType := "image/png"
+	if data, err := base64.StdEncoding.DecodeString(head[:len(head)/4*4]); err == nil {
+		if detected := http.DetectContentType(data); strings.HasPrefix(detected, "image/") {
+			mediaType = detected
+		}
+	}
+	return "data:" + mediaType + ";base64," + image
+}
+
+// mergeToolCalls accumulates streamed tool call fragments by index
+func mergeToolCalls(calls map[int]*openAIToolCall, fragments []openAIToolCall) {
+	for i, fragment := range fragments {
+		index := i
+		if fragment.Index != nil {
+			index = *fragment.Index
+		}
+		call, ok := calls[index]
+		if !ok {
+			call = &openAIToolCall{}
+			calls[index] = call
+		}
+		if fragment.ID != "" {
+			call.ID = fragment.ID
+		}
+		if fragment.Function.Name != "" {
+			call.Function.Name = fragment.Function.Name
+		}
+		call.Function.Arguments += fragment.Function.Arguments
+	}
+}
+
+func orderedToolCalls(calls map[int]*openAIToolCall) []openAIToolCall {
+	indexes := make([]int, 0, len(calls))
+	for index := range calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	ordered := make([]openAIToolCall, len(indexes))
+	for i, index := range indexes {
+		ordered[i] = *calls[index]
+	}
+	return ordered
+}
+
+// convertToolCalls decodes the JSON-encoded arguments OpenAI servers send
+func convertToolCalls(calls []openAIToolCall) ([]ToolCall, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	converted := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		args := make(map[string]interface{})
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool call %s: %w", call.Function.Name, err)
+			}
+		}
+		converted[i] = ToolCall{ID: call.ID, Function: ToolCallFunction{Name: call.Function.Name, Arguments: args}}
+	}
+	return converted, nil
+}
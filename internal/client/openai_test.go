@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		fmt.Fprintf(w, "data: %s\n\n", event)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		writeSSE(w,
+			`{"model":"qwen","created":1700000000,"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"pa"}}]}}]}`,
+			`{"model":"qwen","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"th\":\"a.txt\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"model":"qwen","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":5}}`,
+		)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAI(Options{BaseURL: server.URL + "/v1", Token: "sk-test"})
+	stream, err := provider.ChatStream(context.Background(), ChatRequest{
+		Model:    "qwen",
+		Messages: []ChatMessage{{Role: RoleUser, Content: "hi"}},
+		Options:  map[string]interface{}{"temperature": 0.2, "num_predict": 64, "num_ctx": 4096},
+		Format:   FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var content strings.Builder
+	var final ChatResponse
+	for resp := range stream {
+		if resp.Err != nil {
+			t.Fatalf("stream error: %v", resp.Err)
+		}
+		content.WriteString(resp.Message.Content)
+		if resp.Done {
+			final = resp
+		}
+	}
+
+	if content.String() != "Hello" {
+		t.Errorf("content = %q, want Hello", content.String())
+	}
+	if !final.Done || final.PromptEvalCount != 12 || final.EvalCount != 5 {
+		t.Errorf("final = %+v, want done with usage", final)
+	}
+	if len(final.Message.ToolCalls) != 1 || final.Message.ToolCalls[0].ID != "call_1" ||
+		final.Message.ToolCalls[0].Function.Arguments["path"] != "a.txt" {
+		t.Errorf("tool calls = %+v", final.Message.ToolCalls)
+	}
+
+	if body["temperature"] != 0.2 || body["max_tokens"] != float64(64) {
+		t.Errorf("options not mapped: %v", body)
+	}
+	if _, ok := body["num_ctx"]; ok {
+		t.Errorf("options without an OpenAI equivalent should be dropped: %v", body)
+	}
+	if format, _ := body["response_format"].(map[string]interface{}); format["type"] != "json_object" {
+		t.Errorf("response_format = %v", body["response_format"])
+	}
+}
+
+func TestOpenAIChatSendsToolResultsAndImages(t *testing.T) {
+	var body struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"model":"qwen","choices":[{"message":{"role":"assistant","content":"done"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAI(Options{BaseURL: server.URL})
+	resp, err := provider.Chat(context.Background(), ChatRequest{
+		Model: "qwen",
+		Messages: []ChatMessage{
+			{Role: RoleUser, Content: "look", Images: []string{"iVBORw0KGgoAAAANSUhEUg=="}},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_9", Function: ToolCallFunction{Name: "list_directory", Arguments: map[string]interface{}{"path": "."}}}}},
+			{Role: RoleTool, Content: "a.txt", ToolName: "list_directory", ToolCallID: "call_9"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Message.Content != "done" || resp.EvalCount != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if len(body.Messages) != 3 {
+		t.Fatalf("sent %d messages, want 3", len(body.Messages))
+	}
+	if !strings.Contains(string(body.Messages[0]), `"url":"data:image/png;base64,iVBORw0KGgo`) {
+		t.Errorf("image not sent as a data URL: %s", body.Messages[0])
+	}
+	if !strings.Contains(string(body.Messages[1]), `"arguments":"{\"path\":\".\"}"`) {
+		t.Errorf("tool call arguments not JSON-encoded: %s", body.Messages[1])
+	}
+	if !strings.Contains(string(body.Messages[2]), `"tool_call_id":"call_9"`) {
+		t.Errorf("tool result missing tool_call_id: %s", body.Messages[2])
+	}
+}
+
+func TestOpenAIGenerateStream(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		prompt = body.Prompt
+		writeSSE(w,
+			`{"model":"tiny","choices":[{"text":"Once"}]}`,
+			`{"model":"tiny","choices":[{"text":" upon","finish_reason":"length"}],"usage":{"prompt_tokens":4,"completion_tokens":2}}`,
+		)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAI(Options{BaseURL: server.URL})
+	stream, err := provider.GenerateStream(context.Background(), GenerateRequest{Model: "tiny", Prompt: "Tell a story", System: "Be brief"})
+	if err != nil {
+		t.Fatalf("GenerateStream() error = %v", err)
+	}
+
+	var text strings.Builder
+	var final GenerateResponse
+	for resp := range stream {
+		text.WriteString(resp.Response)
+		if resp.Done {
+			final = resp
+		}
+	}
+	if text.String() != "Once upon" || final.EvalCount != 2 {
+		t.Errorf("text = %q, final = %+v", text.String(), final)
+	}
+	if prompt != "Be brief\n\nTell a story" {
+		t.Errorf("prompt = %q, want the system prompt prepended", prompt)
+	}
+}
+
+func TestOpenAIStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, `{"error":{"message":"context length exceeded","type":"invalid_request_error"}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAI(Options{BaseURL: server.URL, Retries: 1, RetryDelay: time.Millisecond})
+	stream, err := provider.ChatStream(context.Background(), ChatRequest{Model: "qwen"})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	var last ChatResponse
+	for resp := range stream {
+		last = resp
+	}
+	var apiErr *APIError
+	if !errors.As(last.Err, &apiErr) || apiErr.Message != "context length exceeded" {
+		t.Errorf("Err = %v, want the server message", last.Err)
+	}
+}
+
+func TestOpenAIModelsAndEmbeddings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Write([]byte(`{"object":"list","data":[{"id":"qwen","created":1700000000},{"id":"bge-small"}]}`))
+		case "/v1/embeddings":
+			w.Write([]byte(`{"model":"bge-small","data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider("openai", Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	models, err := provider.ListModels(context.Background())
+	if err != nil || len(models.Models) != 2 || models.Models[0].Name != "qwen" {
+		t.Fatalf("ListModels() = %+v, %v", models, err)
+	}
+	if ok, err := HasModel(context.Background(), provider, "qwen"); err != nil || !ok {
+		t.Errorf("HasModel(qwen) = %v, %v", ok, err)
+	}
+	if _, err := provider.ShowModel(context.Background(), ShowRequest{Name: "llama3"}); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("ShowModel(missing) error = %v, want ErrModelNotFound", err)
+	}
+	if _, err := provider.PullModel(context.Background(), PullRequest{Name: "qwen"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("PullModel() error = %v, want ErrUnsupported", err)
+	}
+
+	embeddings, err := provider.Embed(context.Background(), EmbedRequest{Model: "bge-small", Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if embeddings.Embeddings[0][0] != 1 || embeddings.Embeddings[1][1] != 1 {
+		t.Errorf("embeddings not ordered by index: %v", embeddings.Embeddings)
+	}
+
+	if _, err := NewProvider("bedrock", Options{}); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
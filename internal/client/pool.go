@@ -0,0 +1,583 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategy selects how requests are spread across a pool of endpoints
+type Strategy string
+
+const (
+	// StrategyRoundRobin rotates through the candidate endpoints
+	StrategyRoundRobin Strategy = "round-robin"
+	// StrategyLeastInflight picks the endpoint with the fewest open requests
+	StrategyLeastInflight Strategy = "least-inflight"
+)
+
+const (
+	defaultHealthInterval = 30 * time.Second
+	healthCheckTimeout    = 5 * time.Second
+)
+
+// ParseStrategy validates a load balancing strategy name; empty means round-robin
+func ParseStrategy(name string) (Strategy, error) {
+	switch Strategy(strings.ToLower(strings.TrimSpace(name))) {
+	case "", StrategyRoundRobin:
+		return StrategyRoundRobin, nil
+	case StrategyLeastInflight:
+		return StrategyLeastInflight, nil
+	}
+	return "", fmt.Errorf("unknown load balancing strategy %q (use %s or %s)", name, StrategyRoundRobin, StrategyLeastInflight)
+}
+
+// EndpointStatus is a snapshot of one pool endpoint
+type EndpointStatus struct {
+	URL          string    `json:"url"`
+	Healthy      bool      `json:"healthy"`
+	Inflight     int       `json:"inflight"`
+	LoadedModels []string  `json:"loaded_models,omitempty"`
+	LastCheck    time.Time `json:"last_check"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// PoolOptions configures NewPool
+type PoolOptions struct {
+	// Endpoints are server base URLs; "host:port" is accepted as http://host:port
+	Endpoints []string
+	// Strategy spreads requests among equally good endpoints (default round-robin)
+	Strategy Strategy
+	// HealthInterval is how often endpoints are re-probed (default 30s)
+	HealthInterval time.Duration
+	// Token is sent with health checks
+	Token string
+}
+
+type endpoint struct {
+	url       *url.URL
+	healthy   bool
+	inflight  int
+	loaded    map[string]bool
+	lastCheck time.Time
+	lastError string
+}
+
+// Pool spreads requests over several Ollama servers. It health-checks them
+// with /api/version (falling back to /api/tags), prefers servers that already
+// have the requested model loaded and fails over on connection errors.
+// A Pool serves a single Client, passed in Options.Pool.
+type Pool struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	strategy  Strategy
+	interval  time.Duration
+	token     string
+	next      http.RoundTripper
+	// probe sends health checks; nil skips them
+	probe http.RoundTripper
+	// base is the path of the client's base URL, which every request path
+	// starts with; it is replaced by the path of the chosen endpoint
+	base   string
+	cursor int
+	last   string
+}
+
+// NewPool creates an endpoint pool
+func NewPool(opts PoolOptions) (*Pool, error) {
+	if len(opts.Endpoints) == 0 {
+		return nil, errors.New("endpoint pool needs at least one endpoint")
+	}
+
+	strategy, err := ParseStrategy(string(opts.Strategy))
+	if err != nil {
+		return nil, err
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = defaultHealthInterval
+	}
+
+	pool := &Pool{strategy: strategy, interval: opts.HealthInterval, token: opts.Token}
+	for _, raw := range opts.Endpoints {
+		u, err := parseEndpoint(raw)
+		if err != nil {
+			return nil, err
+		}
+		// Endpoints count as healthy until their first probe says otherwise
+		pool.endpoints = append(pool.endpoints, &endpoint{url: u, healthy: true, loaded: make(map[string]bool)})
+	}
+	return pool, nil
+}
+
+func parseEndpoint(raw string) (*url.URL, error) {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: expected host:port or a URL such as http://host:11434", raw)
+	}
+	return u, nil
+}
+
+// URL returns the base URL of the first endpoint
+func (p *Pool) URL() string {
+	return p.endpoints[0].url.String()
+}
+
+// middleware routes every request through the pool. Requests are built
+// against baseURL, usually the first endpoint. Health checks are sent with
+// probe, or skipped if it is nil.
+func (p *Pool) middleware(next, probe http.RoundTripper, baseURL string) http.RoundTripper {
+	p.next = next
+	p.probe = probe
+	if u, err := url.Parse(baseURL); err == nil {
+		p.base = strings.TrimRight(u.Path, "/")
+	}
+	return RoundTripperFunc(p.roundTrip)
+}
+
+// LastEndpoint returns the URL of the endpoint that served the latest request
+func (p *Pool) LastEndpoint() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+// Status returns a snapshot of every endpoint in the pool
+func (p *Pool) Status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]EndpointStatus, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		status[i] = EndpointStatus{
+			URL:       ep.url.String(),
+			Healthy:   ep.healthy,
+			Inflight:  ep.inflight,
+			LastCheck: ep.lastCheck,
+			LastError: ep.lastError,
+		}
+		for model := range ep.loaded {
+			status[i].LoadedModels = append(status[i].LoadedModels, model)
+		}
+	}
+	return status
+}
+
+// CheckHealth probes every endpoint now and refreshes its loaded models
+func (p *Pool) CheckHealth(ctx context.Context) {
+	if p.probe == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, ep := range p.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			p.check(ctx, ep)
+		}(ep)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) roundTrip(req *http.Request) (*http.Response, error) {
+	if err := p.refresh(req.Context()); err != nil {
+		return nil, err
+	}
+
+	// The API path, such as /api/chat, without the base URL's prefix
+	path := strings.TrimPrefix(req.URL.Path, p.base)
+
+	// Uploads are passed through as they are read; they can only move to
+	// another endpoint if the body can be obtained again
+	var body []byte
+	streamed := streamedBody(req)
+	if req.Body != nil && !streamed {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	candidates := p.candidates(path, requestModel(body))
+	pin, _ := req.Context().Value(pinKey{}).(*pinnedEndpoint)
+	if ep := pin.get(); ep != nil {
+		candidates = []*endpoint{ep}
+	}
+
+	var lastErr error
+	for i, ep := range candidates {
+		in := req
+		if streamed && i > 0 {
+			if req.GetBody == nil {
+				break
+			}
+			rc, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			in = req.Clone(req.Context())
+			in.Body = rc
+		}
+		resp, err := p.send(in, ep, path, body)
+		if err == nil {
+			pin.set(ep)
+			return resp, nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		p.markDown(ep, err)
+		lastErr = err
+		// The server may have acted on the request already; only repeat it
+		// elsewhere when that is safe, as doRequest does for retries
+		if !notSent(err) && !isIdempotent(req.Method, path) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// send forwards req for the API path to ep, keeping the in-flight count
+// until the body is closed. A nil body leaves the request's own body, if
+// any, in place.
+func (p *Pool) send(req *http.Request, ep *endpoint, path string, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = ep.url.Scheme
+	out.URL.Host = ep.url.Host
+	out.URL.Path = ep.url.Path + path
+	out.URL.RawPath = ""
+	out.Host = ""
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	p.mu.Lock()
+	ep.inflight++
+	p.mu.Unlock()
+
+	resp, err := p.next.RoundTrip(out)
+	if err != nil {
+		p.release(ep)
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.last = ep.url.String()
+	p.mu.Unlock()
+
+	if resp.StatusCode < 400 {
+		if model := requestModel(body); model != "" && loadsModel(path) {
+			p.mu.Lock()
+			if unloadsModel(body) {
+				delete(ep.loaded, NormalizeModelName(model))
+			} else {
+				ep.loaded[NormalizeModelName(model)] = true
+			}
+			p.mu.Unlock()
+		}
+	}
+
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: func() { p.release(ep) }}
+	return resp, nil
+}
+
+func (p *Pool) release(ep *endpoint) {
+	p.mu.Lock()
+	ep.inflight--
+	p.mu.Unlock()
+}
+
+func (p *Pool) markDown(ep *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep.healthy = false
+	ep.lastError = err.Error()
+	ep.lastCheck = time.Now()
+}
+
+// candidates orders the endpoints to try for a request: healthy ones before
+// unhealthy ones, and among those the ones with the model loaded first
+func (p *Pool) candidates(path, model string) []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var loaded, healthy, down []*endpoint
+	order := p.rotation()
+	for _, ep := range order {
+		switch {
+		case !ep.healthy:
+			down = append(down, ep)
+		case model != "" && ep.loaded[NormalizeModelName(model)]:
+			loaded = append(loaded, ep)
+		default:
+			healthy = append(healthy, ep)
+		}
+	}
+
+	if p.strategy == StrategyLeastInflight {
+		sortByInflight(loaded)
+		sortByInflight(healthy)
+	}
+
+	result := append(loaded, healthy...)
+	return append(result, down...)
+}
+
+// rotation returns the endpoints starting at the round-robin cursor and
+// advances it; callers hold p.mu
+func (p *Pool) rotation() []*endpoint {
+	n := len(p.endpoints)
+	order := make([]*endpoint, n)
+	for i := range order {
+		order[i] = p.endpoints[(p.cursor+i)%n]
+	}
+	p.cursor = (p.cursor + 1) % n
+	return order
+}
+
+// sortByInflight is a stable insertion sort, so ties keep round-robin order
+func sortByInflight(endpoints []*endpoint) {
+	for i := 1; i < len(endpoints); i++ {
+		for j := i; j > 0 && endpoints[j].inflight < endpoints[j-1].inflight; j-- {
+			endpoints[j], endpoints[j-1] = endpoints[j-1], endpoints[j]
+		}
+	}
+}
+
+// refresh probes endpoints whose last health check is older than the
+// interval. Endpoints never checked are waited for, until ctx is done, so
+// the first request can be routed by their state; later re-probes run in
+// the background and never hold up a request. The checks do not use ctx,
+// whose cancellation says nothing about the servers.
+func (p *Pool) refresh(ctx context.Context) error {
+	if p.probe == nil {
+		return nil
+	}
+	p.mu.Lock()
+	var first, stale []*endpoint
+	for _, ep := range p.endpoints {
+		if time.Since(ep.lastCheck) >= p.interval {
+			if ep.lastCheck.IsZero() {
+				first = append(first, ep)
+			} else {
+				stale = append(stale, ep)
+			}
+			// Claim the check so concurrent requests do not probe again
+			ep.lastCheck = time.Now()
+		}
+	}
+	p.mu.Unlock()
+
+	for _, ep := range stale {
+		go p.check(context.Background(), ep)
+	}
+	if len(first) == 0 {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for _, ep := range first {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			p.check(context.Background(), ep)
+		}(ep)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// check probes that ep answers and records which models it has loaded
+func (p *Pool) check(ctx context.Context, ep *endpoint) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	status, _, err := p.get(ctx, ep, "/api/version")
+	if err == nil && status == http.StatusNotFound {
+		status, _, err = p.get(ctx, ep, "/api/tags")
+	}
+	if err == nil && status >= 400 {
+		err = fmt.Errorf("health check returned status %d", status)
+	}
+
+	var loaded map[string]bool
+	if err == nil {
+		loaded = p.loadedModels(ctx, ep)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep.lastCheck = time.Now()
+	ep.healthy = err == nil
+	ep.lastError = ""
+	if err != nil {
+		ep.lastError = err.Error()
+		return
+	}
+	if loaded != nil {
+		ep.loaded = loaded
+	}
+}
+
+// loadedModels asks ep which models are in memory; nil means unknown
+func (p *Pool) loadedModels(ctx context.Context, ep *endpoint) map[string]bool {
+	status, body, err := p.get(ctx, ep, "/api/ps")
+	if err != nil || status != http.StatusOK {
+		return nil
+	}
+
+	var running ListModelsResponse
+	if err := json.Unmarshal(body, &running); err != nil {
+		return nil
+	}
+
+	loaded := make(map[string]bool, len(running.Models))
+	for _, model := range running.Models {
+		loaded[NormalizeModelName(model.Name)] = true
+	}
+	return loaded
+}
+
+func (p *Pool) get(ctx context.Context, ep *endpoint, path string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.url.String()+path, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.probe.RoundTrip(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// pinKey is the context key of a pinnedEndpoint
+type pinKey struct{}
+
+// pinnedEndpoint holds the endpoint that served the first request made
+// with a context from withPinnedEndpoint
+type pinnedEndpoint struct {
+	mu sync.Mutex
+	ep *endpoint
+}
+
+// withPinnedEndpoint returns a context whose pooled requests all go to the
+// endpoint that serves the first of them, without failing over. Blob
+// uploads and the create that uses them must reach the same server.
+func withPinnedEndpoint(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinKey{}, &pinnedEndpoint{})
+}
+
+func (p *pinnedEndpoint) get() *endpoint {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ep
+}
+
+func (p *pinnedEndpoint) set(ep *endpoint) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ep == nil {
+		p.ep = ep
+	}
+}
+
+// requestModel extracts the model a request body refers to, if any
+func requestModel(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var payload struct {
+		Model string `json:"model"`
+		Name  string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	if payload.Model != "" {
+		return payload.Model
+	}
+	return payload.Name
+}
+
+// loadsModel reports whether a successful request to path leaves the model in memory
+func loadsModel(path string) bool {
+	switch path {
+	case "/api/chat", "/api/generate", "/api/embed", "/api/embeddings":
+		return true
+	}
+	return false
+}
+
+// unloadsModel reports whether the request asks for keep_alive 0, which
+// frees the model as soon as the request is done
+func unloadsModel(body []byte) bool {
+	var payload struct {
+		KeepAlive json.RawMessage `json:"keep_alive"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.KeepAlive) == 0 {
+		return false
+	}
+	var seconds float64
+	if err := json.Unmarshal(payload.KeepAlive, &seconds); err == nil {
+		return seconds == 0
+	}
+	var s string
+	if err := json.Unmarshal(payload.KeepAlive, &s); err != nil {
+		return false
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n == 0
+	}
+	d, err := time.ParseDuration(s)
+	return err == nil && d == 0
+}
+
+// releaseBody runs release once when the response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
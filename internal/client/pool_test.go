@@ -0,0 +1,471 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// poolServer is a fake Ollama server that counts the requests it serves
+type poolServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func newPoolServer(t *testing.T, loaded ...string) *poolServer {
+	t.Helper()
+	s := &poolServer{hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		s.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.5.0"}`))
+		case "/api/ps":
+			var running ListModelsResponse
+			for _, name := range loaded {
+				running.Models = append(running.Models, Model{Name: name})
+			}
+			json.NewEncoder(w).Encode(running)
+		case "/api/tags":
+			w.Write([]byte(`{"models":[]}`))
+		case "/api/chat":
+			w.Write([]byte(`{"message":{"role":"assistant","content":"hi"},"done":true}`))
+		case "/api/generate":
+			w.Write([]byte(`{"response":"","done":true}`))
+		case "/api/blobs/sha256:abc":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *poolServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+func newPoolClient(t *testing.T, strategy Strategy, urls ...string) *Client {
+	t.Helper()
+	pool, err := NewPool(PoolOptions{Endpoints: urls, Strategy: strategy})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	return New(Options{Pool: pool, Retries: 1, RetryDelay: time.Millisecond})
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	a, b := newPoolServer(t), newPoolServer(t)
+	c := newPoolClient(t, StrategyRoundRobin, a.URL, b.URL)
+
+	for i := 0; i < 4; i++ {
+		if _, err := c.ListModels(context.Background()); err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+	}
+
+	if a.count("/api/tags") != 2 || b.count("/api/tags") != 2 {
+		t.Errorf("requests not spread evenly: a=%d b=%d", a.count("/api/tags"), b.count("/api/tags"))
+	}
+	if a.count("/api/version") != 1 || b.count("/api/version") != 1 {
+		t.Errorf("expected one health check per endpoint, got a=%d b=%d", a.count("/api/version"), b.count("/api/version"))
+	}
+}
+
+func TestPoolPrefersEndpointWithModelLoaded(t *testing.T) {
+	a, b := newPoolServer(t), newPoolServer(t, "llama2:latest")
+	c := newPoolClient(t, StrategyRoundRobin, a.URL, b.URL)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Chat(context.Background(), ChatRequest{Model: "llama2"}); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if c.Endpoint() != b.URL {
+			t.Errorf("turn %d served by %s, want %s", i, c.Endpoint(), b.URL)
+		}
+	}
+	if a.count("/api/chat") != 0 {
+		t.Errorf("endpoint without the model served %d chats", a.count("/api/chat"))
+	}
+}
+
+func TestPoolForgetsUnloadedModels(t *testing.T) {
+	a := newPoolServer(t, "llama2:latest")
+	c := newPoolClient(t, StrategyRoundRobin, a.URL)
+
+	if err := UnloadModel(context.Background(), c, "llama2"); err != nil {
+		t.Fatalf("UnloadModel() error = %v", err)
+	}
+	if loaded := c.Pool().Status()[0].LoadedModels; len(loaded) != 0 {
+		t.Errorf("unloaded model still counted as loaded: %v", loaded)
+	}
+
+	if err := LoadModel(context.Background(), c, "llama2", "10m"); err != nil {
+		t.Fatalf("LoadModel() error = %v", err)
+	}
+	if loaded := c.Pool().Status()[0].LoadedModels; len(loaded) != 1 {
+		t.Errorf("expected the loaded model to be counted, got %v", loaded)
+	}
+}
+
+func TestPoolEndpointsWithPathPrefix(t *testing.T) {
+	// Servers behind a gateway that routes /ollama and /gw to them
+	a, b := newPoolServer(t), newPoolServer(t, "llama2:latest")
+	frontA := httptest.NewServer(http.StripPrefix("/ollama", a.Config.Handler))
+	defer frontA.Close()
+	frontB := httptest.NewServer(http.StripPrefix("/gw", b.Config.Handler))
+	defer frontB.Close()
+
+	c := newPoolClient(t, StrategyRoundRobin, frontA.URL+"/ollama", frontB.URL+"/gw/")
+	for i := 0; i < 2; i++ {
+		if _, err := c.Chat(context.Background(), ChatRequest{Model: "llama2"}); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+	if b.count("/api/chat") != 2 {
+		t.Errorf("expected both chats at /gw/api/chat on the endpoint with the model, got %d", b.count("/api/chat"))
+	}
+
+	// Uploads are recognised by the API path under either prefix
+	if err := c.CreateBlob(context.Background(), "sha256:abc", strings.NewReader("weights")); err != nil {
+		t.Fatalf("CreateBlob() error = %v", err)
+	}
+	if a.count("/api/blobs/sha256:abc")+b.count("/api/blobs/sha256:abc") != 1 {
+		t.Errorf("expected the upload served without a doubled prefix")
+	}
+	if _, err := c.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if a.count("/api/tags")+b.count("/api/tags") != 1 {
+		t.Errorf("expected the listing served without a doubled prefix")
+	}
+}
+
+func TestPoolPinnedEndpoint(t *testing.T) {
+	a, b := newPoolServer(t), newPoolServer(t)
+	c := newPoolClient(t, StrategyRoundRobin, a.URL, b.URL)
+
+	pinned := withPinnedEndpoint(context.Background())
+	if _, err := c.ListModels(pinned); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	first := c.Endpoint()
+
+	// Other requests keep rotating without moving the pinned ones
+	for i := 0; i < 3; i++ {
+		if _, err := c.ListModels(context.Background()); err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		if _, err := c.ListModels(pinned); err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+		if c.Endpoint() != first {
+			t.Fatalf("pinned request %d served by %s, want %s", i, c.Endpoint(), first)
+		}
+	}
+	if a.count("/api/tags") != 4 && b.count("/api/tags") != 4 {
+		t.Errorf("expected the pinned requests on one endpoint, got a=%d b=%d", a.count("/api/tags"), b.count("/api/tags"))
+	}
+}
+
+func TestPoolFailsOverOnConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+	up := newPoolServer(t)
+
+	c := newPoolClient(t, StrategyRoundRobin, downURL, up.URL)
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListModels(context.Background()); err != nil {
+			t.Fatalf("ListModels() error = %v", err)
+		}
+	}
+
+	if up.count("/api/tags") != 2 {
+		t.Errorf("healthy endpoint served %d requests, want 2", up.count("/api/tags"))
+	}
+	for _, status := range c.Pool().Status() {
+		if status.URL == downURL && status.Healthy {
+			t.Errorf("unreachable endpoint still marked healthy")
+		}
+	}
+}
+
+func TestPoolKeepsNonIdempotentRequestsOnOneEndpoint(t *testing.T) {
+	var copies int32
+	// Drops the connection after the request was sent
+	drop := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.5.0"}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[]}`))
+		default:
+			atomic.AddInt32(&copies, 1)
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	}
+	a, b := httptest.NewServer(http.HandlerFunc(drop)), httptest.NewServer(http.HandlerFunc(drop))
+	defer a.Close()
+	defer b.Close()
+
+	c := newPoolClient(t, StrategyRoundRobin, a.URL, b.URL)
+	if err := c.CopyModel(context.Background(), CopyRequest{Source: "a", Destination: "b"}); err == nil {
+		t.Fatal("expected the dropped copy to fail")
+	}
+	if got := atomic.LoadInt32(&copies); got != 1 {
+		t.Errorf("copy sent %d times, want 1", got)
+	}
+}
+
+func TestPoolHealthChecksBypassMiddleware(t *testing.T) {
+	a := newPoolServer(t, "llama2:latest")
+	pool, err := NewPool(PoolOptions{Endpoints: []string{a.URL}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	var mu sync.Mutex
+	var seen []string
+	trace := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			seen = append(seen, req.URL.Path)
+			mu.Unlock()
+			return next.RoundTrip(req)
+		})
+	}
+	dir := t.TempDir()
+	c := New(Options{Pool: pool, Record: dir, Middleware: []Middleware{trace}, Retries: 1, RetryDelay: time.Millisecond})
+
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "llama2"}); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if a.count("/api/version") != 1 || a.count("/api/ps") != 1 {
+		t.Fatalf("expected the endpoint to be health checked")
+	}
+	if len(seen) != 1 || seen[0] != "/api/chat" {
+		t.Errorf("middleware saw health checks: %v", seen)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 1 {
+		t.Errorf("expected only the chat recorded, got %v", files)
+	}
+
+	// Replaying needs no server, so nothing is probed or marked down
+	replay, err := NewPool(PoolOptions{Endpoints: []string{"127.0.0.1:1"}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	c = New(Options{Pool: replay, Replay: dir, BaseURL: a.URL, Retries: 1, RetryDelay: time.Millisecond})
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "llama2"}); err != nil {
+		t.Fatalf("replayed Chat() error = %v", err)
+	}
+	if status := replay.Status(); !status[0].Healthy {
+		t.Errorf("replay marked the endpoint down: %+v", status[0])
+	}
+}
+
+func TestPoolHealthChecksDoNotStallRequests(t *testing.T) {
+	var hang atomic.Bool
+	release := make(chan struct{})
+	a := newPoolServer(t)
+	// Answers until hang is set, then never answers health checks
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/version" && hang.Load() {
+			<-release
+			return
+		}
+		a.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	pool, err := NewPool(PoolOptions{Endpoints: []string{slow.URL}, HealthInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	c := New(Options{Pool: pool, Retries: 1, RetryDelay: time.Millisecond})
+	if _, err := c.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	// Stale endpoints are re-probed in the background
+	hang.Store(true)
+	time.Sleep(5 * time.Millisecond)
+	start := time.Now()
+	if _, err := c.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request waited %v for a health check", elapsed)
+	}
+
+	// The first check is waited for, but only as long as the request lasts
+	pool, err = NewPool(PoolOptions{Endpoints: []string{slow.URL}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	c = New(Options{Pool: pool, Retries: 1, RetryDelay: time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := c.ListModels(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to end the wait, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled request waited %v for a health check", elapsed)
+	}
+}
+
+func TestPoolCancelledRequestKeepsEndpointsHealthy(t *testing.T) {
+	a := newPoolServer(t)
+	c := newPoolClient(t, StrategyRoundRobin, a.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ListModels(ctx); err == nil {
+		t.Fatal("expected the cancelled request to fail")
+	}
+	if status := c.Pool().Status()[0]; !status.Healthy || status.LastError != "" {
+		t.Errorf("a cancelled request marked the endpoint down: %+v", status)
+	}
+}
+
+func TestPoolStreamsBlobUploads(t *testing.T) {
+	firstChunk := make(chan struct{})
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/version":
+			w.Write([]byte(`{"version":"0.5.0"}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[]}`))
+		default:
+			buf := make([]byte, 5)
+			io.ReadFull(r.Body, buf)
+			close(firstChunk)
+			rest, _ := io.ReadAll(r.Body)
+			received = append(buf, rest...)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	pool, err := NewPool(PoolOptions{Endpoints: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	dir := t.TempDir()
+	c := New(Options{Pool: pool, Record: dir, RetryDelay: time.Millisecond})
+
+	// The second half is only written once the server has the first, which
+	// deadlocks if the pool or the recorder buffers the whole body
+	body, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("GGUF "))
+		select {
+		case <-firstChunk:
+			writer.Write([]byte("weights"))
+			writer.Close()
+		case <-time.After(5 * time.Second):
+			writer.CloseWithError(io.ErrUnexpectedEOF)
+		}
+	}()
+
+	sum := sha256.Sum256([]byte("GGUF weights"))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if err := c.CreateBlob(context.Background(), digest, body); err != nil {
+		t.Fatalf("CreateBlob() error = %v", err)
+	}
+	if string(received) != "GGUF weights" {
+		t.Errorf("server received %q", received)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*-post-api-blobs-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one recorded upload, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), `"body_digest": "`+digest+`"`) || !strings.Contains(string(data), `"body_size": 12`) {
+		t.Errorf("expected the upload recorded by digest and size:\n%s", data)
+	}
+}
+
+func TestPoolLeastInflight(t *testing.T) {
+	release := make(chan struct{})
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/chat" {
+			w.Write([]byte(`{"message":{"role":"assistant","content":"slow"},"done":false}` + "\n"))
+			w.(http.Flusher).Flush()
+			<-release
+			return
+		}
+		if r.URL.Path == "/api/ps" {
+			w.Write([]byte(`{"models":[{"name":"llama2:latest"}]}`))
+			return
+		}
+		w.Write([]byte(`{"version":"0.5.0"}`))
+	}))
+	defer busy.Close()
+	defer close(release)
+	idle := newPoolServer(t, "llama2:latest")
+
+	c := newPoolClient(t, StrategyLeastInflight, busy.URL, idle.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.ChatStream(ctx, ChatRequest{Model: "llama2", Stream: true})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	<-stream
+	if c.Endpoint() != busy.URL {
+		t.Fatalf("stream served by %s, want %s", c.Endpoint(), busy.URL)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Chat(context.Background(), ChatRequest{Model: "llama2"}); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if c.Endpoint() != idle.URL {
+			t.Errorf("request %d went to %s while it had a stream open", i, c.Endpoint())
+		}
+	}
+}
+
+func TestNewPoolValidation(t *testing.T) {
+	if _, err := NewPool(PoolOptions{}); err == nil {
+		t.Error("expected an error for an empty pool")
+	}
+	if _, err := NewPool(PoolOptions{Endpoints: []string{"localhost:11434"}, Strategy: "random"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+
+	pool, err := NewPool(PoolOptions{Endpoints: []string{"gpu1:11434", "https://gpu2/"}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	status := pool.Status()
+	if status[0].URL != "http://gpu1:11434" || status[1].URL != "https://gpu2" {
+		t.Errorf("endpoints not normalized: %+v", status)
+	}
+}
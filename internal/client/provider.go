@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned by providers for operations their backend lacks
+var ErrUnsupported = errors.New("operation not supported by this backend")
+
+// Backend names accepted by NewProvider
+const (
+	BackendOllama = "ollama"
+	BackendOpenAI = "openai"
+)
+
+// Provider is the part of the API that chat, RAG and structured output need.
+// Client implements it against Ollama and OpenAIClient against servers that
+// speak the OpenAI /v1 API, such as llama.cpp's server or vLLM.
+type Provider interface {
+	ListModels(ctx context.Context) (*ListModelsResponse, error)
+	ShowModel(ctx context.Context, req ShowRequest) (*ShowResponse, error)
+	PullModel(ctx context.Context, req PullRequest) (<-chan PullResponse, error)
+	Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
+	GenerateStream(ctx context.Context, req GenerateRequest) (<-chan GenerateResponse, error)
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	ChatStream(ctx context.Context, req ChatRequest) (<-chan ChatResponse, error)
+	Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error)
+	// Endpoint returns the server that answered the latest request
+	Endpoint() string
+}
+
+var (
+	_ Provider = (*Client)(nil)
+	_ Provider = (*OpenAIClient)(nil)
+)
+
+// NewProvider creates the provider for a backend name; empty means Ollama
+func NewProvider(backend string, opts Options) (Provider, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendOllama:
+		return New(opts), nil
+	case BackendOpenAI:
+		return NewOpenAI(opts), nil
+	}
+	return nil, fmt.Errorf("unknown backend %q (use %s or %s)", backend, BackendOllama, BackendOpenAI)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// PullStatusResuming is reported when a dropped pull is started again
+	PullStatusResuming = "resuming"
+	// PullStatusVerified is the last status of a pull whose model was found
+	// installed afterwards; Digest holds the model's digest
+	PullStatusVerified = "verified"
+)
+
+// pull runs a pull to completion, resuming it after dropped streams for as
+// long as each attempt makes progress, then verifies the result
+func (c *Client) pull(ctx context.Context, req PullRequest, out chan<- PullResponse) error {
+	policy := c.policyFor("/api/pull")
+	completed := make(map[string]int64)
+	failures := 0
+
+	for {
+		delivered, success, advanced := false, false, false
+		err := c.streamRequest(ctx, "POST", "/api/pull", req, func(data []byte) error {
+			var resp PullResponse
+			if err := json.Unmarshal(data, &resp); err != nil {
+				return err
+			}
+			delivered = true
+			if resp.Digest != "" && resp.Completed > completed[resp.Digest] {
+				completed[resp.Digest] = resp.Completed
+				advanced = true
+			}
+			if resp.Status == "success" {
+				success = true
+			}
+			select {
+			case out <- resp:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err == nil && !success {
+			err = fmt.Errorf("pull stream ended before completion: %w", io.ErrUnexpectedEOF)
+		}
+		if err == nil {
+			break
+		}
+
+		// Requests that failed before streaming anything were already
+		// retried by streamRequest. Attempts that downloaded something reset
+		// the budget, so a long pull over a flaky connection keeps going.
+		if advanced {
+			failures = 0
+		}
+		failures++
+		if !delivered || !c.resumable(ctx, policy, err) || failures > policy.MaxRetries {
+			return err
+		}
+
+		if sleepErr := sleepContext(ctx, policy.delay(failures-1, nil)); sleepErr != nil {
+			return sleepErr
+		}
+		select {
+		case out <- PullResponse{Status: PullStatusResuming}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	model, err := FindModel(ctx, c, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to verify pull: %w", err)
+	}
+	if model == nil {
+		return fmt.Errorf("%w: pull of %s finished but the model is not installed", ErrModelNotFound, req.Name)
+	}
+
+	select {
+	case out <- PullResponse{Status: PullStatusVerified, Digest: model.Digest}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// resumable reports whether a failed pull is worth starting again. Errors
+// the server reports about the pull itself, such as an unknown model, are not.
+func (c *Client) resumable(ctx context.Context, policy RetryPolicy, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCassetteMiss) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 && policy.retryStatus(apiErr.StatusCode)
+	}
+	return true
+}
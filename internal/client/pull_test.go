@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ollamacli/pkg/ollamatest"
+)
+
+func collectPull(t *testing.T, c *Client, name string) ([]PullResponse, error) {
+	t.Helper()
+	progress, err := c.PullModel(context.Background(), PullRequest{Name: name})
+	if err != nil {
+		t.Fatalf("PullModel failed: %v", err)
+	}
+	var updates []PullResponse
+	for resp := range progress {
+		if resp.Err != nil {
+			return updates, resp.Err
+		}
+		updates = append(updates, resp)
+	}
+	return updates, nil
+}
+
+func TestPullResumesDroppedStream(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{})
+	srv.Inject(ollamatest.Fault{Path: "/api/pull", MidStream: true, AfterChunks: 3, Drop: true, Times: 1})
+
+	c := New(Options{BaseURL: srv.URL, Retries: 2, RetryDelay: time.Millisecond})
+	updates, err := collectPull(t, c, "llama2")
+	if err != nil {
+		t.Fatalf("pull should resume after the dropped stream: %v", err)
+	}
+
+	srv.AssertRequestCount(t, "/api/pull", 2)
+	var resumed bool
+	for _, update := range updates {
+		resumed = resumed || update.Status == PullStatusResuming
+	}
+	if !resumed {
+		t.Error("expected a resuming status between attempts")
+	}
+	last := updates[len(updates)-1]
+	if last.Status != PullStatusVerified || last.Digest == "" {
+		t.Errorf("expected the pull to end verified with a digest, got %+v", last)
+	}
+}
+
+func TestPullGivesUpWithoutProgress(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{})
+	// Every attempt drops before any layer bytes arrive
+	srv.Inject(ollamatest.Fault{Path: "/api/pull", MidStream: true, AfterChunks: 1, Drop: true})
+
+	c := New(Options{BaseURL: srv.URL, Retries: 2, RetryDelay: time.Millisecond})
+	if _, err := collectPull(t, c, "llama2"); err == nil {
+		t.Fatal("expected the pull to fail")
+	}
+	srv.AssertRequestCount(t, "/api/pull", 3)
+}
+
+func TestPullDoesNotResumeServerErrors(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{})
+	srv.Inject(ollamatest.Fault{Path: "/api/pull", MidStream: true, AfterChunks: 2, Message: "pull model manifest: file does not exist"})
+
+	c := New(Options{BaseURL: srv.URL, Retries: 2, RetryDelay: time.Millisecond})
+	_, err := collectPull(t, c, "llama2")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected the in-stream APIError, got %v", err)
+	}
+	srv.AssertRequestCount(t, "/api/pull", 1)
+}
+
+func TestPullVerifiesModelInstalled(t *testing.T) {
+	// A server that reports success without installing anything
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pull":
+			fmt.Fprintln(w, `{"status":"success"}`)
+		case "/api/tags":
+			fmt.Fprintln(w, `{"models":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	c := New(Options{BaseURL: server.URL, Retries: 1, RetryDelay: time.Millisecond})
+	if _, err := collectPull(t, c, "llama2"); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected verification to report ErrModelNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts after the first one
+	MaxRetries int
+	// BaseDelay is the wait before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff and any Retry-After the server asks for
+	MaxDelay time.Duration
+	// Multiplier grows the delay between consecutive retries
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction (0 to 1)
+	Jitter float64
+	// RetryStatuses lists the HTTP status codes worth retrying
+	RetryStatuses []int
+	// RetryNonIdempotent allows repeating requests the server may have
+	// already acted on. Without it such requests are only retried when
+	// they never reached the server or were refused with 429/503.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the policy used when Options sets none
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Second,
+		MaxDelay:   30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// idempotentPaths are POST endpoints that can safely be repeated because
+// they do not change server state (or, for pulls, resume where they left off)
+var idempotentPaths = map[string]bool{
+	"/api/show":       true,
+	"/api/embed":      true,
+	"/api/embeddings": true,
+	"/api/chat":       true,
+	"/api/generate":   true,
+	"/api/pull":       true,
+	// OpenAI-compatible backends
+	"/v1/chat/completions": true,
+	"/v1/completions":      true,
+	"/v1/embeddings":       true,
+}
+
+func isIdempotent(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return idempotentPaths[path]
+}
+
+// policyFor returns the retry policy for an endpoint path, falling back to
+// the client-wide policy
+func (c *Client) policyFor(path string) RetryPolicy {
+	if policy, ok := c.retryPolicies[path]; ok {
+		return policy.withDefaults()
+	}
+	return c.retryPolicy.withDefaults()
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaults.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaults.MaxDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = defaults.Jitter
+	}
+	if p.RetryStatuses == nil {
+		p.RetryStatuses = defaults.RetryStatuses
+	}
+	return p
+}
+
+// shouldRetry decides whether the outcome of an attempt is worth another try
+func (p RetryPolicy) shouldRetry(attempt int, idempotent bool, resp *http.Response, err error) bool {
+	if attempt >= p.MaxRetries {
+		return false
+	}
+
+	if err != nil {
+		// A timeout has already waited out its full limit
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCassetteMiss) || errors.Is(err, ErrTimeout) {
+			return false
+		}
+		return idempotent || p.RetryNonIdempotent || notSent(err)
+	}
+
+	if !p.retryStatus(resp.StatusCode) {
+		return false
+	}
+	if idempotent || p.RetryNonIdempotent {
+		return true
+	}
+	// The server explicitly refused to handle the request
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+func (p RetryPolicy) retryStatus(code int) bool {
+	for _, status := range p.RetryStatuses {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before the retry following attempt,
+// preferring the server's Retry-After header when present
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > p.MaxDelay {
+				return p.MaxDelay
+			}
+			return wait
+		}
+	}
+
+	backoff := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(attempt))
+	if backoff > float64(p.MaxDelay) {
+		backoff = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// parseRetryAfter understands both delay-seconds and HTTP-date values
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		wait := time.Until(when)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// notSent reports whether err happened before the request reached the server
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
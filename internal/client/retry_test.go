@@ -0,0 +1,310 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastRetryClient(url string, retries int) *Client {
+	return New(Options{
+		BaseURL:    url,
+		Retries:    retries,
+		RetryDelay: time.Millisecond,
+	})
+}
+
+func TestRetryResendsRequestBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ShowRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name != "llama2" {
+			t.Errorf("attempt %d: expected full request body, got %+v (err: %v)", atomic.LoadInt32(&attempts)+1, req, err)
+		}
+
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(ShowResponse{License: "MIT"})
+	}))
+	defer server.Close()
+
+	resp, err := fastRetryClient(server.URL, 3).ShowModel(context.Background(), ShowRequest{Name: "llama2"})
+	if err != nil {
+		t.Fatalf("Expected success after retries, got: %v", err)
+	}
+	if resp.License != "MIT" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(ListModelsResponse{})
+	}))
+	defer server.Close()
+
+	// The backoff alone would wait an hour; Retry-After: 0 must win
+	client := New(Options{
+		BaseURL:     server.URL,
+		RetryPolicy: &RetryPolicy{MaxRetries: 1, BaseDelay: time.Hour, MaxDelay: time.Hour},
+	})
+
+	start := time.Now()
+	if _, err := client.ListModels(context.Background()); err != nil {
+		t.Fatalf("Expected success, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected Retry-After to shorten the wait, took %v", elapsed)
+	}
+}
+
+func TestRetryAbortsWhenContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := New(Options{
+		BaseURL:     server.URL,
+		RetryPolicy: &RetryPolicy{MaxRetries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.ListModels(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected retry sleep to stop on cancellation, took %v", elapsed)
+	}
+}
+
+func TestRetrySkipsNonIdempotentServerErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := fastRetryClient(server.URL, 3).CopyModel(context.Background(), CopyRequest{Source: "a", Destination: "b"})
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("Expected a single attempt for POST /api/copy, got %d", got)
+	}
+}
+
+func TestRetryNonIdempotentOnServiceUnavailable(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer server.Close()
+
+	err := fastRetryClient(server.URL, 3).CopyModel(context.Background(), CopyRequest{Source: "a", Destination: "b"})
+	if err != nil {
+		t.Fatalf("Expected success after 503, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestRetryPolicyPerOperation(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := New(Options{
+		BaseURL:    server.URL,
+		RetryDelay: time.Millisecond,
+		RetryPolicies: map[string]RetryPolicy{
+			"/api/version": {MaxRetries: 0},
+		},
+	})
+
+	if _, err := client.Version(context.Background()); err == nil {
+		t.Fatal("Expected error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("Expected per-operation policy to disable retries, got %d attempts", got)
+	}
+}
+
+func TestStreamRetriesBeforeFirstByte(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		encoder := json.NewEncoder(w)
+		encoder.Encode(GenerateResponse{Response: "Hi"})
+		encoder.Encode(GenerateResponse{Response: "!", Done: true})
+	}))
+	defer server.Close()
+
+	respCh, err := fastRetryClient(server.URL, 2).GenerateStream(context.Background(), GenerateRequest{Model: "llama2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	text := ""
+	for resp := range respCh {
+		text += resp.Response
+	}
+	if text != "Hi!" {
+		t.Errorf("Expected 'Hi!', got %q", text)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestStreamDoesNotRetryAfterFirstChunk(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		json.NewEncoder(w).Encode(GenerateResponse{Response: "partial"})
+		w.(http.Flusher).Flush()
+
+		// Drop the connection mid-stream
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	respCh, err := fastRetryClient(server.URL, 3).GenerateStream(context.Background(), GenerateRequest{Model: "llama2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for range respCh {
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("Expected no retry after data was delivered, got %d attempts", got)
+	}
+}
+
+func TestStreamDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'llama2' not found"}`))
+	}))
+	defer server.Close()
+
+	respCh, err := fastRetryClient(server.URL, 3).ChatStream(context.Background(), ChatRequest{Model: "llama2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var streamErr error
+	for resp := range respCh {
+		if resp.Err != nil {
+			streamErr = resp.Err
+		}
+	}
+	var apiErr *APIError
+	if !errors.As(streamErr, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 APIError, got: %v", streamErr)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("Expected exactly 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryOnConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	client := fastRetryClient("http://"+addr, 2)
+	err = client.CopyModel(context.Background(), CopyRequest{Source: "a", Destination: "b"})
+	if err == nil {
+		t.Fatal("Expected connection error")
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Expected the attempts in the error, got: %v", err)
+	}
+	if !notSent(errors.Unwrap(err)) {
+		t.Errorf("Expected dial error to be classified as not sent, got: %v", err)
+	}
+}
+
+func TestRetryErrorReportsAttempts(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		// Drop the connection after the request was sent
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	err := fastRetryClient(server.URL, 3).CopyModel(context.Background(), CopyRequest{Source: "a", Destination: "b"})
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 || strings.Contains(err.Error(), "retries") || strings.Contains(err.Error(), "attempts") {
+		t.Errorf("Expected a single attempt reported as such, got %d attempts: %v", got, err)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2, Jitter: 0}.withDefaults()
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for attempt, want := range expected {
+		if got := policy.delay(attempt, nil); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", attempt, want, got)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"120"}}}
+	if got := policy.delay(0, resp); got != time.Second {
+		t.Errorf("Expected Retry-After to be capped at MaxDelay, got %v", got)
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		if got := policy.delay(0, nil); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("jittered delay out of range: %v", got)
+		}
+	}
+}
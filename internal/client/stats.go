@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Stats are the token counts and timings of a reply, taken from the final
+// chunk of a chat or generate response
+type Stats struct {
+	PromptTokens       int
+	CompletionTokens   int
+	LoadDuration       time.Duration
+	PromptEvalDuration time.Duration
+	EvalDuration       time.Duration
+	TotalDuration      time.Duration
+	// TimeToFirstToken is measured by the caller from sending the request to
+	// the first streamed chunk; zero when unknown
+	TimeToFirstToken time.Duration
+}
+
+// Stats returns the counts and timings of a final chat response
+func (r *ChatResponse) Stats() Stats {
+	return Stats{
+		PromptTokens:       r.PromptEvalCount,
+		CompletionTokens:   r.EvalCount,
+		LoadDuration:       time.Duration(r.LoadDuration),
+		PromptEvalDuration: time.Duration(r.PromptEvalDuration),
+		EvalDuration:       time.Duration(r.EvalDuration),
+		TotalDuration:      time.Duration(r.TotalDuration),
+	}
+}
+
+// Stats returns the counts and timings of a final generate response
+func (r *GenerateResponse) Stats() Stats {
+	return Stats{
+		PromptTokens:       r.PromptEvalCount,
+		CompletionTokens:   r.EvalCount,
+		LoadDuration:       time.Duration(r.LoadDuration),
+		PromptEvalDuration: time.Duration(r.PromptEvalDuration),
+		EvalDuration:       time.Duration(r.EvalDuration),
+		TotalDuration:      time.Duration(r.TotalDuration),
+	}
+}
+
+// TokensPerSecond is the generation speed, or 0 when the server reported no
+// eval duration (OpenAI-compatible backends)
+func (s Stats) TokensPerSecond() float64 {
+	return rate(s.CompletionTokens, s.EvalDuration)
+}
+
+// PromptTokensPerSecond is the prompt processing speed
+func (s Stats) PromptTokensPerSecond() float64 {
+	return rate(s.PromptTokens, s.PromptEvalDuration)
+}
+
+func rate(tokens int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(tokens) / d.Seconds()
+}
+
+// Add accumulates another reply into a running total. Rates of the total
+// are averages weighted by duration.
+func (s *Stats) Add(other Stats) {
+	s.PromptTokens += other.PromptTokens
+	s.CompletionTokens += other.CompletionTokens
+	s.LoadDuration += other.LoadDuration
+	s.PromptEvalDuration += other.PromptEvalDuration
+	s.EvalDuration += other.EvalDuration
+	s.TotalDuration += other.TotalDuration
+	s.TimeToFirstToken += other.TimeToFirstToken
+}
+
+// MarshalJSON writes durations in milliseconds along with the derived rates
+func (s Stats) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	return json.Marshal(struct {
+		PromptTokens          int     `json:"prompt_tokens"`
+		CompletionTokens      int     `json:"completion_tokens"`
+		TokensPerSecond       float64 `json:"tokens_per_second"`
+		PromptTokensPerSecond float64 `json:"prompt_tokens_per_second"`
+		TimeToFirstTokenMs    float64 `json:"time_to_first_token_ms,omitempty"`
+		LoadMs                float64 `json:"load_ms"`
+		PromptEvalMs          float64 `json:"prompt_eval_ms"`
+		EvalMs                float64 `json:"eval_ms"`
+		TotalMs               float64 `json:"total_ms"`
+	}{
+		PromptTokens:          s.PromptTokens,
+		CompletionTokens:      s.CompletionTokens,
+		TokensPerSecond:       s.TokensPerSecond(),
+		PromptTokensPerSecond: s.PromptTokensPerSecond(),
+		TimeToFirstTokenMs:    ms(s.TimeToFirstToken),
+		LoadMs:                ms(s.LoadDuration),
+		PromptEvalMs:          ms(s.PromptEvalDuration),
+		EvalMs:                ms(s.EvalDuration),
+		TotalMs:               ms(s.TotalDuration),
+	})
+}
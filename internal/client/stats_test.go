@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	resp := &ChatResponse{
+		Done:               true,
+		PromptEvalCount:    20,
+		PromptEvalDuration: int64(100 * time.Millisecond),
+		EvalCount:          50,
+		EvalDuration:       int64(2 * time.Second),
+		LoadDuration:       int64(500 * time.Millisecond),
+		TotalDuration:      int64(2600 * time.Millisecond),
+	}
+
+	stats := resp.Stats()
+	if stats.TokensPerSecond() != 25 || stats.PromptTokensPerSecond() != 200 {
+		t.Errorf("unexpected rates %v and %v", stats.TokensPerSecond(), stats.PromptTokensPerSecond())
+	}
+
+	// Totals weight each reply by its duration
+	stats.Add((&GenerateResponse{EvalCount: 50, EvalDuration: int64(time.Second)}).Stats())
+	if stats.CompletionTokens != 100 || stats.TokensPerSecond() != 100.0/3 {
+		t.Errorf("unexpected totals %+v", stats)
+	}
+
+	if (Stats{CompletionTokens: 10}).TokensPerSecond() != 0 {
+		t.Error("expected no rate without an eval duration")
+	}
+
+	stats = resp.Stats()
+	stats.TimeToFirstToken = 320 * time.Millisecond
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded map[string]float64
+	json.Unmarshal(data, &decoded)
+	want := map[string]float64{
+		"prompt_tokens":            20,
+		"completion_tokens":        50,
+		"tokens_per_second":        25,
+		"prompt_tokens_per_second": 200,
+		"time_to_first_token_ms":   320,
+		"load_ms":                  500,
+		"prompt_eval_ms":           100,
+		"eval_ms":                  2000,
+		"total_ms":                 2600,
+	}
+	for key, value := range want {
+		if decoded[key] != value {
+			t.Errorf("%s = %v, want %v (%s)", key, decoded[key], value, data)
+		}
+	}
+}
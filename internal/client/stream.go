@@ -0,0 +1,69 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// frameReader returns the next JSON value of a stream, or io.EOF at its end
+type frameReader func() (json.RawMessage, error)
+
+// jsonFrames reads the newline-delimited JSON that Ollama streams
+func jsonFrames(r io.Reader) frameReader {
+	decoder := json.NewDecoder(r)
+	return func() (json.RawMessage, error) {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		return raw, err
+	}
+}
+
+// maxSSELine bounds a single server-sent event line
+const maxSSELine = 4 * 1024 * 1024
+
+// sseFrames reads the data of server-sent events as used by OpenAI-style
+// APIs. Comments and other fields are skipped and "[DONE]" ends the stream.
+func sseFrames(r io.Reader) frameReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSSELine)
+
+	done := false
+	return func() (json.RawMessage, error) {
+		if done {
+			return nil, io.EOF
+		}
+
+		var data []byte
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				// A blank line ends the event
+				if len(data) > 0 {
+					break
+				}
+				continue
+			}
+
+			value, ok := bytes.CutPrefix(line, []byte("data:"))
+			if !ok {
+				continue
+			}
+			value = bytes.TrimPrefix(value, []byte(" "))
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		if len(data) == 0 || string(bytes.TrimSpace(data)) == "[DONE]" {
+			done = true
+			return nil, io.EOF
+		}
+		return json.RawMessage(data), nil
+	}
+}
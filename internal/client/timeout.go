@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTimeout matches every TimeoutError with errors.Is
+var ErrTimeout = errors.New("timeout")
+
+// TimeoutKind names the limit that fired
+type TimeoutKind string
+
+const (
+	// TimeoutConnect covers dialing the server and the TLS handshake
+	TimeoutConnect TimeoutKind = "connect"
+	// TimeoutFirstToken is the wait for the first chunk of a stream
+	TimeoutFirstToken TimeoutKind = "first-token"
+	// TimeoutIdle is the longest pause allowed between chunks of a stream
+	TimeoutIdle TimeoutKind = "idle"
+	// TimeoutOverall bounds a whole request, body included
+	TimeoutOverall TimeoutKind = "overall"
+)
+
+// TimeoutError is returned when one of the client's time limits expires
+type TimeoutError struct {
+	Kind     TimeoutKind
+	Limit    time.Duration
+	Endpoint string
+}
+
+func (e *TimeoutError) Error() string {
+	var what string
+	switch e.Kind {
+	case TimeoutConnect:
+		what = "could not connect to the server"
+	case TimeoutFirstToken:
+		what = "no output received"
+	case TimeoutIdle:
+		what = "stream stalled between chunks"
+	default:
+		what = "request did not complete"
+	}
+	if e.Limit > 0 {
+		return fmt.Sprintf("%s timeout for %s: %s within %v", e.Kind, e.Endpoint, what, e.Limit)
+	}
+	return fmt.Sprintf("%s timeout for %s: %s", e.Kind, e.Endpoint, what)
+}
+
+// Is matches ErrTimeout, and ErrServerUnavailable for connect timeouts
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout || (target == ErrServerUnavailable && e.Kind == TimeoutConnect)
+}
+
+// newTransport returns a copy of the default transport with the connect
+// timeout applied to both dialing and the TLS handshake
+func newTransport(connect time.Duration) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = connect
+	return transport
+}
+
+// connectTimeout reports whether err is a dial or TLS handshake timeout
+func connectTimeout(err error) bool {
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return false
+	}
+	var opErr *net.OpError
+	return (errors.As(err, &opErr) && opErr.Op == "dial") || strings.Contains(err.Error(), "TLS handshake timeout")
+}
+
+// withTimeout bounds ctx by d, recording which limit fired so timeoutCause
+// can report it. A zero or negative d adds no limit.
+func withTimeout(ctx context.Context, d time.Duration, kind TimeoutKind, endpoint string) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, &TimeoutError{Kind: kind, Limit: d, Endpoint: endpoint})
+}
+
+// timeoutCause replaces err with the TimeoutError that cancelled ctx, if a
+// client limit rather than the caller ended the request
+func timeoutCause(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	var timeout *TimeoutError
+	if errors.As(context.Cause(ctx), &timeout) {
+		return timeout
+	}
+	return err
+}
+
+// watchdog enforces the first-token and idle limits on a streamed body. It
+// cancels the attempt's context with a TimeoutError when either expires.
+type watchdog struct {
+	mu         sync.Mutex
+	timer      *time.Timer
+	cancel     context.CancelCauseFunc
+	idle       time.Duration
+	endpoint   string
+	firstChunk bool
+}
+
+// watch derives the context for one streaming attempt and starts the
+// first-token timer
+func (c *Client) watch(ctx context.Context, endpoint string) (context.Context, *watchdog) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &watchdog{cancel: cancel, idle: c.idleTimeout, endpoint: endpoint}
+	if c.firstTokenTimeout > 0 {
+		limit := c.firstTokenTimeout
+		w.timer = time.AfterFunc(limit, func() {
+			cancel(&TimeoutError{Kind: TimeoutFirstToken, Limit: limit, Endpoint: endpoint})
+		})
+	}
+	return ctx, w
+}
+
+// chunk records that data arrived, replacing the first-token timer with the
+// idle timer or restarting the idle timer
+func (w *watchdog) chunk() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.firstChunk {
+		if w.timer != nil {
+			w.timer.Reset(w.idle)
+		}
+		return
+	}
+
+	w.firstChunk = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.idle > 0 {
+		limit, endpoint, cancel := w.idle, w.endpoint, w.cancel
+		w.timer = time.AfterFunc(limit, func() {
+			cancel(&TimeoutError{Kind: TimeoutIdle, Limit: limit, Endpoint: endpoint})
+		})
+	}
+}
+
+// stop releases the timer and the attempt's context
+func (w *watchdog) stop() {
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	w.cancel(nil)
+}
+
+// body wraps a response body so every read that returns data counts as a chunk
+func (w *watchdog) body(rc io.ReadCloser) io.ReadCloser {
+	return &watchedBody{ReadCloser: rc, w: w}
+}
+
+type watchedBody struct {
+	io.ReadCloser
+	w *watchdog
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.w.chunk()
+	}
+	return n, err
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowStream writes a chunk every interval after an initial delay, stopping
+// after count chunks; a negative count stalls forever after the first chunk
+func slowStream(delay, interval time.Duration, count int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Reading the body lets the server notice the client hanging up
+		io.Copy(io.Discard, r.Body)
+		wait := func(d time.Duration) bool {
+			select {
+			case <-time.After(d):
+				return true
+			case <-r.Context().Done():
+				return false
+			}
+		}
+		if !wait(delay) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 0; count < 0 || i < count; i++ {
+			if i > 0 {
+				d := interval
+				if count < 0 {
+					d = time.Hour
+				}
+				if !wait(d) {
+					return
+				}
+			}
+			done := count >= 0 && i == count-1
+			fmt.Fprintf(w, `{"model":"llama2","response":"%d ","done":%t}`+"\n", i, done)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func collectGenerate(t *testing.T, c *Client) (string, error) {
+	t.Helper()
+	stream, err := c.GenerateStream(context.Background(), GenerateRequest{Model: "llama2", Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+	var text string
+	var streamErr error
+	for resp := range stream {
+		text += resp.Response
+		if resp.Err != nil {
+			streamErr = resp.Err
+		}
+	}
+	return text, streamErr
+}
+
+func TestStreamOutlivesRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(slowStream(0, 30*time.Millisecond, 5))
+	defer server.Close()
+
+	c := New(Options{BaseURL: server.URL, Timeout: 50 * time.Millisecond, IdleTimeout: time.Second})
+	text, err := collectGenerate(t, c)
+	if err != nil {
+		t.Fatalf("stream should not be bound by the request timeout: %v", err)
+	}
+	if text != "0 1 2 3 4 " {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestStreamTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		opts    Options
+		kind    TimeoutKind
+		text    string
+	}{
+		{
+			name:    "first token",
+			handler: slowStream(time.Second, 0, 1),
+			opts:    Options{FirstTokenTimeout: 50 * time.Millisecond},
+			kind:    TimeoutFirstToken,
+		},
+		{
+			name:    "idle",
+			handler: slowStream(0, 0, -1),
+			opts:    Options{FirstTokenTimeout: time.Second, IdleTimeout: 50 * time.Millisecond},
+			kind:    TimeoutIdle,
+			text:    "0 ",
+		},
+		{
+			name:    "overall",
+			handler: slowStream(0, 20*time.Millisecond, 100),
+			opts:    Options{IdleTimeout: time.Second, StreamTimeout: 100 * time.Millisecond},
+			kind:    TimeoutOverall,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			tt.opts.BaseURL = server.URL
+			tt.opts.RetryDelay = time.Millisecond
+			text, err := collectGenerate(t, New(tt.opts))
+
+			var timeout *TimeoutError
+			if !errors.As(err, &timeout) {
+				t.Fatalf("expected a TimeoutError, got %v", err)
+			}
+			if timeout.Kind != tt.kind || timeout.Endpoint != "/api/generate" {
+				t.Errorf("got %s timeout for %s, want %s", timeout.Kind, timeout.Endpoint, tt.kind)
+			}
+			if !errors.Is(err, ErrTimeout) || errors.Is(err, ErrServerUnavailable) {
+				t.Errorf("unexpected sentinel matches for %v", err)
+			}
+			if tt.text != "" && text != tt.text {
+				t.Errorf("text before the timeout = %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutNamesLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(slowStream(time.Second, 0, 1))
+	defer server.Close()
+	counting := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	c := New(Options{BaseURL: server.URL, Timeout: 50 * time.Millisecond, Transport: counting, RetryDelay: time.Millisecond})
+	_, err := c.ShowModel(context.Background(), ShowRequest{Name: "llama2"})
+
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || timeout.Kind != TimeoutOverall || timeout.Limit != 50*time.Millisecond {
+		t.Fatalf("expected an overall TimeoutError, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("timeouts should not be retried, got %d requests", requests)
+	}
+
+	// Cancellation by the caller is not reported as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ShowModel(ctx, ShowRequest{Name: "llama2"}); errors.Is(err, ErrTimeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+type fakeTimeout struct{}
+
+func (fakeTimeout) Error() string   { return "i/o timeout" }
+func (fakeTimeout) Timeout() bool   { return true }
+func (fakeTimeout) Temporary() bool { return true }
+
+func TestConnectTimeoutError(t *testing.T) {
+	dialErr := fmt.Errorf("Post: %w", &net.OpError{Op: "dial", Net: "tcp", Err: fakeTimeout{}})
+	err := transportError("/api/chat", dialErr)
+
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) || timeout.Kind != TimeoutConnect {
+		t.Fatalf("expected a connect TimeoutError, got %v", err)
+	}
+	if !errors.Is(err, ErrServerUnavailable) || !errors.Is(err, ErrTimeout) {
+		t.Errorf("connect timeouts should match ErrServerUnavailable and ErrTimeout")
+	}
+
+	// Reads that time out after connecting are not connect timeouts
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: fakeTimeout{}}
+	if connectTimeout(readErr) {
+		t.Error("read timeout reported as a connect timeout")
+	}
+}
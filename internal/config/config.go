@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"ollamacli/internal/client"
 )
 
 const (
@@ -23,11 +28,80 @@ type Config struct {
 	Verbose  bool   `yaml:"verbose"`
 	Quiet    bool   `yaml:"quiet"`
 
+	// Backend selects the API the servers speak: ollama (default) or openai
+	Backend string `yaml:"backend"`
+
+	// Endpoints lists several servers to spread requests over; when set it
+	// takes precedence over Host and Port
+	Endpoints     []string `yaml:"endpoints"`
+	LoadBalancing string   `yaml:"load_balancing"`
+
+	// Profile names the entry of Profiles to apply on load
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	// Timeouts for requests to the server
+	Timeouts TimeoutsConfig `yaml:"timeouts"`
+
+	// KeepAlive is how long models stay loaded after a request, such as
+	// "10m"; empty leaves it to the server
+	KeepAlive string `yaml:"keep_alive"`
+
+	// Models holds per-model settings keyed by model name
+	Models map[string]ModelConfig `yaml:"models"`
+
 	// RAG configuration
 	RAG RAGConfig `yaml:"rag"`
 
 	// Runtime config
 	ConfigPath string `yaml:"-"`
+
+	// active is the profile applied by UseProfile. It is kept apart from
+	// the fields above so that Save does not write it out as the defaults.
+	active *Profile
+}
+
+// Profile overrides the server settings when selected; empty fields keep
+// the top-level value
+type Profile struct {
+	Backend   string   `yaml:"backend,omitempty"`
+	Host      string   `yaml:"host,omitempty"`
+	Port      int      `yaml:"port,omitempty"`
+	Endpoints []string `yaml:"endpoints,omitempty"`
+	Token     string   `yaml:"token,omitempty"`
+}
+
+// ModelConfig overrides settings for one model; empty fields keep the
+// top-level value
+type ModelConfig struct {
+	KeepAlive string `yaml:"keep_alive,omitempty"`
+}
+
+// TimeoutsConfig holds the client time limits, written as durations such
+// as "10s" or "5m". Zero disables a limit, except that a zero Request limit
+// falls back to the client default of 30s.
+type TimeoutsConfig struct {
+	// Connect covers dialing the server and the TLS handshake
+	Connect time.Duration `yaml:"connect"`
+	// FirstToken is the wait for the first chunk of a streamed reply,
+	// including the time to load the model
+	FirstToken time.Duration `yaml:"first_token"`
+	// Idle is the longest pause allowed between streamed chunks
+	Idle time.Duration `yaml:"idle"`
+	// Request is the overall deadline of a non-streaming request
+	Request time.Duration `yaml:"request"`
+	// Stream is the overall deadline of a streamed reply or download
+	Stream time.Duration `yaml:"stream"`
+}
+
+// DefaultTimeouts returns the limits used when the config file sets none
+func DefaultTimeouts() TimeoutsConfig {
+	return TimeoutsConfig{
+		Connect:    10 * time.Second,
+		FirstToken: 5 * time.Minute,
+		Idle:       2 * time.Minute,
+		Request:    30 * time.Second,
+	}
 }
 
 type RAGConfig struct {
@@ -45,6 +119,7 @@ func Load() (*Config, error) {
 		LogLevel: DefaultLogLevel,
 		Verbose:  false,
 		Quiet:    false,
+		Timeouts: DefaultTimeouts(),
 		RAG: RAGConfig{
 			EmbedModel:   "mxbai-embed-large",
 			ChunkSize:    500,
@@ -59,6 +134,19 @@ func Load() (*Config, error) {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
 		}
 	}
+	if err := cfg.validateKeepAlive(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", cfg.ConfigPath, err)
+	}
+
+	profile := cfg.Profile
+	if env := os.Getenv("OLLAMA_PROFILE"); env != "" {
+		profile = env
+	}
+	if profile != "" {
+		if err := cfg.UseProfile(profile); err != nil {
+			return nil, err
+		}
+	}
 
 	// Override with environment variables
 	cfg.loadFromEnv()
@@ -66,6 +154,50 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// UseProfile applies the named profile on top of the current settings. The
+// profile is seen through GetServerURL, GetEndpoints, GetBackend and
+// GetToken; the top-level fields keep the values that Save writes.
+func (c *Config) UseProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	profile.Endpoints = append([]string(nil), profile.Endpoints...)
+	c.active = &profile
+	return nil
+}
+
+// server returns the connection settings in effect: the top-level fields
+// with the active profile applied over them
+func (c *Config) server() Profile {
+	s := Profile{Backend: c.Backend, Host: c.Host, Port: c.Port, Endpoints: c.Endpoints, Token: c.Token}
+	p := c.active
+	if p == nil {
+		return s
+	}
+
+	if p.Backend != "" {
+		s.Backend = p.Backend
+	}
+	if p.Host != "" {
+		s.Host = p.Host
+	}
+	if p.Port != 0 {
+		s.Port = p.Port
+	}
+	if len(p.Endpoints) > 0 {
+		s.Endpoints = p.Endpoints
+	} else if p.Host != "" || p.Port != 0 {
+		// A profile naming a single server replaces any top-level pool
+		s.Endpoints = nil
+	}
+	if p.Token != "" {
+		s.Token = p.Token
+	}
+	return s
+}
+
 func (c *Config) loadFromFile() error {
 	configPath := c.getConfigPath()
 	c.ConfigPath = configPath
@@ -79,18 +211,44 @@ func (c *Config) loadFromFile() error {
 }
 
 func (c *Config) loadFromEnv() {
+	// Environment variables win over the active profile
+	active := c.active
+	if active == nil {
+		active = &Profile{}
+	}
+
 	if host := os.Getenv("OLLAMA_HOST"); host != "" {
 		c.Host = host
+		active.Host = ""
 	}
 
 	if portStr := os.Getenv("OLLAMA_PORT"); portStr != "" {
 		if port, err := strconv.Atoi(portStr); err == nil {
 			c.Port = port
+			active.Port = 0
+		}
+	}
+
+	if endpoints := os.Getenv("OLLAMA_ENDPOINTS"); endpoints != "" {
+		// A profile's host or port would otherwise replace the pool
+		active.Endpoints = nil
+		active.Host, active.Port = "", 0
+		c.Endpoints = nil
+		for _, endpoint := range strings.Split(endpoints, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				c.Endpoints = append(c.Endpoints, endpoint)
+			}
 		}
 	}
 
+	if backend := os.Getenv("OLLAMA_BACKEND"); backend != "" {
+		c.Backend = backend
+		active.Backend = ""
+	}
+
 	if token := os.Getenv("OLLAMA_TOKEN"); token != "" {
 		c.Token = token
+		active.Token = ""
 	}
 
 	if logLevel := os.Getenv("OLLAMA_LOG_LEVEL"); logLevel != "" {
@@ -147,6 +305,44 @@ func (c *Config) generateConfigWithComments() string {
 		allowedFilesYAML += "  "
 	}
 
+	endpointsYAML := "[]"
+	if len(c.Endpoints) > 0 {
+		endpointsYAML = "\n"
+		for _, endpoint := range c.Endpoints {
+			endpointsYAML += fmt.Sprintf("  - %s\n", endpoint)
+		}
+	}
+
+	backend := c.Backend
+	if backend == "" {
+		backend = "ollama"
+	}
+
+	profilesYAML := "{}"
+	if len(c.Profiles) > 0 {
+		if data, err := yaml.Marshal(c.Profiles); err == nil {
+			profilesYAML = "\n"
+			for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+				profilesYAML += "  " + line + "\n"
+			}
+		}
+	}
+
+	modelsYAML := "{}"
+	if len(c.Models) > 0 {
+		if data, err := yaml.Marshal(c.Models); err == nil {
+			modelsYAML = "\n"
+			for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+				modelsYAML += "  " + line + "\n"
+			}
+		}
+	}
+
+	loadBalancing := c.LoadBalancing
+	if loadBalancing == "" {
+		loadBalancing = "round-robin"
+	}
+
 	return fmt.Sprintf(`# Ollama Server Configuration
 # The hostname or IP address of the Ollama server
 host: %s
@@ -154,9 +350,32 @@ host: %s
 # The port number of the Ollama server (default: 11434)
 port: %d
 
+# API spoken by the server: ollama, or openai for OpenAI-compatible servers
+# such as llama.cpp's server or vLLM
+backend: %s
+
+# Additional Ollama servers to spread requests over (optional)
+# When set, host and port are ignored. Example: ["http://gpu1:11434", "gpu2:11434"]
+endpoints: %s
+
+# How to pick among healthy endpoints: round-robin or least-inflight
+load_balancing: %s
+
 # Authentication token for the Ollama server (leave empty if not required)
 token: "%s"
 
+# Named server profiles; each may set backend, host, port, endpoints and token
+# Example:
+#   profiles:
+#     vllm:
+#       backend: openai
+#       host: gpu1
+#       port: 8000
+profiles: %s
+
+# Profile to apply on startup (override with OLLAMA_PROFILE)
+profile: "%s"
+
 # Logging level: debug, info, warn, error (default: info)
 log_level: %s
 
@@ -166,6 +385,30 @@ verbose: %t
 # Enable quiet mode to suppress non-essential output
 quiet: %t
 
+# Time limits for requests, as durations like 10s or 5m; 0s disables a limit
+timeouts:
+  # Connecting to the server, including the TLS handshake
+  connect: %s
+  # Waiting for the first chunk of a streamed reply (includes model loading)
+  first_token: %s
+  # Longest pause allowed between streamed chunks
+  idle: %s
+  # Overall deadline of a non-streaming request (0s uses the default of 30s)
+  request: %s
+  # Overall deadline of a streamed reply or download
+  stream: %s
+
+# How long models stay loaded after a request, e.g. 10m or 1h; 0 unloads
+# them right away and -1 keeps them loaded. Empty uses the server default (5m)
+keep_alive: "%s"
+
+# Per-model settings, keyed by model name
+# Example:
+#   models:
+#     llama3:
+#       keep_alive: 1h
+models: %s
+
 # RAG (Retrieval Augmented Generation) Configuration
 rag:
   # Path to the SQLite vector database for knowledge base storage
@@ -190,10 +433,22 @@ rag:
 `,
 		c.Host,
 		c.Port,
+		backend,
+		endpointsYAML,
+		loadBalancing,
 		c.Token,
+		profilesYAML,
+		c.Profile,
 		c.LogLevel,
 		c.Verbose,
 		c.Quiet,
+		c.Timeouts.Connect,
+		c.Timeouts.FirstToken,
+		c.Timeouts.Idle,
+		c.Timeouts.Request,
+		c.Timeouts.Stream,
+		c.KeepAlive,
+		modelsYAML,
 		c.RAG.KnowledgeBase,
 		c.RAG.EmbedModel,
 		c.RAG.ChunkSize,
@@ -203,11 +458,67 @@ rag:
 }
 
 func (c *Config) GetServerURL() string {
-	return fmt.Sprintf("http://%s:%d", c.Host, c.Port)
+	s := c.server()
+	return fmt.Sprintf("http://%s:%d", s.Host, s.Port)
+}
+
+// GetEndpoints returns the servers to use: the endpoints list if configured,
+// otherwise the single server from Host and Port
+func (c *Config) GetEndpoints() []string {
+	if s := c.server(); len(s.Endpoints) > 0 {
+		return s.Endpoints
+	}
+	return []string{c.GetServerURL()}
+}
+
+// GetBackend returns the configured backend, defaulting to ollama
+func (c *Config) GetBackend() string {
+	if s := c.server(); s.Backend != "" {
+		return s.Backend
+	}
+	return "ollama"
+}
+
+// GetToken returns the token to authenticate with, from the active profile
+// if it sets one
+func (c *Config) GetToken() string {
+	return c.server().Token
+}
+
+// validateKeepAlive checks the keep_alive settings, which would otherwise
+// be sent with every request and make the server reject it
+func (c *Config) validateKeepAlive() error {
+	if _, err := client.ParseKeepAlive(c.KeepAlive); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Models))
+	for name := range c.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := client.ParseKeepAlive(c.Models[name].KeepAlive); err != nil {
+			return fmt.Errorf("model %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// KeepAliveFor returns the keep-alive to use for a model: its entry in
+// Models if it sets one, otherwise the top-level KeepAlive. "llama3" and
+// "llama3:latest" refer to the same entry.
+func (c *Config) KeepAliveFor(model string) string {
+	for _, name := range []string{model, strings.TrimSuffix(model, ":latest"), model + ":latest"} {
+		if m, ok := c.Models[name]; ok && m.KeepAlive != "" {
+			return m.KeepAlive
+		}
+	}
+	return c.KeepAlive
 }
 
 func (c *Config) HasToken() bool {
-	return c.Token != ""
+	return c.GetToken() != ""
 }
 
 func (c *Config) GetKnowledgeBasePath() string {
@@ -221,4 +532,24 @@ func (c *Config) GetKnowledgeBasePath() string {
 	}
 
 	return filepath.Join(homeDir, ".ollamacli", "knowledge.db")
+}
+
+// GetSessionsPath returns the SQLite database that stores chat sessions
+func (c *Config) GetSessionsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".ollamacli/sessions.db"
+	}
+
+	return filepath.Join(homeDir, ".ollamacli", "sessions.db")
+}
+
+// GetPersonasDir returns the directory holding persona definitions (*.yaml)
+func (c *Config) GetPersonasDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".ollamacli/personas"
+	}
+
+	return filepath.Join(homeDir, ".ollamacli", "personas")
 }
\ No newline at end of file
@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfigLoad(t *testing.T) {
@@ -121,4 +123,217 @@ func TestConfigSave(t *testing.T) {
 	if cfg2.Host != cfg.Host {
 		t.Errorf("Expected host %s, got %s", cfg.Host, cfg2.Host)
 	}
-}
\ No newline at end of file
+}
+func TestConfigEndpoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	os.Setenv("OLLAMA_CONFIG_PATH", configPath)
+	defer os.Unsetenv("OLLAMA_CONFIG_PATH")
+
+	cfg := &Config{
+		Host:          "localhost",
+		Port:          11434,
+		Endpoints:     []string{"http://gpu1:11434", "gpu2:11434"},
+		LoadBalancing: "least-inflight",
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Expected no error saving config, got: %v", err)
+	}
+
+	cfg2 := &Config{}
+	if err := cfg2.loadFromFile(); err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	if len(cfg2.Endpoints) != 2 || cfg2.Endpoints[1] != "gpu2:11434" {
+		t.Errorf("Expected endpoints to round-trip, got %v", cfg2.Endpoints)
+	}
+	if cfg2.LoadBalancing != "least-inflight" {
+		t.Errorf("Expected load_balancing least-inflight, got %q", cfg2.LoadBalancing)
+	}
+	if got := cfg2.GetEndpoints(); len(got) != 2 {
+		t.Errorf("Expected GetEndpoints to return the list, got %v", got)
+	}
+
+	os.Setenv("OLLAMA_ENDPOINTS", "a:1, b:2")
+	defer os.Unsetenv("OLLAMA_ENDPOINTS")
+	cfg2.loadFromEnv()
+	if len(cfg2.Endpoints) != 2 || cfg2.Endpoints[0] != "a:1" || cfg2.Endpoints[1] != "b:2" {
+		t.Errorf("Expected OLLAMA_ENDPOINTS to replace the list, got %v", cfg2.Endpoints)
+	}
+
+	single := &Config{Host: "localhost", Port: 11434}
+	if got := single.GetEndpoints(); len(got) != 1 || got[0] != "http://localhost:11434" {
+		t.Errorf("Expected single server fallback, got %v", got)
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	os.Setenv("OLLAMA_CONFIG_PATH", configPath)
+	defer os.Unsetenv("OLLAMA_CONFIG_PATH")
+
+	cfg := &Config{
+		Host:      "localhost",
+		Port:      11434,
+		Endpoints: []string{"gpu1:11434", "gpu2:11434"},
+		Profiles: map[string]Profile{
+			"vllm": {Backend: "openai", Host: "gpu3", Port: 8000, Token: "sk-test"},
+		},
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Expected no error saving config, got: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	if loaded.GetBackend() != "ollama" {
+		t.Errorf("Expected default backend ollama, got %q", loaded.GetBackend())
+	}
+	if len(loaded.Profiles) != 1 {
+		t.Fatalf("Expected profiles to round-trip, got %v", loaded.Profiles)
+	}
+
+	os.Setenv("OLLAMA_PROFILE", "vllm")
+	defer os.Unsetenv("OLLAMA_PROFILE")
+	loaded, err = Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	if loaded.GetBackend() != "openai" || loaded.GetToken() != "sk-test" {
+		t.Errorf("Expected vllm profile to apply, got backend %q token %q", loaded.GetBackend(), loaded.GetToken())
+	}
+	if got := loaded.GetEndpoints(); len(got) != 1 || got[0] != "http://gpu3:8000" {
+		t.Errorf("Expected the profile server to replace the pool, got %v", got)
+	}
+
+	// Saving with a profile active keeps the top-level settings
+	if err := loaded.Save(); err != nil {
+		t.Fatalf("Expected no error saving config, got: %v", err)
+	}
+	os.Unsetenv("OLLAMA_PROFILE")
+	saved, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	if saved.Host != "localhost" || saved.Token != "" || saved.Profile != "" || len(saved.Endpoints) != 2 || saved.GetBackend() != "ollama" {
+		t.Errorf("Expected the profile not to be saved as the defaults, got %+v", saved)
+	}
+
+	// Environment variables win over the profile
+	os.Setenv("OLLAMA_PROFILE", "vllm")
+	os.Setenv("OLLAMA_HOST", "gpu9")
+	defer os.Unsetenv("OLLAMA_HOST")
+	loaded, err = Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	if got := loaded.GetServerURL(); got != "http://gpu9:8000" {
+		t.Errorf("Expected OLLAMA_HOST with the profile port, got %s", got)
+	}
+
+	// An endpoint pool from the environment is not replaced by the
+	// profile's host and port
+	os.Unsetenv("OLLAMA_HOST")
+	os.Setenv("OLLAMA_ENDPOINTS", "a:1,b:2")
+	defer os.Unsetenv("OLLAMA_ENDPOINTS")
+	loaded, err = Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	if got := loaded.GetEndpoints(); len(got) != 2 || got[0] != "a:1" {
+		t.Errorf("Expected OLLAMA_ENDPOINTS over the profile server, got %v", got)
+	}
+	if loaded.GetBackend() != "openai" {
+		t.Errorf("Expected the rest of the profile to apply, got backend %q", loaded.GetBackend())
+	}
+
+	if err := loaded.UseProfile("missing"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+}
+
+func TestConfigTimeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	os.Setenv("OLLAMA_CONFIG_PATH", configPath)
+	defer os.Unsetenv("OLLAMA_CONFIG_PATH")
+
+	// Missing settings keep their defaults
+	os.WriteFile(configPath, []byte("timeouts:\n  first_token: 10m\n  stream: 1h\n"), 0600)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	want := DefaultTimeouts()
+	want.FirstToken = 10 * time.Minute
+	want.Stream = time.Hour
+	if cfg.Timeouts != want {
+		t.Errorf("Expected timeouts %+v, got %+v", want, cfg.Timeouts)
+	}
+
+	cfg.Timeouts.Idle = 0
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Expected no error saving config, got: %v", err)
+	}
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	want.Idle = 0
+	if reloaded.Timeouts != want {
+		t.Errorf("Expected timeouts to round-trip as %+v, got %+v", want, reloaded.Timeouts)
+	}
+}
+
+func TestConfigKeepAlive(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	os.Setenv("OLLAMA_CONFIG_PATH", configPath)
+	defer os.Unsetenv("OLLAMA_CONFIG_PATH")
+
+	cfg := &Config{
+		Host:      "localhost",
+		Port:      11434,
+		KeepAlive: "10m",
+		Models: map[string]ModelConfig{
+			"llama3":         {KeepAlive: "1h"},
+			"mistral:latest": {KeepAlive: "-1"},
+			"phi3":           {},
+		},
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Expected no error saving config, got: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error loading config, got: %v", err)
+	}
+	tests := map[string]string{
+		"llama3":        "1h",
+		"llama3:latest": "1h",
+		"mistral":       "-1",
+		"phi3":          "10m",
+		"gemma":         "10m",
+	}
+	for model, want := range tests {
+		if got := loaded.KeepAliveFor(model); got != want {
+			t.Errorf("KeepAliveFor(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	// Values the server would reject fail when the config is loaded
+	for _, data := range []string{"keep_alive: 1 hour\n", "models:\n  llama3:\n    keep_alive: NaN\n"} {
+		os.WriteFile(configPath, []byte(data), 0600)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "invalid keep-alive") {
+			t.Errorf("Expected an invalid keep-alive error for %q, got %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/schema"
+)
+
+// Process exit codes documented in docs/design.md. Automation can rely on
+// these to tell failure classes apart.
+const (
+	OK                = 0
+	General           = 1
+	Usage             = 2
+	ServerUnavailable = 3
+	Unauthorized      = 4
+	ModelNotFound     = 5
+	SchemaMismatch    = 6
+	Interrupted       = 130
+)
+
+// ErrUsage marks errors caused by invalid command-line input
+var ErrUsage = errors.New("usage error")
+
+// FromError maps an error to the exit code the CLI should terminate with
+func FromError(err error) int {
+	switch {
+	case err == nil:
+		return OK
+	case errors.Is(err, ErrUsage):
+		return Usage
+	case errors.Is(err, context.Canceled):
+		return Interrupted
+	case errors.Is(err, client.ErrUnauthorized):
+		return Unauthorized
+	case errors.Is(err, client.ErrModelNotFound):
+		return ModelNotFound
+	case errors.Is(err, client.ErrServerUnavailable):
+		return ServerUnavailable
+	case errors.Is(err, schema.ErrMismatch):
+		return SchemaMismatch
+	default:
+		return General
+	}
+}
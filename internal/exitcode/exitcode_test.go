@@ -0,0 +1,62 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+	"ollamacli/internal/schema"
+)
+
+func TestFromError(t *testing.T) {
+	mismatch := &schema.ValidationError{Violations: []schema.Violation{{Path: "$", Message: "bad"}}}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, OK},
+		{"generic", errors.New("boom"), General},
+		{"usage", fmt.Errorf("missing model: %w", ErrUsage), Usage},
+		{"cancelled", fmt.Errorf("chat: %w", context.Canceled), Interrupted},
+		{"model missing", &client.APIError{StatusCode: http.StatusNotFound, Message: "model 'x' not found"}, ModelNotFound},
+		{"unauthorized", &client.APIError{StatusCode: http.StatusUnauthorized}, Unauthorized},
+		{"server down", &client.ConnectionError{Endpoint: "/api/tags", Err: errors.New("connection refused")}, ServerUnavailable},
+		{"bad gateway", &client.APIError{StatusCode: http.StatusBadGateway}, ServerUnavailable},
+		{"internal error", &client.APIError{StatusCode: http.StatusInternalServerError}, General},
+		{"schema", fmt.Errorf("reply still invalid: %w", mismatch), SchemaMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromError(tt.err); got != tt.want {
+				t.Errorf("FromError(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model \"nope\" not found, try pulling it first"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := client.New(client.Options{BaseURL: server.URL, RetryDelay: time.Millisecond})
+	_, err := c.ShowModel(context.Background(), client.ShowRequest{Name: "nope"})
+	if got := FromError(err); got != ModelNotFound {
+		t.Errorf("Expected exit code %d for missing model, got %d (%v)", ModelNotFound, got, err)
+	}
+
+	server.Close()
+	_, err = c.ListModels(context.Background())
+	if got := FromError(err); got != ServerUnavailable {
+		t.Errorf("Expected exit code %d for unreachable server, got %d (%v)", ServerUnavailable, got, err)
+	}
+}
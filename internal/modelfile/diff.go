@@ -0,0 +1,102 @@
+package modelfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind tells how a directive differs between two Modelfiles
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "+"
+	Removed ChangeKind = "-"
+	Changed ChangeKind = "~"
+)
+
+// Change is one directive that differs. Key is the instruction, followed by
+// the parameter name for PARAMETER. Repeatable directives such as stop
+// parameters or MESSAGE lines are compared as a whole and their values
+// joined with newlines.
+type Change struct {
+	Kind ChangeKind
+	Key  string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s %s", c.Key, c.New)
+	case Removed:
+		return fmt.Sprintf("- %s %s", c.Key, c.Old)
+	}
+	if !strings.Contains(c.Old+c.New, "\n") {
+		return fmt.Sprintf("~ %s: %s -> %s", c.Key, c.Old, c.New)
+	}
+	return fmt.Sprintf("~ %s\n%s\n%s", c.Key, indent("- ", c.Old), indent("+ ", c.New))
+}
+
+func indent(prefix, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "  " + prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff compares two Modelfiles directive by directive, in the order the keys
+// first appear in a and then b. Surrounding whitespace in values is ignored.
+func Diff(a, b *Modelfile) []Change {
+	oldValues, order := group(a, nil)
+	newValues, order := group(b, order)
+
+	var changes []Change
+	for _, key := range order {
+		oldValue, inOld := oldValues[key]
+		newValue, inNew := newValues[key]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Kind: Added, Key: key, New: newValue})
+		case !inNew:
+			changes = append(changes, Change{Kind: Removed, Key: key, Old: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, Change{Kind: Changed, Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// group collects the values of each key, appending keys not yet in order
+func group(m *Modelfile, order []string) (map[string]string, []string) {
+	values := make(map[string]string)
+	known := make(map[string]bool, len(order))
+	for _, key := range order {
+		known[key] = true
+	}
+
+	for _, d := range m.Directives {
+		key := string(d.Command)
+		value := strings.TrimSpace(d.Value)
+		switch d.Command {
+		case Parameter:
+			key += " " + d.Name
+		case Message:
+			value = d.Name + ": " + value
+		}
+
+		if !known[key] {
+			known[key] = true
+			order = append(order, key)
+		}
+		// FROM, TEMPLATE and SYSTEM keep the last value, as when Ollama
+		// builds the model; other directives accumulate
+		previous, ok := values[key]
+		if ok && d.Command != From && d.Command != Template && d.Command != System {
+			value = previous + "\n" + value
+		}
+		values[key] = value
+	}
+	return values, order
+}
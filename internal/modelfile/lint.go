@@ -0,0 +1,195 @@
+package modelfile
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Severity ranks lint issues
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found by Lint
+type Issue struct {
+	Line     int
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", i.Line, i.Severity, i.Message)
+}
+
+type paramKind int
+
+const (
+	paramInt paramKind = iota
+	paramFloat
+	paramBool
+	paramString
+)
+
+// paramSpec describes a parameter Ollama accepts and its valid range
+type paramSpec struct {
+	kind     paramKind
+	min, max float64
+	repeat   bool
+}
+
+var (
+	anyInt      = paramSpec{kind: paramInt, min: -1e18, max: 1e18}
+	positiveInt = paramSpec{kind: paramInt, min: 1, max: 1e18}
+	anyFloat    = paramSpec{kind: paramFloat, min: -1e18, max: 1e18}
+	unitFloat   = paramSpec{kind: paramFloat, min: 0, max: 1}
+)
+
+var parameters = map[string]paramSpec{
+	"mirostat":          {kind: paramInt, min: 0, max: 2},
+	"mirostat_eta":      anyFloat,
+	"mirostat_tau":      anyFloat,
+	"num_ctx":           positiveInt,
+	"num_batch":         positiveInt,
+	"num_gpu":           anyInt,
+	"main_gpu":          anyInt,
+	"num_thread":        anyInt,
+	"num_keep":          anyInt,
+	"num_predict":       anyInt,
+	"repeat_last_n":     anyInt,
+	"repeat_penalty":    anyFloat,
+	"presence_penalty":  anyFloat,
+	"frequency_penalty": anyFloat,
+	"temperature":       {kind: paramFloat, min: 0, max: 1e18},
+	"seed":              anyInt,
+	"stop":              {kind: paramString, repeat: true},
+	"top_k":             {kind: paramInt, min: 0, max: 1e18},
+	"top_p":             unitFloat,
+	"min_p":             unitFloat,
+	"typical_p":         unitFloat,
+	"tfs_z":             anyFloat,
+	"use_mmap":          {kind: paramBool},
+	"use_mlock":         {kind: paramBool},
+	"numa":              {kind: paramBool},
+	"penalize_newline":  {kind: paramBool},
+}
+
+// templateFuncs are the functions Ollama makes available to templates; only
+// their names matter for parsing
+var templateFuncs = template.FuncMap{
+	"json":             func(interface{}) string { return "" },
+	"currentDate":      func() string { return "" },
+	"yesterdayDate":    func() string { return "" },
+	"toTypeScriptType": func(interface{}) string { return "" },
+}
+
+var roles = map[string]bool{"system": true, "user": true, "assistant": true}
+
+// Lint checks a Modelfile for mistakes Ollama would reject or silently
+// ignore: a missing base model, unknown parameters, values of the wrong type
+// or out of range, invalid MESSAGE roles and templates that do not parse.
+func Lint(m *Modelfile) []Issue {
+	var issues []Issue
+	report := func(line int, severity Severity, format string, args ...interface{}) {
+		issues = append(issues, Issue{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]int)
+	for _, d := range m.Directives {
+		key := string(d.Command)
+		if d.Command == Parameter {
+			key += " " + d.Name
+		}
+
+		switch d.Command {
+		case From, Template, System:
+			if first, ok := seen[key]; ok {
+				report(d.Line, SeverityWarning, "%s repeated (first on line %d); only the last one is used", d.Command, first)
+			}
+		case Parameter:
+			spec, ok := parameters[d.Name]
+			if !ok {
+				report(d.Line, SeverityError, "unknown parameter %q", d.Name)
+				break
+			}
+			if first, ok := seen[key]; ok && !spec.repeat {
+				report(d.Line, SeverityWarning, "parameter %s repeated (first on line %d)", d.Name, first)
+			}
+			if msg := spec.check(d.Value); msg != "" {
+				report(d.Line, SeverityError, "parameter %s: %s", d.Name, msg)
+			}
+		case Message:
+			if !roles[strings.ToLower(d.Name)] {
+				report(d.Line, SeverityError, "invalid MESSAGE role %q (want system, user or assistant)", d.Name)
+			}
+		}
+		if d.Command == Template {
+			if _, err := template.New("").Funcs(templateFuncs).Parse(d.Value); err != nil {
+				report(d.Line, SeverityError, "invalid TEMPLATE: %v", err)
+			}
+		}
+		if _, ok := seen[key]; !ok {
+			seen[key] = d.Line
+		}
+	}
+
+	if _, ok := seen[string(From)]; !ok {
+		report(0, SeverityError, "missing FROM")
+	}
+	return issues
+}
+
+// HasErrors reports whether any issue is an error rather than a warning
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns why a value is invalid, or "" when it is fine
+func (s paramSpec) check(value string) string {
+	var n float64
+	switch s.kind {
+	case paramInt:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("%q is not an integer", value)
+		}
+		n = float64(i)
+	case paramFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		// NaN and infinities parse but cannot be sent as JSON
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+		n = f
+	case paramBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("%q is not true or false", value)
+		}
+		return ""
+	default:
+		return ""
+	}
+
+	if n < s.min || n > s.max {
+		switch {
+		case s.max >= 1e18:
+			return fmt.Sprintf("%s must be at least %g", value, s.min)
+		default:
+			return fmt.Sprintf("%s must be between %g and %g", value, s.min, s.max)
+		}
+	}
+	return ""
+}
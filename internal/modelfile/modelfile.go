@@ -0,0 +1,225 @@
+package modelfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Command is a Modelfile instruction
+type Command string
+
+const (
+	From      Command = "FROM"
+	Parameter Command = "PARAMETER"
+	Template  Command = "TEMPLATE"
+	System    Command = "SYSTEM"
+	Adapter   Command = "ADAPTER"
+	License   Command = "LICENSE"
+	Message   Command = "MESSAGE"
+)
+
+var commands = map[string]Command{
+	"FROM":      From,
+	"PARAMETER": Parameter,
+	"TEMPLATE":  Template,
+	"SYSTEM":    System,
+	"ADAPTER":   Adapter,
+	"LICENSE":   License,
+	"MESSAGE":   Message,
+}
+
+// Directive is one instruction of a Modelfile
+type Directive struct {
+	Command Command
+	// Name is the parameter name for PARAMETER and the role for MESSAGE
+	Name  string
+	Value string
+	// Line is where the directive starts, or 0 when it was not parsed
+	Line int
+}
+
+// Modelfile is a parsed Modelfile. Directives keep their original order;
+// comments and blank lines are not kept.
+type Modelfile struct {
+	Directives []Directive
+}
+
+// ParseError reports a line that is not a valid directive
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Parse reads a Modelfile. Instructions are case-insensitive, lines starting
+// with # are comments and values may be wrapped in "..." or span several
+// lines inside """...""".
+func Parse(r io.Reader) (*Modelfile, error) {
+	m := &Modelfile{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		word, rest := cut(line)
+		cmd, ok := commands[strings.ToUpper(word)]
+		if !ok {
+			return nil, &ParseError{Line: lineNo, Message: fmt.Sprintf("unknown instruction %q", word)}
+		}
+
+		d := Directive{Command: cmd, Line: lineNo}
+		if cmd == Parameter || cmd == Message {
+			d.Name, rest = cut(rest)
+			if cmd == Parameter {
+				d.Name = strings.ToLower(d.Name)
+			}
+		}
+		if rest == "" {
+			return nil, &ParseError{Line: lineNo, Message: fmt.Sprintf("missing value for %s", cmd)}
+		}
+
+		value, err := readValue(rest, scanner, &lineNo)
+		if err != nil {
+			return nil, &ParseError{Line: d.Line, Message: err.Error()}
+		}
+		d.Value = value
+		m.Directives = append(m.Directives, d)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Modelfile: %w", err)
+	}
+
+	return m, nil
+}
+
+// ParseFile reads and parses a Modelfile from disk
+func ParseFile(path string) (*Modelfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Modelfile: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// cut splits off the first whitespace-separated word
+func cut(s string) (string, string) {
+	i := strings.IndexAny(s, " \t")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// readValue unquotes a value, reading further lines for """ blocks
+func readValue(rest string, scanner *bufio.Scanner, lineNo *int) (string, error) {
+	if strings.HasPrefix(rest, `"""`) {
+		body := rest[3:]
+		for {
+			if end := strings.Index(body, `"""`); end >= 0 {
+				if strings.TrimSpace(body[end+3:]) != "" {
+					return "", fmt.Errorf(`unexpected text after closing """`)
+				}
+				return body[:end], nil
+			}
+			if !scanner.Scan() {
+				return "", fmt.Errorf(`unterminated """`)
+			}
+			*lineNo++
+			body += "\n" + scanner.Text()
+		}
+	}
+
+	if strings.HasPrefix(rest, `"`) {
+		if len(rest) < 2 || !strings.HasSuffix(rest, `"`) {
+			return "", fmt.Errorf("unterminated quote")
+		}
+		return rest[1 : len(rest)-1], nil
+	}
+	return rest, nil
+}
+
+// From returns the base model, or "" when there is no FROM line
+func (m *Modelfile) From() string {
+	return m.last(From)
+}
+
+// System returns the system prompt; the last SYSTEM line wins
+func (m *Modelfile) System() string {
+	return m.last(System)
+}
+
+// Template returns the prompt template; the last TEMPLATE line wins
+func (m *Modelfile) Template() string {
+	return m.last(Template)
+}
+
+// Parameter returns every value set for a parameter, in order
+func (m *Modelfile) Parameter(name string) []string {
+	var values []string
+	for _, d := range m.Directives {
+		if d.Command == Parameter && d.Name == strings.ToLower(name) {
+			values = append(values, d.Value)
+		}
+	}
+	return values
+}
+
+func (m *Modelfile) last(cmd Command) string {
+	value := ""
+	for _, d := range m.Directives {
+		if d.Command == cmd {
+			value = d.Value
+		}
+	}
+	return value
+}
+
+// String formats the Modelfile so that Parse returns the same directives
+func (m *Modelfile) String() string {
+	var b strings.Builder
+	for _, d := range m.Directives {
+		b.WriteString(d.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// WriteTo writes the formatted Modelfile
+func (m *Modelfile) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, m.String())
+	return int64(n), err
+}
+
+// String formats one directive
+func (d Directive) String() string {
+	parts := []string{string(d.Command)}
+	if d.Name != "" {
+		parts = append(parts, d.Name)
+	}
+	return strings.Join(append(parts, quote(d.Command, d.Value)), " ")
+}
+
+// quote wraps values that would not survive parsing as written. Prompts and
+// templates always use """ as Ollama does.
+func quote(cmd Command, value string) string {
+	switch {
+	case cmd == Template || cmd == System || cmd == License:
+		return `"""` + value + `"""`
+	case strings.Contains(value, "\n"), strings.HasPrefix(value, `"`), value != strings.TrimSpace(value):
+		return `"""` + value + `"""`
+	}
+	return value
+}
@@ -0,0 +1,193 @@
+package modelfile
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `# Coding assistant
+FROM llama3.2
+from ./adapter-base.gguf
+
+PARAMETER temperature 0.8
+parameter Top_P 0.9
+PARAMETER stop "<|end|>"
+PARAMETER stop <|user|>
+
+ADAPTER ./lora.gguf
+TEMPLATE """{{ if .System }}<|system|>{{ .System }}{{ end }}
+<|user|>{{ .Prompt }}"""
+SYSTEM """
+You are a helpful coding assistant.
+"""
+MESSAGE user How do I read a file?
+MESSAGE assistant "Use os.ReadFile."
+LICENSE """MIT"""
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := []Directive{
+		{Command: From, Value: "llama3.2", Line: 2},
+		{Command: From, Value: "./adapter-base.gguf", Line: 3},
+		{Command: Parameter, Name: "temperature", Value: "0.8", Line: 5},
+		{Command: Parameter, Name: "top_p", Value: "0.9", Line: 6},
+		{Command: Parameter, Name: "stop", Value: "<|end|>", Line: 7},
+		{Command: Parameter, Name: "stop", Value: "<|user|>", Line: 8},
+		{Command: Adapter, Value: "./lora.gguf", Line: 10},
+		{Command: Template, Value: "{{ if .System }}<|system|>{{ .System }}{{ end }}\n<|user|>{{ .Prompt }}", Line: 11},
+		{Command: System, Value: "\nYou are a helpful coding assistant.\n", Line: 13},
+		{Command: Message, Name: "user", Value: "How do I read a file?", Line: 16},
+		{Command: Message, Name: "assistant", Value: "Use os.ReadFile.", Line: 17},
+		{Command: License, Value: "MIT", Line: 18},
+	}
+	if !reflect.DeepEqual(m.Directives, want) {
+		t.Errorf("got directives\n%+v\nwant\n%+v", m.Directives, want)
+	}
+	if m.From() != "./adapter-base.gguf" || !strings.Contains(m.System(), "coding assistant") {
+		t.Errorf("unexpected accessors: from=%q system=%q", m.From(), m.System())
+	}
+	if got := m.Parameter("STOP"); !reflect.DeepEqual(got, []string{"<|end|>", "<|user|>"}) {
+		t.Errorf("unexpected stop values %v", got)
+	}
+
+	// Formatting and parsing again keeps every directive
+	again, err := Parse(strings.NewReader(m.String()))
+	if err != nil {
+		t.Fatalf("Parse of formatted output failed: %v\n%s", err, m.String())
+	}
+	if len(Diff(m, again)) != 0 || len(again.Directives) != len(m.Directives) {
+		t.Errorf("round trip changed the Modelfile:\n%s", m.String())
+	}
+	if !strings.Contains(m.String(), "PARAMETER top_p 0.9\n") {
+		t.Errorf("unexpected formatting:\n%s", m.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+		want  string
+	}{
+		{"FROM llama3\nRUN echo hi\n", 2, "unknown instruction"},
+		{"FROM llama3\nPARAMETER temperature\n", 2, "missing value"},
+		{"FROM llama3\nSYSTEM \"\"\"\nnever closed\n", 2, "unterminated"},
+		{"FROM llama3\nSYSTEM \"half quoted\n", 2, "unterminated quote"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", tt.input, err)
+			continue
+		}
+		if parseErr.Line != tt.line || !strings.Contains(parseErr.Message, tt.want) {
+			t.Errorf("%q: got %v, want line %d with %q", tt.input, err, tt.line, tt.want)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	m, err := Parse(strings.NewReader(`PARAMETER temperature hot
+PARAMETER top_p 1.5
+PARAMETER num_ctx 4096
+PARAMETER num_ctx 8192
+PARAMETER mirostat 3
+PARAMETER use_mmap maybe
+PARAMETER creativity 11
+PARAMETER stop a
+PARAMETER stop b
+TEMPLATE """{{ .Prompt }"""
+MESSAGE narrator Once upon a time
+MESSAGE tool {"temperature": 21}
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	issues := Lint(m)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`line 1: error: parameter temperature: "hot" is not a number`,
+		`line 2: error: parameter top_p: 1.5 must be between 0 and 1`,
+		`line 4: warning: parameter num_ctx repeated (first on line 3)`,
+		`line 5: error: parameter mirostat: 3 must be between 0 and 2`,
+		`line 6: error: parameter use_mmap: "maybe" is not true or false`,
+		`line 7: error: unknown parameter "creativity"`,
+		`line 11: error: invalid MESSAGE role "narrator" (want system, user or assistant)`,
+		`line 12: error: invalid MESSAGE role "tool" (want system, user or assistant)`,
+		`error: missing FROM`,
+	}
+	// The template error comes from text/template; check it separately
+	var templateIssue string
+	for i, line := range got {
+		if strings.HasPrefix(line, "line 10: error: invalid TEMPLATE") {
+			templateIssue = line
+			got = append(got[:i], got[i+1:]...)
+			break
+		}
+	}
+	if templateIssue == "" {
+		t.Errorf("expected a TEMPLATE error, got %q", got)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got issues\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !HasErrors(issues) {
+		t.Error("expected HasErrors to be true")
+	}
+
+	clean, _ := Parse(strings.NewReader(sample))
+	if issues := Lint(clean); HasErrors(issues) {
+		t.Errorf("expected no errors for the sample, got %v", issues)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, _ := Parse(strings.NewReader("FROM llama3\nPARAMETER temperature 0.7\nPARAMETER stop a\nSYSTEM \"\"\"\nBe brief.\n\"\"\"\nADAPTER ./old.gguf\n"))
+	b, _ := Parse(strings.NewReader("FROM llama3\nSYSTEM Be brief.\nPARAMETER stop a\nPARAMETER stop b\nPARAMETER temperature 0.2\nTEMPLATE \"\"\"{{ .Prompt }}\"\"\"\n"))
+
+	changes := Diff(a, b)
+	want := []Change{
+		{Kind: Changed, Key: "PARAMETER temperature", Old: "0.7", New: "0.2"},
+		{Kind: Changed, Key: "PARAMETER stop", Old: "a", New: "a\nb"},
+		{Kind: Removed, Key: "ADAPTER", Old: "./old.gguf"},
+		{Kind: Added, Key: "TEMPLATE", New: "{{ .Prompt }}"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes\n%+v\nwant\n%+v", changes, want)
+	}
+	if got := changes[1].String(); got != "~ PARAMETER stop\n  - a\n  + a\n  + b" {
+		t.Errorf("unexpected multi-line change %q", got)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	options, err := ParseOptions([]string{"temperature=0.2", "NUM_CTX=8192", "stop=<|end|>", "stop=User:", "use_mmap=false"})
+	if err != nil {
+		t.Fatalf("ParseOptions failed: %v", err)
+	}
+	if options["temperature"] != 0.2 || options["num_ctx"] != int64(8192) || options["use_mmap"] != false {
+		t.Errorf("values not converted: %#v", options)
+	}
+	if stops, _ := options["stop"].([]string); len(stops) != 2 || stops[1] != "User:" {
+		t.Errorf("expected stop to collect into a list, got %#v", options["stop"])
+	}
+
+	for _, bad := range []string{"temperature", "creativity=1", "top_p=1.5", "seed=abc", "top_p=nan", "temperature=NaN", "temperature=+Inf"} {
+		if _, err := ParseOptions([]string{bad}); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package modelfile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseParameter validates a parameter like Lint does and converts its value
+// to the type Ollama expects in request options
+func ParseParameter(name, value string) (interface{}, error) {
+	name = strings.ToLower(name)
+	spec, ok := parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %q (known: %s)", name, strings.Join(ParameterNames(), ", "))
+	}
+	if msg := spec.check(value); msg != "" {
+		return nil, fmt.Errorf("parameter %s: %s", name, msg)
+	}
+
+	switch spec.kind {
+	case paramInt:
+		n, _ := strconv.ParseInt(value, 10, 64)
+		return n, nil
+	case paramFloat:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f, nil
+	case paramBool:
+		b, _ := strconv.ParseBool(value)
+		return b, nil
+	}
+	return value, nil
+}
+
+// ParseOptions turns key=value pairs, as given to --option, into request
+// options. Parameters that may repeat, such as stop, collect into a list.
+func ParseOptions(pairs []string) (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid option %q: use key=value", pair)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		v, err := ParseParameter(name, value)
+		if err != nil {
+			return nil, err
+		}
+		if parameters[name].repeat {
+			list, _ := options[name].([]string)
+			options[name] = append(list, value)
+			continue
+		}
+		options[name] = v
+	}
+	return options, nil
+}
+
+// IsRepeatable reports whether a parameter takes a list of values
+func IsRepeatable(name string) bool {
+	return parameters[strings.ToLower(name)].repeat
+}
+
+// ParameterNames returns the parameters Ollama accepts, sorted
+func ParameterNames() []string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
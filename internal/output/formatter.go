@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"ollamacli/internal/client"
 )
@@ -30,6 +31,11 @@ type Formatter interface {
 	FormatPullProgress(resp *client.PullResponse) error
 	FormatEmbeddings(resp *client.EmbedResponse) error
 	FormatCreateProgress(resp *client.CreateResponse) error
+	FormatRunningModels(models []client.Model) error
+	FormatStats(stats client.Stats) error
+	FormatPushProgress(resp *client.PushResponse) error
+	FormatVersion(resp *client.VersionResponse) error
+	FormatResult(message string, data interface{}) error
 	FormatError(err error) error
 	FormatGeneric(data interface{}) error
 }
@@ -139,8 +145,18 @@ func (f *formatter) formatModelInfoText(info *client.ShowResponse) error {
 }
 
 func (f *formatter) FormatChatResponse(resp *client.ChatResponse) error {
+	if resp.Err != nil {
+		return f.FormatError(resp.Err)
+	}
+
 	switch f.opts.Format {
 	case FormatJSON:
+		if resp.Done {
+			return f.writeJSON(struct {
+				*client.ChatResponse
+				Stats client.Stats `json:"stats"`
+			}{resp, resp.Stats()})
+		}
 		return f.writeJSON(resp)
 	default:
 		return f.formatChatResponseText(resp)
@@ -158,8 +174,18 @@ func (f *formatter) formatChatResponseText(resp *client.ChatResponse) error {
 }
 
 func (f *formatter) FormatGenerateResponse(resp *client.GenerateResponse) error {
+	if resp.Err != nil {
+		return f.FormatError(resp.Err)
+	}
+
 	switch f.opts.Format {
 	case FormatJSON:
+		if resp.Done {
+			return f.writeJSON(struct {
+				*client.GenerateResponse
+				Stats client.Stats `json:"stats"`
+			}{resp, resp.Stats()})
+		}
 		return f.writeJSON(resp)
 	default:
 		return f.formatGenerateResponseText(resp)
@@ -177,6 +203,10 @@ func (f *formatter) formatGenerateResponseText(resp *client.GenerateResponse) er
 }
 
 func (f *formatter) FormatPullProgress(resp *client.PullResponse) error {
+	if resp.Err != nil {
+		return f.FormatError(resp.Err)
+	}
+
 	switch f.opts.Format {
 	case FormatJSON:
 		return f.writeJSON(resp)
@@ -186,15 +216,11 @@ func (f *formatter) FormatPullProgress(resp *client.PullResponse) error {
 }
 
 func (f *formatter) formatPullProgressText(resp *client.PullResponse) error {
-	if f.opts.Quiet && !strings.HasPrefix(resp.Status, "error:") {
+	if f.opts.Quiet {
 		return nil
 	}
 
 	status := resp.Status
-	if strings.HasPrefix(status, "error:") {
-		_, err := fmt.Fprintf(f.opts.Writer, "Error: %s\n", strings.TrimPrefix(status, "error: "))
-		return err
-	}
 
 	if resp.Total > 0 && resp.Completed >= 0 {
 		progress := float64(resp.Completed) / float64(resp.Total) * 100
@@ -260,6 +286,10 @@ func (f *formatter) formatEmbeddingsText(resp *client.EmbedResponse) error {
 }
 
 func (f *formatter) FormatCreateProgress(resp *client.CreateResponse) error {
+	if resp.Err != nil {
+		return f.FormatError(resp.Err)
+	}
+
 	switch f.opts.Format {
 	case FormatJSON:
 		return f.writeJSON(resp)
@@ -269,20 +299,202 @@ func (f *formatter) FormatCreateProgress(resp *client.CreateResponse) error {
 }
 
 func (f *formatter) formatCreateProgressText(resp *client.CreateResponse) error {
-	if f.opts.Quiet && !strings.HasPrefix(resp.Status, "error:") {
+	if f.opts.Quiet {
 		return nil
 	}
 
-	status := resp.Status
-	if strings.HasPrefix(status, "error:") {
-		_, err := fmt.Fprintf(f.opts.Writer, "Error: %s\n", strings.TrimPrefix(status, "error: "))
+	// Hashing and uploading local weights report byte counts
+	if resp.Total > 0 {
+		progress := float64(resp.Completed) / float64(resp.Total) * 100
+		end := ""
+		if resp.Completed >= resp.Total {
+			end = "\n"
+		}
+		_, err := fmt.Fprintf(f.opts.Writer, "\r%s %.1f%% (%s/%s)%s",
+			resp.Status, progress,
+			f.formatSize(resp.Completed),
+			f.formatSize(resp.Total), end)
 		return err
 	}
 
-	_, err := fmt.Fprintf(f.opts.Writer, "%s\n", status)
+	_, err := fmt.Fprintf(f.opts.Writer, "%s\n", resp.Status)
 	return err
 }
 
+func (f *formatter) FormatRunningModels(models []client.Model) error {
+	switch f.opts.Format {
+	case FormatJSON:
+		return f.writeJSON(map[string]interface{}{"models": models})
+	default:
+		return f.formatRunningModelsText(models)
+	}
+}
+
+func (f *formatter) formatRunningModelsText(models []client.Model) error {
+	if f.opts.Quiet {
+		for _, model := range models {
+			if _, err := fmt.Fprintln(f.opts.Writer, model.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(models) == 0 {
+		_, err := fmt.Fprintln(f.opts.Writer, "No models loaded")
+		return err
+	}
+
+	_, err := fmt.Fprintf(f.opts.Writer, "%-30s %-14s %-10s %-16s %s\n", "NAME", "ID", "SIZE", "PROCESSOR", "UNTIL")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(f.opts.Writer, strings.Repeat("-", 90))
+	if err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		_, err = fmt.Fprintf(f.opts.Writer, "%-30s %-14s %-10s %-16s %s\n",
+			model.Name,
+			f.truncateString(strings.TrimPrefix(model.Digest, "sha256:"), 12),
+			f.formatSize(model.Size),
+			f.formatProcessor(model),
+			f.formatExpiry(model.ExpiresAt, time.Now()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatProcessor describes how a loaded model is split between GPU and CPU
+func (f *formatter) formatProcessor(model client.Model) string {
+	switch {
+	case model.Size == 0:
+		return "-"
+	case model.SizeVRAM == 0:
+		return "100% CPU"
+	case model.SizeVRAM >= model.Size:
+		return "100% GPU"
+	default:
+		gpu := float64(model.SizeVRAM) / float64(model.Size) * 100
+		return fmt.Sprintf("%.0f%%/%.0f%% CPU/GPU", 100-gpu, gpu)
+	}
+}
+
+// formatExpiry tells when a loaded model will be evicted from memory. Models
+// kept loaded with a negative keep-alive expire centuries from now.
+func (f *formatter) formatExpiry(expiresAt *time.Time, now time.Time) string {
+	if expiresAt == nil || expiresAt.IsZero() {
+		return "-"
+	}
+
+	left := expiresAt.Sub(now)
+	switch {
+	case left <= 0:
+		return "Stopping..."
+	case left > 100*365*24*time.Hour:
+		return "Forever"
+	case left < time.Minute:
+		return "less than a minute from now"
+	case left < 2*time.Minute:
+		return "about a minute from now"
+	case left < time.Hour:
+		return fmt.Sprintf("%d minutes from now", int(left/time.Minute))
+	case left < 2*time.Hour:
+		return "about an hour from now"
+	case left < 48*time.Hour:
+		return fmt.Sprintf("%d hours from now", int(left/time.Hour))
+	}
+	return fmt.Sprintf("%d days from now", int(left/(24*time.Hour)))
+}
+
+// FormatStats prints the footer shown by --stats after a reply
+func (f *formatter) FormatStats(stats client.Stats) error {
+	switch f.opts.Format {
+	case FormatJSON:
+		return f.writeJSON(map[string]interface{}{"stats": stats})
+	default:
+		_, err := fmt.Fprintf(f.opts.Writer, "\n%s\n", StatsLine(stats))
+		return err
+	}
+}
+
+// StatsLine summarizes a reply's token counts and timings on one line,
+// leaving out the parts the server did not report
+func StatsLine(stats client.Stats) string {
+	prompt := fmt.Sprintf("prompt %d tokens", stats.PromptTokens)
+	if r := stats.PromptTokensPerSecond(); r > 0 {
+		prompt += fmt.Sprintf(" (%.1f tok/s)", r)
+	}
+	completion := fmt.Sprintf("%d tokens", stats.CompletionTokens)
+	if r := stats.TokensPerSecond(); r > 0 {
+		completion += fmt.Sprintf(" at %.1f tok/s", r)
+	}
+
+	parts := []string{prompt, completion}
+	if stats.TimeToFirstToken > 0 {
+		parts = append(parts, "first token "+formatDuration(stats.TimeToFirstToken))
+	}
+	if stats.LoadDuration > 0 {
+		parts = append(parts, "load "+formatDuration(stats.LoadDuration))
+	}
+	if stats.TotalDuration > 0 {
+		parts = append(parts, "total "+formatDuration(stats.TotalDuration))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// formatDuration rounds to milliseconds below a second and to tenths above
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func (f *formatter) FormatPushProgress(resp *client.PushResponse) error {
+	if resp.Err != nil {
+		return f.FormatError(resp.Err)
+	}
+
+	switch f.opts.Format {
+	case FormatJSON:
+		return f.writeJSON(resp)
+	default:
+		// Push progress has the same shape as pull progress
+		return f.formatPullProgressText((*client.PullResponse)(resp))
+	}
+}
+
+func (f *formatter) FormatVersion(resp *client.VersionResponse) error {
+	switch f.opts.Format {
+	case FormatJSON:
+		return f.writeJSON(resp)
+	default:
+		_, err := fmt.Fprintln(f.opts.Writer, resp.Version)
+		return err
+	}
+}
+
+// FormatResult reports the outcome of an operation without a response body,
+// printing message as text or data as JSON
+func (f *formatter) FormatResult(message string, data interface{}) error {
+	switch f.opts.Format {
+	case FormatJSON:
+		return f.writeJSON(data)
+	default:
+		if f.opts.Quiet {
+			return nil
+		}
+		_, err := fmt.Fprintln(f.opts.Writer, message)
+		return err
+	}
+}
+
 func (f *formatter) FormatError(err error) error {
 	if f.opts.Format == FormatJSON {
 		return f.writeJSON(map[string]string{"error": err.Error()})
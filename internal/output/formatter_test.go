@@ -2,6 +2,7 @@ package output
 
 import (
 	"bytes"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -196,4 +197,127 @@ func TestStreamFormatter(t *testing.T) {
 	if sf.GetBuffer() != "" {
 		t.Errorf("Expected buffer to be empty after clear, got '%s'", sf.GetBuffer())
 	}
-}
\ No newline at end of file
+}
+func TestFormatRunningModelsText(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := New(Options{Format: FormatText, Writer: &buf})
+
+	expires := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	models := []client.Model{
+		{Name: "llama2", Digest: "sha256:1234567890abcdef", Size: 4000, SizeVRAM: 4000, ExpiresAt: &expires},
+		{Name: "phi", Size: 4000, SizeVRAM: 1000},
+	}
+
+	if err := formatter.FormatRunningModels(models); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"PROCESSOR", "UNTIL", "llama2", "100% GPU", "75%/25% CPU/GPU", "123456789..."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Output should contain %q, got: %s", want, output)
+		}
+	}
+}
+
+func TestFormatExpiry(t *testing.T) {
+	f := &formatter{}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		expiresAt *time.Time
+		want      string
+	}{
+		{nil, "-"},
+		{at(-time.Second), "Stopping..."},
+		{at(30 * time.Second), "less than a minute from now"},
+		{at(4*time.Minute + 30*time.Second), "4 minutes from now"},
+		{at(90 * time.Minute), "about an hour from now"},
+		{at(5 * time.Hour), "5 hours from now"},
+		{at(72 * time.Hour), "3 days from now"},
+		{at(math.MaxInt64), "Forever"},
+	}
+	for _, tt := range tests {
+		if got := f.formatExpiry(tt.expiresAt, now); got != tt.want {
+			t.Errorf("formatExpiry(%v) = %q, want %q", tt.expiresAt, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRunningModelsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := New(Options{Format: FormatJSON, Writer: &buf})
+
+	if err := formatter.FormatRunningModels([]client.Model{{Name: "llama2", SizeVRAM: 10}}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"size_vram": 10`) {
+		t.Errorf("Expected JSON output with size_vram, got: %s", buf.String())
+	}
+}
+
+func TestFormatResultAndVersion(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := New(Options{Format: FormatText, Writer: &buf})
+
+	if err := formatter.FormatResult("copied llama2 to backup", map[string]string{"status": "success"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := formatter.FormatVersion(&client.VersionResponse{Version: "0.5.1"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if buf.String() != "copied llama2 to backup\n0.5.1\n" {
+		t.Errorf("unexpected text output: %q", buf.String())
+	}
+
+	buf.Reset()
+	formatter = New(Options{Format: FormatJSON, Writer: &buf})
+	if err := formatter.FormatResult("copied llama2 to backup", map[string]string{"status": "success"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"status": "success"`) {
+		t.Errorf("Expected JSON result, got: %s", buf.String())
+	}
+}
+
+func TestFormatStats(t *testing.T) {
+	resp := &client.ChatResponse{
+		Message:            client.ChatMessage{Role: "assistant", Content: "Hi"},
+		Done:               true,
+		PromptEvalCount:    26,
+		PromptEvalDuration: int64(50 * time.Millisecond),
+		EvalCount:          85,
+		EvalDuration:       int64(2 * time.Second),
+		LoadDuration:       int64(1200 * time.Millisecond),
+		TotalDuration:      int64(3300 * time.Millisecond),
+	}
+	stats := resp.Stats()
+	stats.TimeToFirstToken = 1320 * time.Millisecond
+
+	var buf bytes.Buffer
+	formatter := New(Options{Format: FormatText, Writer: &buf})
+	if err := formatter.FormatStats(stats); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	want := "\nprompt 26 tokens (520.0 tok/s) | 85 tokens at 42.5 tok/s | first token 1.3s | load 1.2s | total 3.3s\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	// JSON output of a final reply carries the stats
+	buf.Reset()
+	formatter = New(Options{Format: FormatJSON, Writer: &buf})
+	if err := formatter.FormatChatResponse(resp); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, want := range []string{`"content": "Hi"`, `"eval_count": 85`, `"tokens_per_second": 42.5`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %s in JSON output, got: %s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"ollamacli/internal/client"
+)
+
+// redrawInterval limits how often a terminal progress view is repainted
+const redrawInterval = 100 * time.Millisecond
+
+// PullProgress renders the progress of one or more concurrent pulls. On a
+// terminal every model gets a line with its combined progress, speed and ETA,
+// followed by a line per layer still downloading, repainted in place.
+// Elsewhere only status changes are printed, one line each.
+type PullProgress struct {
+	formatter
+	mu    sync.Mutex
+	tty   bool
+	now   func() time.Time
+	pulls []*pullState
+	// lines drawn by the last repaint, and when it happened
+	lines int
+	drawn time.Time
+}
+
+type pullState struct {
+	model  string
+	status string
+	layers []*layerState
+	digest string
+	done   bool
+	err    error
+}
+
+// layerState tracks one layer; speed is averaged since the layer was first
+// seen, or since the pull was last resumed
+type layerState struct {
+	digest     string
+	total      int64
+	completed  int64
+	start      time.Time
+	startBytes int64
+}
+
+// NewPullProgress creates a progress view writing to w. With tty set it
+// repaints in place using ANSI escapes.
+func NewPullProgress(w io.Writer, tty bool) *PullProgress {
+	return &PullProgress{
+		formatter: formatter{opts: Options{Writer: w}},
+		tty:       tty,
+		now:       time.Now,
+	}
+}
+
+// Add registers a model so it is shown before its first update
+func (p *PullProgress) Add(model string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pull(model)
+}
+
+func (p *PullProgress) pull(model string) *pullState {
+	for _, state := range p.pulls {
+		if state.model == model {
+			return state
+		}
+	}
+	state := &pullState{model: model, status: "waiting"}
+	p.pulls = append(p.pulls, state)
+	return state
+}
+
+// Update records a progress message for model and redraws
+func (p *PullProgress) Update(model string, resp client.PullResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.pull(model)
+	now := p.now()
+	changed := false
+
+	switch {
+	case resp.Err != nil:
+		state.err = resp.Err
+		state.done = true
+		changed = true
+	case resp.Status == client.PullStatusVerified:
+		state.digest = resp.Digest
+		state.done = true
+		changed = true
+	case resp.Status == client.PullStatusResuming:
+		// Measure speed afresh from where the server picks up again
+		for _, layer := range state.layers {
+			layer.start = time.Time{}
+		}
+	}
+
+	if resp.Status != "" && resp.Err == nil && resp.Status != state.status {
+		state.status = resp.Status
+		changed = true
+	}
+
+	if resp.Digest != "" && resp.Total > 0 {
+		layer := state.layer(resp.Digest)
+		layer.total = resp.Total
+		if layer.start.IsZero() {
+			layer.start = now
+			layer.startBytes = resp.Completed
+		}
+		layer.completed = resp.Completed
+	}
+
+	if p.tty {
+		if changed || now.Sub(p.drawn) >= redrawInterval {
+			p.redraw(now)
+		}
+	} else if changed {
+		fmt.Fprintln(p.opts.Writer, p.summary(state, now))
+	}
+}
+
+func (s *pullState) layer(digest string) *layerState {
+	for _, layer := range s.layers {
+		if layer.digest == digest {
+			return layer
+		}
+	}
+	layer := &layerState{digest: digest}
+	s.layers = append(s.layers, layer)
+	return layer
+}
+
+// Finish draws the final state
+func (p *PullProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty {
+		p.redraw(p.now())
+	}
+}
+
+// redraw repaints every line, moving the cursor back over the previous paint
+func (p *PullProgress) redraw(now time.Time) {
+	var b strings.Builder
+	if p.lines > 0 {
+		fmt.Fprintf(&b, "\033[%dA", p.lines)
+	}
+
+	lines := 0
+	for _, state := range p.pulls {
+		b.WriteString("\r\033[K" + p.summary(state, now) + "\n")
+		lines++
+		if state.done {
+			continue
+		}
+		for _, layer := range state.layers {
+			if layer.completed >= layer.total {
+				continue
+			}
+			b.WriteString("\r\033[K    " + p.layerLine(layer, now) + "\n")
+			lines++
+		}
+	}
+	// Clear lines left over from a taller previous paint
+	for i := lines; i < p.lines; i++ {
+		b.WriteString("\r\033[K\n")
+	}
+	if p.lines > lines {
+		fmt.Fprintf(&b, "\033[%dA", p.lines-lines)
+	}
+
+	io.WriteString(p.opts.Writer, b.String())
+	p.lines = lines
+	p.drawn = now
+}
+
+// summary describes a whole model: its status and combined progress
+func (p *PullProgress) summary(state *pullState, now time.Time) string {
+	switch {
+	case state.err != nil:
+		return fmt.Sprintf("%s: failed: %v", state.model, state.err)
+	case state.done:
+		total, _ := state.bytes()
+		line := fmt.Sprintf("%s: done", state.model)
+		if total > 0 {
+			line += fmt.Sprintf(" (%s)", p.formatSize(total))
+		}
+		if state.digest != "" {
+			line += " " + shortDigest(state.digest)
+		}
+		return line
+	}
+
+	total, completed := state.bytes()
+	if total == 0 {
+		return fmt.Sprintf("%s: %s", state.model, state.status)
+	}
+
+	var speed float64
+	for _, layer := range state.layers {
+		speed += layer.speed(now)
+	}
+	return fmt.Sprintf("%s: %s %s", state.model, state.status, p.transfer(total, completed, speed))
+}
+
+func (p *PullProgress) layerLine(layer *layerState, now time.Time) string {
+	return fmt.Sprintf("%s %s", shortDigest(layer.digest), p.transfer(layer.total, layer.completed, layer.speed(now)))
+}
+
+// transfer formats percentage, sizes, speed and ETA
+func (p *PullProgress) transfer(total, completed int64, speed float64) string {
+	line := fmt.Sprintf("%5.1f%% %s/%s", float64(completed)/float64(total)*100,
+		p.formatSize(completed), p.formatSize(total))
+	if speed > 0 && completed < total {
+		eta := time.Duration(float64(total-completed) / speed * float64(time.Second))
+		line += fmt.Sprintf(" %s/s ETA %s", p.formatSize(int64(speed)), eta.Round(time.Second))
+	}
+	return line
+}
+
+// bytes sums the layers of a model
+func (s *pullState) bytes() (total, completed int64) {
+	for _, layer := range s.layers {
+		total += layer.total
+		completed += layer.completed
+	}
+	return total, completed
+}
+
+// speed returns bytes per second, or zero until there is enough to measure
+func (l *layerState) speed(now time.Time) float64 {
+	elapsed := now.Sub(l.start).Seconds()
+	if l.start.IsZero() || elapsed < 0.5 || l.completed >= l.total {
+		return 0
+	}
+	return float64(l.completed-l.startBytes) / elapsed
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+)
+
+func TestPullProgressSpeedAndETA(t *testing.T) {
+	var out strings.Builder
+	p := NewPullProgress(&out, true)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return clock }
+
+	const mb = 1024 * 1024
+	p.Update("llama2", client.PullResponse{Status: "pulling manifest"})
+	p.Update("llama2", client.PullResponse{Status: "pulling aaa", Digest: "sha256:aaaaaaaaaaaaaaaa", Total: 100 * mb})
+	p.Update("llama2", client.PullResponse{Status: "pulling bbb", Digest: "sha256:bbbbbbbbbbbbbbbb", Total: 10 * mb, Completed: 10 * mb})
+
+	clock = clock.Add(2 * time.Second)
+	p.Update("llama2", client.PullResponse{Status: "pulling bbb", Digest: "sha256:aaaaaaaaaaaaaaaa", Total: 100 * mb, Completed: 20 * mb})
+
+	// 20 MB in 2s leaves 80 MB at 10 MB/s
+	frame := out.String()[strings.LastIndex(out.String(), "llama2:"):]
+	for _, want := range []string{"27.3% 30.0 MB/110.0 MB", "10.0 MB/s ETA 8s", "aaaaaaaaaaaa  20.0% 20.0 MB/100.0 MB"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("expected %q in %q", want, frame)
+		}
+	}
+	if strings.Contains(frame, "bbbbbbbbbbbb") {
+		t.Errorf("finished layers should not be listed: %q", frame)
+	}
+
+	p.Update("llama2", client.PullResponse{Status: client.PullStatusVerified, Digest: "sha256:0123456789abcdef"})
+	p.Finish()
+	if !strings.Contains(out.String(), "llama2: done (110.0 MB) 0123456789ab") {
+		t.Errorf("expected a done line, got %q", out.String())
+	}
+}
+
+func TestPullProgressPlainOutput(t *testing.T) {
+	var out strings.Builder
+	p := NewPullProgress(&out, false)
+
+	p.Add("llama2")
+	p.Add("mistral")
+	p.Update("llama2", client.PullResponse{Status: "pulling manifest"})
+	for i := int64(0); i <= 4; i++ {
+		p.Update("llama2", client.PullResponse{Status: "pulling aaa", Digest: "sha256:aaa", Total: 4, Completed: i})
+	}
+	p.Update("mistral", client.PullResponse{Err: errors.New("connection reset")})
+	p.Finish()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"llama2: pulling manifest",
+		"llama2: pulling aaa   0.0% 0 B/4 B",
+		"mistral: failed: connection reset",
+	}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+	if strings.Contains(out.String(), "\033[") {
+		t.Error("plain output should not contain escape sequences")
+	}
+}
@@ -0,0 +1,105 @@
+package persona
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"ollamacli/internal/modelfile"
+)
+
+// ErrNotFound is returned when a persona file does not exist
+var ErrNotFound = errors.New("persona not found")
+
+// Persona bundles a system prompt with the model and options it works
+// best with, loaded from <dir>/<name>.yaml
+type Persona struct {
+	// Name is taken from the file name
+	Name        string `yaml:"-"`
+	Description string `yaml:"description"`
+	System      string `yaml:"system"`
+	// Model is used unless one is given on the command line; empty keeps
+	// the current model
+	Model   string                 `yaml:"model"`
+	Options map[string]interface{} `yaml:"options"`
+	// Greeting is shown when the persona is selected
+	Greeting string `yaml:"greeting"`
+}
+
+// Load reads the persona called name from dir
+func Load(dir, name string) (*Persona, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid persona name %q", name)
+	}
+
+	path := filepath.Join(dir, name+".yaml")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s (looked for %s)", ErrNotFound, name, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona: %w", err)
+	}
+
+	p := &Persona{Name: name}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid persona %s: %w", path, err)
+	}
+	p.System = strings.TrimSpace(p.System)
+	p.Greeting = strings.TrimSpace(p.Greeting)
+
+	options, err := normalizeOptions(p.Options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid persona %s: %w", path, err)
+	}
+	p.Options = options
+	return p, nil
+}
+
+// List loads every persona in dir, sorted by name. A missing directory
+// has no personas.
+func List(dir string) ([]*Persona, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personas: %w", err)
+	}
+	sort.Strings(paths)
+
+	personas := make([]*Persona, 0, len(paths))
+	for _, path := range paths {
+		p, err := Load(dir, strings.TrimSuffix(filepath.Base(path), ".yaml"))
+		if err != nil {
+			return nil, err
+		}
+		personas = append(personas, p)
+	}
+	return personas, nil
+}
+
+// normalizeOptions validates options against the parameters Ollama accepts
+// and converts YAML values to the types sent in requests
+func normalizeOptions(options map[string]interface{}) (map[string]interface{}, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	var pairs []string
+	for name, value := range options {
+		if list, ok := value.([]interface{}); ok {
+			if !modelfile.IsRepeatable(name) {
+				return nil, fmt.Errorf("parameter %s takes a single value", name)
+			}
+			for _, item := range list {
+				pairs = append(pairs, fmt.Sprintf("%s=%v", name, item))
+			}
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, value))
+	}
+	return modelfile.ParseOptions(pairs)
+}
@@ -0,0 +1,80 @@
+package persona
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePersona(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write persona: %v", err)
+	}
+}
+
+func TestLoadAndList(t *testing.T) {
+	dir := t.TempDir()
+	writePersona(t, dir, "reviewer", `description: Strict code reviewer
+model: qwen2.5-coder
+system: |
+  You review Go code. Point out bugs first.
+options:
+  temperature: 0.2
+  num_ctx: 8192
+  stop: ["<|end|>", "User:"]
+greeting: Paste a diff to review.
+`)
+	writePersona(t, dir, "translator", "system: Translate into English.\n")
+
+	p, err := Load(dir, "reviewer")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if p.Name != "reviewer" || p.Model != "qwen2.5-coder" || p.System != "You review Go code. Point out bugs first." {
+		t.Errorf("unexpected persona: %+v", p)
+	}
+	if p.Options["temperature"] != 0.2 || p.Options["num_ctx"] != int64(8192) {
+		t.Errorf("options not normalized: %v", p.Options)
+	}
+	if stops, _ := p.Options["stop"].([]string); len(stops) != 2 || stops[1] != "User:" {
+		t.Errorf("expected two stop sequences, got %v", p.Options["stop"])
+	}
+
+	personas, err := List(dir)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(personas) != 2 || personas[0].Name != "reviewer" || personas[1].Name != "translator" {
+		t.Errorf("unexpected personas: %v", personas)
+	}
+	if personas[1].Options != nil || personas[1].Model != "" {
+		t.Errorf("expected no model or options, got %+v", personas[1])
+	}
+
+	if personas, err := List(filepath.Join(dir, "missing")); err != nil || len(personas) != 0 {
+		t.Errorf("expected no personas in a missing directory, got %v, %v", personas, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	writePersona(t, dir, "hot", "options:\n  temperature: hot\n")
+	writePersona(t, dir, "creative", "options:\n  creativity: 1\n")
+	writePersona(t, dir, "seeds", "options:\n  seed: [1, 2]\n")
+	writePersona(t, dir, "broken", "system: [unclosed\n")
+
+	if _, err := Load(dir, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := Load(dir, "../etc/passwd"); err == nil {
+		t.Error("expected a path in the name to be rejected")
+	}
+	for _, name := range []string{"hot", "creative", "seeds", "broken"} {
+		if _, err := Load(dir, name); err == nil || !strings.Contains(err.Error(), name+".yaml") {
+			t.Errorf("expected %s to fail naming the file, got %v", name, err)
+		}
+	}
+}
@@ -16,7 +16,7 @@ import (
 // Retriever handles document ingestion and retrieval
 type Retriever struct {
 	store   Store
-	client  *client.Client
+	client  client.Provider
 	model   string
 	chunker *Chunker
 }
@@ -24,7 +24,7 @@ type Retriever struct {
 // RetrieverOptions contains configuration for the retriever
 type RetrieverOptions struct {
 	Store       Store
-	Client      *client.Client
+	Client      client.Provider
 	Model       string
 	ChunkSize   int
 	ChunkOverlap int
@@ -52,6 +52,11 @@ func NewRetriever(opts RetrieverOptions) *Retriever {
 	}
 }
 
+// Model returns the embedding model used for documents and queries
+func (r *Retriever) Model() string {
+	return r.model
+}
+
 // IngestFile reads a file, chunks it, generates embeddings, and stores them
 func (r *Retriever) IngestFile(ctx context.Context, filePath string) error {
 	// Read file content
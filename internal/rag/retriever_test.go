@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func TestRetrieverEndToEnd(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{
+		Models: []ollamatest.Model{{Name: "mxbai-embed-large", Capabilities: []string{"embedding"}}},
+	})
+
+	dir := t.TempDir()
+	store, err := NewVectorDB(filepath.Join(dir, "rag.db"))
+	if err != nil {
+		t.Fatalf("NewVectorDB failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	docs := filepath.Join(dir, "docs")
+	os.MkdirAll(docs, 0755)
+	files := map[string]string{
+		"go.md":       "Go channels pass values between goroutines.",
+		"cooking.txt": "Bake the bread at a high temperature until golden.",
+		"space.md":    "The telescope observed a distant galaxy cluster.",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	retriever := NewRetriever(RetrieverOptions{
+		Store:  store,
+		Client: client.New(client.Options{BaseURL: srv.URL}),
+	})
+	if err := retriever.IngestDirectory(ctx, docs, nil); err != nil {
+		t.Fatalf("IngestDirectory failed: %v", err)
+	}
+	srv.AssertRequestCount(t, "/api/embed", len(files))
+
+	results, err := retriever.Retrieve(ctx, "how do goroutines share values over channels", 1)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) != 1 || !strings.Contains(results[0].Document.Content, "goroutines") {
+		t.Errorf("expected the Go document, got %+v", results)
+	}
+
+	srv.AssertRequested(t, "/api/embed", func(req ollamatest.Request) bool {
+		return req.Model() == "mxbai-embed-large"
+	})
+}
@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ollamacli/internal/client"
+)
+
+// DefaultMaxRepairs is how many times a mismatching reply is re-prompted
+const DefaultMaxRepairs = 2
+
+// Chat sends req with the schema as its format and validates the reply. When
+// the reply does not match, the violations are sent back to the model and the
+// request is retried up to maxRepairs times. The last response is returned
+// together with the validation error if no attempt succeeds.
+func Chat(ctx context.Context, c client.Provider, req client.ChatRequest, s *Schema, maxRepairs int) (*client.ChatResponse, error) {
+	req.Format = s.Raw()
+	req.Stream = false
+	messages := append([]client.ChatMessage(nil), req.Messages...)
+
+	for attempt := 0; ; attempt++ {
+		req.Messages = messages
+		resp, err := c.Chat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		validationErr := s.Validate([]byte(resp.Message.Content))
+		if validationErr == nil {
+			return resp, nil
+		}
+		if attempt >= maxRepairs {
+			return resp, fmt.Errorf("reply still invalid after %d repair attempt(s): %w", maxRepairs, validationErr)
+		}
+
+		messages = append(messages,
+			client.ChatMessage{Role: client.RoleAssistant, Content: resp.Message.Content},
+			client.ChatMessage{Role: client.RoleUser, Content: repairPrompt(validationErr)},
+		)
+	}
+}
+
+// Generate is the /api/generate counterpart of Chat. Since generate has no
+// message history, the previous reply and its violations are appended to the prompt.
+func Generate(ctx context.Context, c client.Provider, req client.GenerateRequest, s *Schema, maxRepairs int) (*client.GenerateResponse, error) {
+	req.Format = s.Raw()
+	req.Stream = false
+	prompt := req.Prompt
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.Generate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		validationErr := s.Validate([]byte(resp.Response))
+		if validationErr == nil {
+			return resp, nil
+		}
+		if attempt >= maxRepairs {
+			return resp, fmt.Errorf("reply still invalid after %d repair attempt(s): %w", maxRepairs, validationErr)
+		}
+
+		req.Prompt = fmt.Sprintf("%s\n\nYour previous answer was:\n%s\n\n%s", prompt, resp.Response, repairPrompt(validationErr))
+	}
+}
+
+func repairPrompt(err error) string {
+	var b strings.Builder
+	b.WriteString("Your reply did not match the required JSON schema:\n")
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		for _, v := range validationErr.Violations {
+			fmt.Fprintf(&b, "- %s\n", v)
+		}
+	} else {
+		fmt.Fprintf(&b, "- %v\n", err)
+	}
+
+	b.WriteString("Reply again with only a JSON value that satisfies the schema.")
+	return b.String()
+}
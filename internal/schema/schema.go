@@ -0,0 +1,496 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrMismatch is matched by errors.Is when output does not satisfy a schema
+var ErrMismatch = errors.New("output does not match schema")
+
+// Schema is a parsed JSON-Schema document. Only the keywords commonly used
+// for structured outputs are enforced locally, along with local $refs into
+// $defs or definitions; the server sees the full document.
+type Schema struct {
+	raw  json.RawMessage
+	root map[string]interface{}
+}
+
+// Parse parses a JSON-Schema document. Keywords that would change what is
+// valid but cannot be checked locally, such as remote $refs or tuple items,
+// are rejected rather than skipped.
+func Parse(data []byte) (*Schema, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if err := checkSchema(root, root, "#"); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	return &Schema{raw: compact.Bytes(), root: root}, nil
+}
+
+// Load reads and parses a JSON-Schema file
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Raw returns the schema document for use as a request format
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// Violation describes one place where a document does not match the schema
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// ValidationError lists every violation found in a document
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrMismatch, strings.Join(lines, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrMismatch
+}
+
+// Validate checks that data is a JSON document matching the schema
+func (s *Schema) Validate(data []byte) error {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(bytes.TrimSpace(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return &ValidationError{Violations: []Violation{{Path: "$", Message: fmt.Sprintf("not valid JSON: %v", err)}}}
+	}
+	if decoder.More() {
+		return &ValidationError{Violations: []Violation{{Path: "$", Message: "unexpected data after JSON value"}}}
+	}
+
+	var violations []Violation
+	v := &validator{root: s.root, refs: make(map[string]bool)}
+	v.validate(s.root, doc, "$", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// checkSchema makes sure every $ref in schema resolves within root and that
+// it uses no keyword validate would silently skip
+func checkSchema(schema, root map[string]interface{}, at string) error {
+	if ref, ok := schema["$ref"]; ok {
+		name, _ := ref.(string)
+		if _, err := resolveRef(root, name); err != nil {
+			return fmt.Errorf("%s: %w", at, err)
+		}
+	}
+	if _, ok := schema["prefixItems"]; ok {
+		return fmt.Errorf("%s: prefixItems is not supported", at)
+	}
+	if _, ok := schema["items"].([]interface{}); ok {
+		return fmt.Errorf("%s: tuple items are not supported", at)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %w", at, pattern, err)
+		}
+	}
+
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		if sub, ok := schema[keyword].(map[string]interface{}); ok {
+			if err := checkSchema(sub, root, at+"/"+keyword); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"properties", "$defs", "definitions"} {
+		subs, _ := schema[keyword].(map[string]interface{})
+		for name, sub := range subs {
+			if subSchema, ok := sub.(map[string]interface{}); ok {
+				if err := checkSchema(subSchema, root, at+"/"+keyword+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, _ := schema[keyword].([]interface{})
+		for i, sub := range subs {
+			if subSchema, ok := sub.(map[string]interface{}); ok {
+				if err := checkSchema(subSchema, root, fmt.Sprintf("%s/%s/%d", at, keyword, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRef finds the schema a local reference such as "#/$defs/Person"
+// points to
+func resolveRef(root map[string]interface{}, ref string) (map[string]interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("$ref %q is not supported: only references within the schema are", ref)
+	}
+
+	var node interface{} = root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch current := node.(type) {
+		case map[string]interface{}:
+			node = current[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(current) {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = current[i]
+		default:
+			node = nil
+		}
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not resolve", ref)
+	}
+	return schema, nil
+}
+
+// validator checks a document against the schema rooted at root
+type validator struct {
+	root map[string]interface{}
+	// refs are the $refs being expanded at each document path, so a schema
+	// that refers to itself without descending is expanded only once
+	refs map[string]bool
+}
+
+func (v *validator) validate(schema map[string]interface{}, value interface{}, path string, out *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		if key := path + " " + ref; !v.refs[key] {
+			target, err := resolveRef(v.root, ref)
+			if err != nil {
+				report("%v", err)
+				return
+			}
+			v.refs[key] = true
+			v.validate(target, value, path, out)
+			delete(v.refs, key)
+		}
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		report("expected %s, got %s", describeType(t), jsonType(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		report("value %s is not one of %s", encode(value), encode(enum))
+	}
+	if c, ok := schema["const"]; ok && !equalValues(c, value) {
+		report("value %s must equal %s", encode(value), encode(c))
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, val, path, out)
+	case []interface{}:
+		v.validateArray(schema, val, path, out)
+	case string:
+		validateString(schema, val, report)
+	case json.Number:
+		validateNumber(schema, val, report)
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if subSchema, ok := sub.(map[string]interface{}); ok {
+				v.validate(subSchema, value, path, out)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && v.countMatches(anyOf, value, path) == 0 {
+		report("value does not match any of the allowed schemas")
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if n := v.countMatches(oneOf, value, path); n != 1 {
+			report("value must match exactly one schema, matched %d", n)
+		}
+	}
+}
+
+func (v *validator) validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, out *[]Violation) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "." + key
+		if propSchema, ok := properties[key].(map[string]interface{}); ok {
+			v.validate(propSchema, obj[key], childPath, out)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("unexpected property %q", key)})
+			}
+		case map[string]interface{}:
+			v.validate(additional, obj[key], childPath, out)
+		}
+	}
+
+	if min, ok := number(schema["minProperties"]); ok && float64(len(obj)) < min {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("must have at least %v properties", min)})
+	}
+	if max, ok := number(schema["maxProperties"]); ok && float64(len(obj)) > max {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("must have at most %v properties", max)})
+	}
+}
+
+func (v *validator) validateArray(schema map[string]interface{}, items []interface{}, path string, out *[]Violation) {
+	if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range items {
+			v.validate(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+
+	if min, ok := number(schema["minItems"]); ok && float64(len(items)) < min {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("must have at least %v items, got %d", min, len(items))})
+	}
+	if max, ok := number(schema["maxItems"]); ok && float64(len(items)) > max {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("must have at most %v items, got %d", max, len(items))})
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		seen := make(map[string]bool, len(items))
+		for _, item := range items {
+			key := encode(item)
+			if seen[key] {
+				*out = append(*out, Violation{Path: path, Message: fmt.Sprintf("duplicate item %s", key)})
+				break
+			}
+			seen[key] = true
+		}
+	}
+}
+
+func validateString(schema map[string]interface{}, s string, report func(string, ...interface{})) {
+	length := float64(utf8.RuneCountInString(s))
+	if min, ok := number(schema["minLength"]); ok && length < min {
+		report("must be at least %v characters long", min)
+	}
+	if max, ok := number(schema["maxLength"]); ok && length > max {
+		report("must be at most %v characters long", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			report("invalid pattern %q: %v", pattern, err)
+		} else if !re.MatchString(s) {
+			report("does not match pattern %q", pattern)
+		}
+	}
+}
+
+func validateNumber(schema map[string]interface{}, n json.Number, report func(string, ...interface{})) {
+	value, err := n.Float64()
+	if err != nil {
+		report("invalid number %s", n)
+		return
+	}
+
+	if min, ok := number(schema["minimum"]); ok && value < min {
+		report("must be >= %v", min)
+	}
+	if max, ok := number(schema["maximum"]); ok && value > max {
+		report("must be <= %v", max)
+	}
+	if min, ok := number(schema["exclusiveMinimum"]); ok && value <= min {
+		report("must be > %v", min)
+	}
+	if max, ok := number(schema["exclusiveMaximum"]); ok && value >= max {
+		report("must be < %v", max)
+	}
+	if multiple, ok := number(schema["multipleOf"]); ok && multiple > 0 {
+		if q := value / multiple; math.Abs(q-math.Round(q)) > 1e-9 {
+			report("must be a multiple of %v", multiple)
+		}
+	}
+}
+
+func (v *validator) countMatches(schemas []interface{}, value interface{}, path string) int {
+	matches := 0
+	for _, sub := range schemas {
+		subSchema, ok := sub.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var violations []Violation
+		v.validate(subSchema, value, path, &violations)
+		if len(violations) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch typ := t.(type) {
+	case string:
+		return matchesSingleType(typ, value)
+	case []interface{}:
+		for _, option := range typ {
+			if name, ok := option.(string); ok && matchesSingleType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(typ string, value interface{}) bool {
+	actual := jsonType(value)
+	switch typ {
+	case "number":
+		return actual == "integer" || actual == "number"
+	default:
+		return typ == actual
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
+
+func describeType(t interface{}) string {
+	if types, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(types))
+		for _, name := range types {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if equalValues(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares a schema value (decoded with float64 numbers) with a
+// document value (decoded with json.Number) by their canonical JSON encoding
+func equalValues(a, b interface{}) bool {
+	return encode(normalize(a)) == encode(normalize(b))
+}
+
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		f, err := value.Float64()
+		if err != nil {
+			return value.String()
+		}
+		return f
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = normalize(item)
+		}
+		return out
+	}
+	return v
+}
+
+func encode(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,230 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+)
+
+const personSchema = `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "age": {"type": "integer", "minimum": 0},
+    "role": {"type": "string", "enum": ["admin", "user"]},
+    "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+  },
+  "required": ["name", "age"],
+  "additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(personSchema))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		doc       string
+		wantError string
+	}{
+		{"valid", `{"name": "Ann", "age": 30, "role": "admin", "tags": ["a"]}`, ""},
+		{"valid with whitespace", "\n {\"name\": \"Ann\", \"age\": 30}\n", ""},
+		{"not json", `Sure! Here is the JSON`, "not valid JSON"},
+		{"missing required", `{"name": "Ann"}`, `missing required property "age"`},
+		{"wrong type", `{"name": "Ann", "age": "thirty"}`, "$.age: expected integer, got string"},
+		{"float for integer", `{"name": "Ann", "age": 30.5}`, "expected integer, got number"},
+		{"below minimum", `{"name": "Ann", "age": -1}`, "must be >= 0"},
+		{"enum", `{"name": "Ann", "age": 1, "role": "root"}`, "is not one of"},
+		{"extra property", `{"name": "Ann", "age": 1, "email": "x"}`, `unexpected property "email"`},
+		{"too many items", `{"name": "Ann", "age": 1, "tags": ["a", "b", "c"]}`, "at most 2 items"},
+		{"item type", `{"name": "Ann", "age": 1, "tags": [1]}`, "$.tags[0]: expected string"},
+		{"empty string", `{"name": "", "age": 1}`, "at least 1 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.doc))
+			if tt.wantError == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got none", tt.wantError)
+			}
+			if !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantError, err)
+			}
+			if !errors.Is(err, ErrMismatch) {
+				t.Errorf("Expected error to match ErrMismatch")
+			}
+		})
+	}
+}
+
+func TestValidateRefs(t *testing.T) {
+	// As generated by pydantic: the constraints live behind $ref
+	s, err := Parse([]byte(`{
+  "$defs": {
+    "Person": {
+      "type": "object",
+      "properties": {"name": {"type": "string"}, "age": {"type": "integer", "minimum": 0}},
+      "required": ["name", "age"]
+    },
+    "Node": {
+      "type": "object",
+      "properties": {"value": {"type": "integer"}, "children": {"type": "array", "items": {"$ref": "#/$defs/Node"}}}
+    }
+  },
+  "type": "object",
+  "properties": {
+    "owner": {"$ref": "#/$defs/Person"},
+    "tree": {"$ref": "#/$defs/Node"},
+    "self": {"$ref": "#"}
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		doc       string
+		wantError string
+	}{
+		{`{"owner": {"name": "Ann", "age": 30}, "tree": {"value": 1, "children": [{"value": 2}]}}`, ""},
+		{`{"owner": {"name": "Ann", "age": -1}}`, "$.owner.age: must be >= 0"},
+		{`{"owner": {"name": "Ann"}}`, `missing required property "age"`},
+		{`{"tree": {"children": [{"children": [{"value": "x"}]}]}}`, "$.tree.children[0].children[0].value: expected integer"},
+		{`{"self": {"owner": {"age": 1}}}`, `$.self.owner: missing required property "name"`},
+	}
+	for _, tt := range tests {
+		err := s.Validate([]byte(tt.doc))
+		if tt.wantError == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error, got: %v", tt.doc, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+			t.Errorf("%s: expected error containing %q, got: %v", tt.doc, tt.wantError, err)
+		}
+	}
+}
+
+func TestParseRejectsUncheckableSchemas(t *testing.T) {
+	for _, doc := range []string{
+		`{"properties": {"a": {"$ref": "https://example.com/a.json"}}}`,
+		`{"properties": {"a": {"$ref": "#/$defs/Missing"}}}`,
+		`{"type": "array", "prefixItems": [{"type": "string"}]}`,
+		`{"type": "array", "items": [{"type": "string"}]}`,
+		`{"$defs": {"Code": {"type": "string", "pattern": "([a-z"}}}`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("expected Parse to reject %s", doc)
+		}
+	}
+}
+
+func TestParseCompactsSchema(t *testing.T) {
+	s, err := Parse([]byte("{\n  \"type\": \"string\"\n}"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if string(s.Raw()) != `{"type":"string"}` {
+		t.Errorf("unexpected raw schema: %s", s.Raw())
+	}
+
+	if _, err := Parse([]byte("not a schema")); err == nil {
+		t.Error("Expected error for invalid schema")
+	}
+}
+
+func TestChatRepairsInvalidReply(t *testing.T) {
+	replies := []string{`{"name": "Ann"}`, `{"name": "Ann", "age": 30}`}
+	var requests []client.ChatRequest
+	var streams []*bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var req client.ChatRequest
+		json.Unmarshal(data, &req)
+		requests = append(requests, req)
+		var stream struct {
+			Stream *bool `json:"stream"`
+		}
+		json.Unmarshal(data, &stream)
+		streams = append(streams, stream.Stream)
+
+		reply := replies[len(requests)-1]
+		json.NewEncoder(w).Encode(client.ChatResponse{
+			Message: client.ChatMessage{Role: "assistant", Content: reply},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	s, _ := Parse([]byte(personSchema))
+	c := client.New(client.Options{BaseURL: server.URL})
+	req := client.ChatRequest{
+		Model:    "llama3",
+		Messages: []client.ChatMessage{{Role: "user", Content: "Describe Ann"}},
+	}
+
+	resp, err := Chat(context.Background(), c, req, s, 2)
+	if err != nil {
+		t.Fatalf("Expected repaired reply, got: %v", err)
+	}
+	if resp.Message.Content != replies[1] {
+		t.Errorf("unexpected reply: %s", resp.Message.Content)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	// Ollama streams by default, so a single reply must be asked for
+	for i, stream := range streams {
+		if stream == nil || *stream {
+			t.Errorf("request %d: expected an explicit \"stream\": false", i+1)
+		}
+	}
+	if string(requests[0].Format) != string(s.Raw()) {
+		t.Errorf("Expected schema to be sent as format, got %s", requests[0].Format)
+	}
+	repair := requests[1].Messages[len(requests[1].Messages)-1]
+	if repair.Role != "user" || !strings.Contains(repair.Content, `missing required property "age"`) {
+		t.Errorf("Expected repair prompt with violations, got: %+v", repair)
+	}
+}
+
+func TestGenerateGivesUpAfterMaxRepairs(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(client.GenerateResponse{Response: "not json", Done: true})
+	}))
+	defer server.Close()
+
+	s, _ := Parse([]byte(personSchema))
+	c := client.New(client.Options{BaseURL: server.URL})
+
+	resp, err := Generate(context.Background(), c, client.GenerateRequest{Model: "llama3", Prompt: "hi"}, s, 1)
+	if !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Expected ErrMismatch, got: %v", err)
+	}
+	if resp == nil || resp.Response != "not json" {
+		t.Errorf("Expected last response to be returned, got: %+v", resp)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
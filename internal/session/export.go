@@ -0,0 +1,39 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes a session as a readable Markdown document, used by
+// `sessions show` and `sessions export --format markdown`. Tool results
+// and thinking are left out.
+func (s *Session) WriteMarkdown(w io.Writer) error {
+	fmt.Fprintf(w, "# %s\n\n", s.Name)
+	fmt.Fprintf(w, "- Model: %s\n", s.Model)
+	fmt.Fprintf(w, "- Created: %s\n", s.Created.Local().Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "- Updated: %s\n", s.Updated.Local().Format("2006-01-02 15:04"))
+
+	for _, msg := range s.Messages {
+		var heading string
+		switch msg.Role {
+		case "system":
+			heading = "System"
+		case "user":
+			heading = "User"
+		case "assistant":
+			if msg.Content == "" {
+				// Only requested tools
+				continue
+			}
+			heading = "Assistant"
+		default:
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n## %s\n\n%s\n", heading, strings.TrimSpace(msg.Content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"ollamacli/internal/client"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+)
+
+// ErrNotFound is returned when no session has the given name or ID
+var ErrNotFound = errors.New("session not found")
+
+// Session is a saved conversation with the settings to carry it on
+type Session struct {
+	ID      int64
+	Name    string
+	Model   string
+	Think   string
+	Options map[string]interface{}
+	// Messages is the conversation history, including any system prompt
+	Messages []client.ChatMessage
+	// Branches keeps every version made with /retry and /edit; Branch is
+	// the ID of the one in Messages
+	Branches []Branch
+	Branch   int
+	Created  time.Time
+	Updated  time.Time
+}
+
+// Branch is one line of a conversation. /retry and /edit fork a new branch
+// from the message they regenerate, keeping the old answer reachable with
+// /branch checkout.
+type Branch struct {
+	ID int `json:"id"`
+	// Parent is the branch this one was forked from; 0 for the first
+	Parent int `json:"parent,omitempty"`
+	// ForkAt is the index of the first message that differs from Parent
+	ForkAt   int                  `json:"fork_at"`
+	Messages []client.ChatMessage `json:"messages"`
+}
+
+// Info summarizes a session for listings
+type Info struct {
+	ID       int64     `json:"id"`
+	Name     string    `json:"name"`
+	Model    string    `json:"model"`
+	Messages int       `json:"messages"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Store keeps sessions in a SQLite database. Every Save is a transaction,
+// so a session survives the process being killed between turns.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore opens the session database, creating its directory if needed
+func NewStore(dbPath string) (*Store, error) {
+	if dir := filepath.Dir(dbPath); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+
+	// WAL lets a second ollamacli read sessions while one is writing, and
+	// the busy timeout makes concurrent writers wait instead of failing
+	dsn := "file:" + dbPath + "?" + url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)"}}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Initialize creates the necessary tables and indexes
+func (s *Store) Initialize(ctx context.Context) error {
+	schema := `
+		CREATE TABLE IF NOT EXISTS sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			model TEXT NOT NULL,
+			think TEXT NOT NULL DEFAULT '',
+			options TEXT,
+			branches TEXT,
+			branch INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS messages (
+			session_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			message TEXT NOT NULL,
+			PRIMARY KEY (session_id, position)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_sessions_updated_at ON sessions(updated_at);
+	`
+
+	if _, err := s.db.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connection
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Create adds an empty session. An empty name is replaced by
+// "session-<id>".
+func (s *Store) Create(ctx context.Context, name, model string) (*Session, error) {
+	now := time.Now().UTC()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A placeholder keeps the UNIQUE constraint satisfied until the ID is known
+	insertName := name
+	if insertName == "" {
+		insertName = fmt.Sprintf("new-%d", now.UnixNano())
+	}
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO sessions (name, model, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		insertName, model, now, now)
+	if err != nil {
+		if s.exists(ctx, name) {
+			return nil, fmt.Errorf("a session named %q already exists", name)
+		}
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	if name == "" {
+		name = fmt.Sprintf("session-%d", id)
+		if _, err := tx.ExecContext(ctx, `UPDATE sessions SET name = ? WHERE id = ?`, name, id); err != nil {
+			return nil, fmt.Errorf("failed to name session: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &Session{ID: id, Name: name, Model: model, Created: now, Updated: now}, nil
+}
+
+func (s *Store) exists(ctx context.Context, name string) bool {
+	var id int64
+	return name != "" && s.db.QueryRowContext(ctx, `SELECT id FROM sessions WHERE name = ?`, name).Scan(&id) == nil
+}
+
+// Save writes the session's settings and replaces its messages
+func (s *Store) Save(ctx context.Context, sess *Session) error {
+	options, err := json.Marshal(sess.Options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal options: %w", err)
+	}
+	var branches sql.NullString
+	if len(sess.Branches) > 0 {
+		data, err := json.Marshal(sess.Branches)
+		if err != nil {
+			return fmt.Errorf("failed to marshal branches: %w", err)
+		}
+		branches = sql.NullString{String: string(data), Valid: true}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx,
+		`UPDATE sessions SET model = ?, think = ?, options = ?, branches = ?, branch = ?, updated_at = ? WHERE id = ?`,
+		sess.Model, sess.Think, string(options), branches, sess.Branch, now, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, sess.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE session_id = ?`, sess.ID); err != nil {
+		return fmt.Errorf("failed to replace messages: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO messages (session_id, position, role, content, message) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, msg := range sess.Messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx, sess.ID, i, msg.Role, msg.Content, string(data)); err != nil {
+			return fmt.Errorf("failed to insert message %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	sess.Updated = now
+	return nil
+}
+
+// Get loads a session by name, or by ID when no session has that name
+func (s *Store) Get(ctx context.Context, nameOrID string) (*Session, error) {
+	id, err := s.resolve(ctx, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(ctx, id)
+}
+
+// Latest loads the most recently updated session
+func (s *Store) Latest(ctx context.Context) (*Session, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM sessions ORDER BY updated_at DESC, id DESC LIMIT 1`).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	return s.load(ctx, id)
+}
+
+// List returns every session, most recently updated first
+func (s *Store) List(ctx context.Context) ([]Info, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.name, s.model, s.created_at, s.updated_at,
+			(SELECT COUNT(*) FROM messages m WHERE m.session_id = s.id)
+		FROM sessions s
+		ORDER BY s.updated_at DESC, s.id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var infos []Info
+	for rows.Next() {
+		var info Info
+		if err := rows.Scan(&info.ID, &info.Name, &info.Model, &info.Created, &info.Updated, &info.Messages); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		infos = append(infos, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return infos, nil
+}
+
+// Rename changes a session's name
+func (s *Store) Rename(ctx context.Context, nameOrID, newName string) error {
+	if newName == "" {
+		return fmt.Errorf("session name cannot be empty")
+	}
+	id, err := s.resolve(ctx, nameOrID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE sessions SET name = ? WHERE id = ?`, newName, id); err != nil {
+		if s.exists(ctx, newName) {
+			return fmt.Errorf("a session named %q already exists", newName)
+		}
+		return fmt.Errorf("failed to rename session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a session and its messages
+func (s *Store) Delete(ctx context.Context, nameOrID string) error {
+	id, err := s.resolve(ctx, nameOrID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE session_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// resolve finds a session ID by name, falling back to a numeric ID
+func (s *Store) resolve(ctx context.Context, nameOrID string) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM sessions WHERE name = ?`, nameOrID).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to query sessions: %w", err)
+	}
+
+	if n, convErr := strconv.ParseInt(nameOrID, 10, 64); convErr == nil {
+		err = s.db.QueryRowContext(ctx, `SELECT id FROM sessions WHERE id = ?`, n).Scan(&id)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("failed to query sessions: %w", err)
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrNotFound, nameOrID)
+}
+
+func (s *Store) load(ctx context.Context, id int64) (*Session, error) {
+	sess := &Session{ID: id}
+	var options, branches sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		`SELECT name, model, think, options, branches, branch, created_at, updated_at FROM sessions WHERE id = ?`, id,
+	).Scan(&sess.Name, &sess.Model, &sess.Think, &options, &branches, &sess.Branch, &sess.Created, &sess.Updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	if options.Valid && options.String != "" {
+		if err := json.Unmarshal([]byte(options.String), &sess.Options); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal options: %w", err)
+		}
+	}
+	if branches.Valid && branches.String != "" {
+		if err := json.Unmarshal([]byte(branches.String), &sess.Branches); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal branches: %w", err)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT message FROM messages WHERE session_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		var msg client.ChatMessage
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+		}
+		sess.Messages = append(sess.Messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return sess, nil
+}
@@ -0,0 +1,134 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ollamacli/internal/client"
+)
+
+func newTestStore(t *testing.T, path string) *Store {
+	t.Helper()
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return store
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "sessions.db")
+	store := newTestStore(t, path)
+
+	first, err := store.Create(ctx, "", "llama2")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.Name != "session-1" {
+		t.Errorf("expected a generated name, got %q", first.Name)
+	}
+	first.Options = map[string]interface{}{"temperature": 0.2}
+	first.Think = "hide"
+	first.Messages = []client.ChatMessage{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "Hello!", Thinking: "A greeting."},
+	}
+	first.Branches = []Branch{
+		{ID: 1, Messages: []client.ChatMessage{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hey."}}},
+		{ID: 2, Parent: 1, ForkAt: 2, Messages: first.Messages},
+	}
+	first.Branch = 2
+	if err := store.Save(ctx, first); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	second, err := store.Create(ctx, "review", "mistral")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.Create(ctx, "review", "mistral"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected a duplicate name error, got %v", err)
+	}
+	second.Messages = []client.ChatMessage{{Role: "user", Content: "Check this"}}
+	if err := store.Save(ctx, second); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Reopening the database, as after a crash, finds everything saved
+	store.Close()
+	store = newTestStore(t, path)
+
+	latest, err := store.Latest(ctx)
+	if err != nil || latest.Name != "review" {
+		t.Fatalf("expected the last saved session, got %+v: %v", latest, err)
+	}
+	got, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get by ID failed: %v", err)
+	}
+	if got.Model != "llama2" || got.Think != "hide" || got.Options["temperature"] != 0.2 {
+		t.Errorf("settings not saved: %+v", got)
+	}
+	if len(got.Messages) != 3 || got.Messages[2].Thinking != "A greeting." {
+		t.Errorf("messages not saved: %+v", got.Messages)
+	}
+	if len(got.Branches) != 2 || got.Branch != 2 || got.Branches[1].ForkAt != 2 || got.Branches[0].Messages[1].Content != "Hey." {
+		t.Errorf("branches not saved: %+v (current %d)", got.Branches, got.Branch)
+	}
+
+	// Saving replaces the history, e.g. after messages were removed
+	got.Messages = got.Messages[:1]
+	if err := store.Save(ctx, got); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	infos, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Name != "session-1" || infos[0].Messages != 1 || infos[1].Messages != 1 {
+		t.Errorf("unexpected listing: %+v", infos)
+	}
+
+	if err := store.Rename(ctx, "session-1", "greetings"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := store.Rename(ctx, "greetings", "review"); err == nil {
+		t.Error("expected renaming onto an existing name to fail")
+	}
+	if err := store.Delete(ctx, "greetings"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "greetings"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	s := &Session{Name: "review", Model: "llama2", Messages: []client.ChatMessage{
+		{Role: "system", Content: "Review code."},
+		{Role: "user", Content: "Look at this"},
+		{Role: "assistant", ToolCalls: []client.ToolCall{{}}},
+		{Role: "tool", Content: "file contents"},
+		{Role: "assistant", Content: "Looks good.\n"},
+	}}
+	var out strings.Builder
+	if err := s.WriteMarkdown(&out); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	md := out.String()
+	if !strings.HasPrefix(md, "# review\n") || !strings.Contains(md, "## System\n\nReview code.\n") || !strings.HasSuffix(md, "## Assistant\n\nLooks good.\n") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+	if strings.Contains(md, "file contents") || strings.Count(md, "## Assistant") != 1 {
+		t.Errorf("tool traffic should be left out:\n%s", md)
+	}
+}
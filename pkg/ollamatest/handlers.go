@@ -0,0 +1,645 @@
+package ollamatest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// stream writes NDJSON values one at a time, pausing between them
+type stream struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	delay   time.Duration
+	fault   *Fault
+	written int
+	started bool
+}
+
+func (s *Server) newStream(w http.ResponseWriter, r *http.Request, fault *Fault) *stream {
+	return &stream{w: w, r: r, delay: s.opts.ChunkDelay, fault: fault}
+}
+
+// send writes one value; it returns false when the stream must stop because
+// the client left or an injected mid-stream fault fired
+func (st *stream) send(v interface{}) bool {
+	if !st.started {
+		st.w.Header().Set("Content-Type", "application/x-ndjson")
+		st.w.WriteHeader(http.StatusOK)
+		st.started = true
+	} else if !sleep(st.r, st.delay) {
+		return false
+	}
+
+	if st.fault != nil && st.fault.MidStream && st.written >= st.fault.AfterChunks {
+		if st.fault.Drop {
+			drop(st.w)
+			return false
+		}
+		json.NewEncoder(st.w).Encode(map[string]string{"error": st.fault.Message})
+		st.flush()
+		return false
+	}
+
+	json.NewEncoder(st.w).Encode(v)
+	st.flush()
+	st.written++
+	return true
+}
+
+func (st *stream) flush() {
+	if flusher, ok := st.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// wantsStream applies Ollama's default of streaming unless told otherwise
+func wantsStream(flag *bool) bool {
+	return flag == nil || *flag
+}
+
+// lookup finds an installed model and loads it for keepAlive, answering 404
+// like Ollama when it is missing. A keep-alive of zero unloads the model.
+// load is the load time to report: the model's LoadDuration when it was not
+// loaded yet and 1ms otherwise.
+func (s *Server) lookup(w http.ResponseWriter, name string, keepAlive json.RawMessage) (model *modelState, load time.Duration, ok bool) {
+	duration, err := parseKeepAlive(keepAlive)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, 0, false
+	}
+
+	s.mu.Lock()
+	model, ok = s.models[normalizeName(name)]
+	load = time.Millisecond
+	if _, loaded := s.loaded[normalizeName(name)]; ok && !loaded && model.LoadDuration > 0 {
+		load = model.LoadDuration
+	}
+	if ok && duration == 0 {
+		delete(s.loaded, model.Name)
+	} else if ok {
+		s.loaded[model.Name] = duration
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found", name))
+	}
+	return model, load, ok
+}
+
+// parseKeepAlive reads a keep_alive value: a duration string or a number of
+// seconds, where negative values keep the model loaded forever
+func parseKeepAlive(raw json.RawMessage) (time.Duration, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return DefaultKeepAlive, nil
+	}
+
+	var duration time.Duration
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, fmt.Errorf("invalid keep_alive: %v", err)
+	}
+	switch v := value.(type) {
+	case float64:
+		duration = time.Duration(v * float64(time.Second))
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid keep_alive %q: %v", v, err)
+		}
+		duration = d
+	default:
+		return 0, fmt.Errorf("invalid keep_alive %s", raw)
+	}
+	if duration < 0 {
+		duration = -1
+	}
+	return duration, nil
+}
+
+func (s *Server) nextReply(model *modelState, req Request, prompt string) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return model.next(req, prompt)
+}
+
+func (s *Server) handleTags(w http.ResponseWriter) {
+	s.mu.Lock()
+	models := make([]map[string]interface{}, 0, len(s.order))
+	for _, name := range s.order {
+		models = append(models, s.modelInfo(s.models[name]))
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"models": models})
+}
+
+func (s *Server) handlePs(w http.ResponseWriter) {
+	s.mu.Lock()
+	models := make([]map[string]interface{}, 0, len(s.loaded))
+	for _, name := range s.order {
+		keepAlive, ok := s.loaded[name]
+		if !ok {
+			continue
+		}
+		// Like Ollama, a model kept forever expires in the distant future
+		if keepAlive < 0 {
+			keepAlive = math.MaxInt64
+		}
+		info := s.modelInfo(s.models[name])
+		info["size_vram"] = s.models[name].size()
+		info["expires_at"] = Epoch.Add(keepAlive)
+		models = append(models, info)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"models": models})
+}
+
+// modelInfo renders a model as listed by /api/tags; callers hold s.mu
+func (s *Server) modelInfo(model *modelState) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        model.Name,
+		"model":       model.Name,
+		"modified_at": Epoch,
+		"size":        model.size(),
+		"digest":      digest(model.Name),
+		"details":     details(model.Model),
+	}
+}
+
+func details(model Model) map[string]interface{} {
+	return map[string]interface{}{
+		"format":             "gguf",
+		"family":             model.Family,
+		"families":           []string{model.Family},
+		"parameter_size":     model.ParameterSize,
+		"quantization_level": model.QuantizationLevel,
+	}
+}
+
+func (s *Server) handleShow(w http.ResponseWriter, req Request) {
+	name := req.Model()
+	s.mu.Lock()
+	model, ok := s.models[normalizeName(name)]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found", name))
+		return
+	}
+
+	modelfile := model.Modelfile
+	if modelfile == "" {
+		modelfile = "FROM " + model.Name + "\n"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"modelfile":    modelfile,
+		"template":     "{{ .Prompt }}",
+		"details":      details(model.Model),
+		"capabilities": model.capabilities(),
+		"modified_at":  Epoch,
+	})
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request, req Request, fault *Fault) {
+	var body ChatRequest
+	if err := req.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	model, load, ok := s.lookup(w, body.Model, body.KeepAlive)
+	if !ok || !supportsThink(w, model, body.Think) {
+		return
+	}
+
+	var prompt string
+	texts := make([]string, 0, len(body.Messages))
+	for _, msg := range body.Messages {
+		texts = append(texts, msg.Content)
+		if msg.Role == "user" {
+			prompt = msg.Content
+		}
+	}
+	reply := s.nextReply(model, req, prompt)
+	stats := replyStats(wordCount(texts...), reply.Content, load)
+	thinking := replyThinking(reply, body.Think)
+
+	message := func(content string, calls []ToolCall) map[string]interface{} {
+		msg := map[string]interface{}{"role": "assistant", "content": content}
+		if len(calls) > 0 {
+			msg["tool_calls"] = calls
+		}
+		return msg
+	}
+
+	if !wantsStream(body.Stream) {
+		msg := message(reply.Content, reply.ToolCalls)
+		if thinking != "" {
+			msg["thinking"] = thinking
+		}
+		final := map[string]interface{}{"model": body.Model, "created_at": Epoch, "message": msg, "done": true, "done_reason": "stop"}
+		writeJSON(w, http.StatusOK, merge(final, stats))
+		return
+	}
+
+	st := s.newStream(w, r, fault)
+	for _, piece := range chunks(thinking) {
+		msg := message("", nil)
+		msg["thinking"] = piece
+		if !st.send(map[string]interface{}{"model": body.Model, "created_at": Epoch, "message": msg, "done": false}) {
+			return
+		}
+	}
+	for _, piece := range chunks(reply.Content) {
+		if !st.send(map[string]interface{}{"model": body.Model, "created_at": Epoch, "message": message(piece, nil), "done": false}) {
+			return
+		}
+	}
+	if len(reply.ToolCalls) > 0 {
+		if !st.send(map[string]interface{}{"model": body.Model, "created_at": Epoch, "message": message("", reply.ToolCalls), "done": false}) {
+			return
+		}
+	}
+	st.send(merge(map[string]interface{}{"model": body.Model, "created_at": Epoch, "message": message("", nil), "done": true, "done_reason": "stop"}, stats))
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request, req Request, fault *Fault) {
+	var body GenerateRequest
+	if err := req.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	model, load, ok := s.lookup(w, body.Model, body.KeepAlive)
+	if !ok {
+		return
+	}
+
+	// An empty prompt only loads or unloads the model, as with Ollama
+	if body.Prompt == "" {
+		reason := "load"
+		if duration, _ := parseKeepAlive(body.KeepAlive); duration == 0 {
+			reason = "unload"
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"model": body.Model, "created_at": Epoch, "response": "", "done": true, "done_reason": reason})
+		return
+	}
+
+	if !supportsThink(w, model, body.Think) {
+		return
+	}
+	reply := s.nextReply(model, req, body.Prompt)
+	stats := replyStats(wordCount(body.System, body.Prompt), reply.Content, load)
+	thinking := replyThinking(reply, body.Think)
+
+	if !wantsStream(body.Stream) {
+		final := map[string]interface{}{"model": body.Model, "created_at": Epoch, "response": reply.Content, "done": true, "done_reason": "stop"}
+		if thinking != "" {
+			final["thinking"] = thinking
+		}
+		writeJSON(w, http.StatusOK, merge(final, stats))
+		return
+	}
+
+	st := s.newStream(w, r, fault)
+	for _, piece := range chunks(thinking) {
+		if !st.send(map[string]interface{}{"model": body.Model, "created_at": Epoch, "response": "", "thinking": piece, "done": false}) {
+			return
+		}
+	}
+	for _, piece := range chunks(reply.Content) {
+		if !st.send(map[string]interface{}{"model": body.Model, "created_at": Epoch, "response": piece, "done": false}) {
+			return
+		}
+	}
+	st.send(merge(map[string]interface{}{"model": body.Model, "created_at": Epoch, "response": "", "done": true, "done_reason": "stop"}, stats))
+}
+
+// supportsThink rejects think=true for models without the thinking
+// capability, answering 400 like Ollama
+func supportsThink(w http.ResponseWriter, model *modelState, think *bool) bool {
+	if think == nil || !*think {
+		return true
+	}
+	for _, capability := range model.capabilities() {
+		if capability == "thinking" {
+			return true
+		}
+	}
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("%q does not support thinking", model.Name))
+	return false
+}
+
+// replyThinking returns the thinking to send, which think=false suppresses
+func replyThinking(reply Reply, think *bool) string {
+	if think != nil && !*think {
+		return ""
+	}
+	return reply.Thinking
+}
+
+// replyStats returns deterministic counts and durations: one token per word
+// and 10ms per token
+func replyStats(promptTokens int, content string, load time.Duration) map[string]interface{} {
+	evalCount := len(chunks(content))
+	const perToken = int64(10 * time.Millisecond)
+	return map[string]interface{}{
+		"prompt_eval_count":    promptTokens,
+		"prompt_eval_duration": int64(promptTokens) * perToken / 10,
+		"eval_count":           evalCount,
+		"eval_duration":        int64(evalCount) * perToken,
+		"load_duration":        int64(load),
+		"total_duration":       int64(load) + int64(promptTokens)*perToken/10 + int64(evalCount)*perToken,
+	}
+}
+
+func merge(a, b map[string]interface{}) map[string]interface{} {
+	for k, v := range b {
+		a[k] = v
+	}
+	return a
+}
+
+func (s *Server) handleEmbed(w http.ResponseWriter, req Request) {
+	var body EmbedRequest
+	if err := req.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, _, ok := s.lookup(w, body.Model, body.KeepAlive); !ok {
+		return
+	}
+
+	inputs := body.Inputs()
+	if req.Path == "/api/embeddings" {
+		var vector []float64
+		if len(inputs) > 0 {
+			vector = Embedding(inputs[0], s.opts.EmbeddingDim)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"embedding": vector})
+		return
+	}
+
+	embeddings := make([][]float64, len(inputs))
+	for i, input := range inputs {
+		embeddings[i] = Embedding(input, s.opts.EmbeddingDim)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"model": body.Model, "embeddings": embeddings})
+}
+
+// Embedding returns the deterministic vector the fake produces for text. Each
+// lower-cased word is hashed into one dimension, so texts that share words
+// have a high cosine similarity.
+func Embedding(text string, dim int) []float64 {
+	if dim <= 0 {
+		dim = DefaultEmbeddingDim
+	}
+	vector := make([]float64, dim)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		sign := 1.0
+		if sum&(1<<63) != 0 {
+			sign = -1
+		}
+		vector[sum%uint64(dim)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
+
+func (s *Server) handlePull(w http.ResponseWriter, r *http.Request, req Request, fault *Fault) {
+	var body struct {
+		Model  string `json:"model"`
+		Name   string `json:"name"`
+		Stream *bool  `json:"stream"`
+	}
+	if err := req.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := body.Model
+	if name == "" {
+		name = body.Name
+	}
+
+	model := Model{Name: name}
+	if s.registry != nil {
+		known, ok := s.registry[normalizeName(name)]
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "pull model manifest: file does not exist")
+			return
+		}
+		model = known
+	}
+
+	size := (&modelState{Model: model}).size()
+	layer := digest(name)
+	steps := []map[string]interface{}{{"status": "pulling manifest"}}
+	for _, part := range []int64{0, size / 4, size / 2, size * 3 / 4, size} {
+		steps = append(steps, map[string]interface{}{
+			"status":    "pulling " + strings.TrimPrefix(layer, "sha256:")[:12],
+			"digest":    layer,
+			"total":     size,
+			"completed": part,
+		})
+	}
+	steps = append(steps,
+		map[string]interface{}{"status": "verifying sha256 digest"},
+		map[string]interface{}{"status": "writing manifest"},
+		map[string]interface{}{"status": "success"},
+	)
+
+	s.finishProgress(w, r, fault, body.Stream, steps, func() { s.AddModel(model) })
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request, req Request, fault *Fault) {
+	var body struct {
+		Model     string            `json:"model"`
+		Name      string            `json:"name"`
+		From      string            `json:"from"`
+		Files     map[string]string `json:"files"`
+		Modelfile string            `json:"modelfile"`
+		Quantize  string            `json:"quantize"`
+		Stream    *bool             `json:"stream"`
+	}
+	if err := req.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := body.Model
+	if name == "" {
+		name = body.Name
+	}
+
+	base := body.From
+	for _, line := range strings.Split(body.Modelfile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && strings.EqualFold(fields[0], "FROM") {
+			base = fields[1]
+		}
+	}
+
+	// Weights must have been uploaded as blobs first
+	digests := make([]string, 0, len(body.Files)+1)
+	if strings.HasPrefix(base, "@") {
+		digests = append(digests, strings.TrimPrefix(base, "@"))
+	}
+	for _, digest := range body.Files {
+		digests = append(digests, digest)
+	}
+	for _, digest := range digests {
+		if _, ok := s.Blob(digest); !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("blob %s not found", digest))
+			return
+		}
+	}
+
+	model := Model{Name: name, QuantizationLevel: strings.ToUpper(body.Quantize), Modelfile: body.Modelfile}
+	s.mu.Lock()
+	if parent, ok := s.models[normalizeName(base)]; ok {
+		model.Family = parent.Family
+		model.ParameterSize = parent.ParameterSize
+		model.Capabilities = parent.Capabilities
+		model.Size = parent.Size
+	}
+	s.mu.Unlock()
+
+	steps := []map[string]interface{}{
+		{"status": "reading model metadata"},
+		{"status": "using existing layer " + digest(base)},
+	}
+	if body.Quantize != "" {
+		steps = append(steps, map[string]interface{}{"status": "quantizing model to " + strings.ToUpper(body.Quantize)})
+	}
+	steps = append(steps,
+		map[string]interface{}{"status": "writing manifest"},
+		map[string]interface{}{"status": "success"},
+	)
+
+	s.finishProgress(w, r, fault, body.Stream, steps, func() { s.AddModel(model) })
+}
+
+// finishProgress streams progress steps, running done just before the final
+// one so the result is visible by the time the client sees "success"
+func (s *Server) finishProgress(w http.ResponseWriter, r *http.Request, fault *Fault, streamFlag *bool, steps []map[string]interface{}, done func()) {
+	if !wantsStream(streamFlag) {
+		done()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+		return
+	}
+
+	st := s.newStream(w, r, fault)
+	for i, step := range steps {
+		if i == len(steps)-1 {
+			done()
+		}
+		if !st.send(step) {
+			return
+		}
+	}
+}
+
+func (s *Server) handleCopy(w http.ResponseWriter, req Request) {
+	var body struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	}
+	if err := req.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	source, ok := s.models[normalizeName(body.Source)]
+	if ok {
+		model := source.Model
+		model.Name = body.Destination
+		model.Loaded = false
+		s.addModel(model)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found", body.Source))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, req Request) {
+	name := req.Model()
+	s.mu.Lock()
+	removed := s.removeModel(name)
+	s.mu.Unlock()
+
+	if !removed {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model '%s' not found", name))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleBlob(w http.ResponseWriter, r *http.Request, req Request) {
+	digestName := strings.TrimPrefix(r.URL.Path, "/api/blobs/")
+
+	switch r.Method {
+	case http.MethodHead:
+		s.mu.Lock()
+		_, ok := s.blobs[digestName]
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodPost:
+		sum := sha256.Sum256(req.Body)
+		if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digestName {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("digest mismatch, expected %q, got %q", digestName, actual))
+			return
+		}
+		s.mu.Lock()
+		s.blobs[digestName] = bytes.Clone(req.Body)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// Blob returns an uploaded blob by digest
+func (s *Server) Blob(digest string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[digest]
+	return data, ok
+}
+
+// digest derives a stable fake layer digest from a model name
+func digest(name string) string {
+	sum := sha256.Sum256([]byte(normalizeName(name)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
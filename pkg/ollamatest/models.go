@@ -0,0 +1,187 @@
+package ollamatest
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Model is a scripted model served by the fake
+type Model struct {
+	Name              string
+	Family            string
+	ParameterSize     string
+	QuantizationLevel string
+	// Capabilities reported by /api/show (default: completion)
+	Capabilities []string
+	// Size in bytes reported by /api/tags and pull progress
+	Size int64
+	// Loaded models are reported by /api/ps from the start; others appear
+	// once they have been used
+	Loaded bool
+	// Replies are returned in order by chat and generate; the last one
+	// repeats once the script runs out
+	Replies []Reply
+	// Respond computes replies instead of Replies
+	Respond func(Request) Reply
+	// Modelfile returned by /api/show (default: "FROM <name>")
+	Modelfile string
+	// LoadDuration is reported by requests that find the model unloaded,
+	// to simulate a cold start; warm requests report 1ms
+	LoadDuration time.Duration
+}
+
+// Reply is one scripted answer
+type Reply struct {
+	Content   string
+	ToolCalls []ToolCall
+	// Thinking is streamed before Content unless the request turns
+	// thinking off
+	Thinking string
+}
+
+// TextReplies builds a script of plain text replies
+func TextReplies(texts ...string) []Reply {
+	replies := make([]Reply, len(texts))
+	for i, text := range texts {
+		replies[i] = Reply{Content: text}
+	}
+	return replies
+}
+
+type modelState struct {
+	Model
+	served int
+}
+
+// next returns the reply for a request, advancing the script. Unscripted
+// models echo the last user message or prompt.
+func (m *modelState) next(req Request, prompt string) Reply {
+	if m.Respond != nil {
+		return m.Respond(req)
+	}
+	if len(m.Replies) == 0 {
+		return Reply{Content: "echo: " + prompt}
+	}
+	index := m.served
+	if index >= len(m.Replies) {
+		index = len(m.Replies) - 1
+	}
+	m.served++
+	return m.Replies[index]
+}
+
+func (m *modelState) capabilities() []string {
+	if len(m.Capabilities) == 0 {
+		return []string{"completion"}
+	}
+	return m.Capabilities
+}
+
+func (m *modelState) size() int64 {
+	if m.Size == 0 {
+		return 1000
+	}
+	return m.Size
+}
+
+// The wire types below mirror the Ollama API so tests can Decode recorded
+// requests without depending on a particular client package.
+
+// ChatRequest is the body of /api/chat
+type ChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []Message              `json:"messages"`
+	Stream    *bool                  `json:"stream,omitempty"`
+	Format    json.RawMessage        `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	Tools     []json.RawMessage      `json:"tools,omitempty"`
+	KeepAlive json.RawMessage        `json:"keep_alive,omitempty"`
+	Think     *bool                  `json:"think,omitempty"`
+}
+
+// Message is a chat message
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+	Thinking  string     `json:"thinking,omitempty"`
+}
+
+// ToolCall is a model request to call a tool
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// GenerateRequest is the body of /api/generate
+type GenerateRequest struct {
+	Model     string                 `json:"model"`
+	Prompt    string                 `json:"prompt"`
+	System    string                 `json:"system,omitempty"`
+	Stream    *bool                  `json:"stream,omitempty"`
+	Format    json.RawMessage        `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	Images    []string               `json:"images,omitempty"`
+	KeepAlive json.RawMessage        `json:"keep_alive,omitempty"`
+	Think     *bool                  `json:"think,omitempty"`
+}
+
+// EmbedRequest is the body of /api/embed; Input is a string or a list of strings
+type EmbedRequest struct {
+	Model     string          `json:"model"`
+	Input     json.RawMessage `json:"input"`
+	Prompt    string          `json:"prompt,omitempty"`
+	KeepAlive json.RawMessage `json:"keep_alive,omitempty"`
+}
+
+// Inputs returns the texts to embed
+func (r EmbedRequest) Inputs() []string {
+	var list []string
+	if err := json.Unmarshal(r.Input, &list); err == nil {
+		return list
+	}
+	var single string
+	if err := json.Unmarshal(r.Input, &single); err == nil {
+		return []string{single}
+	}
+	if r.Prompt != "" {
+		return []string{r.Prompt}
+	}
+	return nil
+}
+
+// wordCount stands in for a token count
+func wordCount(texts ...string) int {
+	count := 0
+	for _, text := range texts {
+		count += len(strings.Fields(text))
+	}
+	return count
+}
+
+// chunks splits a reply into the pieces it is streamed in: one word each,
+// together with the whitespace that follows it
+func chunks(content string) []string {
+	var pieces []string
+	start := 0
+	inWord := false
+	for i, r := range content {
+		space := r == ' ' || r == '\n' || r == '\t'
+		if !space && !inWord && i > 0 && start < i && strings.TrimSpace(content[start:i]) != "" {
+			pieces = append(pieces, content[start:i])
+			start = i
+		}
+		inWord = !space
+	}
+	if start < len(content) {
+		pieces = append(pieces, content[start:])
+	}
+	return pieces
+}
@@ -0,0 +1,430 @@
+// Package ollamatest provides an in-process fake Ollama server for tests.
+//
+// The fake serves scripted models with deterministic streaming replies,
+// hash-based embeddings and pull/create progress, and can inject latency and
+// errors. Every request is recorded so tests can assert on what was sent.
+// It has no dependency on the client packages, so any code that speaks the
+// Ollama HTTP API can be tested against it.
+package ollamatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// DefaultEmbeddingDim is the length of embedding vectors when Options sets none
+	DefaultEmbeddingDim = 64
+	// DefaultVersion is reported by /api/version when Options sets none
+	DefaultVersion = "0.0.0-ollamatest"
+	// DefaultKeepAlive is how long a model stays loaded when a request sets
+	// no keep_alive, as with Ollama
+	DefaultKeepAlive = 5 * time.Minute
+)
+
+// Epoch is the timestamp reported for every reply and model, keeping
+// responses byte-for-byte reproducible
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Options configures a fake server
+type Options struct {
+	// Models are installed when the server starts
+	Models []Model
+	// Registry lists the models that can be pulled; nil allows any name
+	Registry []Model
+	// Latency delays every response
+	Latency time.Duration
+	// ChunkDelay is the pause between streamed chunks
+	ChunkDelay time.Duration
+	// EmbeddingDim is the length of embedding vectors (default 64)
+	EmbeddingDim int
+	// Version is reported by /api/version
+	Version string
+	// Token, when set, must be sent as a Bearer token
+	Token string
+}
+
+// Server is a fake Ollama server. Use URL as the client's base URL.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	opts     Options
+	models   map[string]*modelState
+	order    []string
+	registry map[string]Model
+	// loaded maps each loaded model to its keep-alive; negative is forever
+	loaded   map[string]time.Duration
+	blobs    map[string][]byte
+	faults   []*faultState
+	requests []Request
+}
+
+// New starts a fake server that is closed when the test ends
+func New(t testing.TB, opts Options) *Server {
+	t.Helper()
+	s := NewServer(opts)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewServer starts a fake server; the caller must Close it
+func NewServer(opts Options) *Server {
+	if opts.EmbeddingDim <= 0 {
+		opts.EmbeddingDim = DefaultEmbeddingDim
+	}
+	if opts.Version == "" {
+		opts.Version = DefaultVersion
+	}
+
+	s := &Server{
+		opts:   opts,
+		models: make(map[string]*modelState),
+		loaded: make(map[string]time.Duration),
+		blobs:  make(map[string][]byte),
+	}
+	for _, model := range opts.Models {
+		s.addModel(model)
+	}
+	if opts.Registry != nil {
+		s.registry = make(map[string]Model)
+		for _, model := range opts.Registry {
+			s.registry[normalizeName(model.Name)] = model
+		}
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddModel installs a model, replacing any model with the same name
+func (s *Server) AddModel(model Model) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addModel(model)
+}
+
+func (s *Server) addModel(model Model) {
+	name := normalizeName(model.Name)
+	if _, exists := s.models[name]; !exists {
+		s.order = append(s.order, name)
+	}
+	model.Name = name
+	s.models[name] = &modelState{Model: model}
+	if model.Loaded {
+		s.loaded[name] = DefaultKeepAlive
+	}
+}
+
+func (s *Server) removeModel(name string) bool {
+	name = normalizeName(name)
+	if _, ok := s.models[name]; !ok {
+		return false
+	}
+	delete(s.models, name)
+	delete(s.loaded, name)
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// HasModel reports whether a model is currently installed
+func (s *Server) HasModel(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.models[normalizeName(name)]
+	return ok
+}
+
+// IsLoaded reports whether a model is currently loaded, as listed by /api/ps
+func (s *Server) IsLoaded(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.loaded[normalizeName(name)]
+	return ok
+}
+
+// Request is a recorded request
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the JSON body into v, e.g. a *ChatRequest
+func (r Request) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Model returns the model named in the body, if any
+func (r Request) Model() string {
+	var body struct {
+		Model string `json:"model"`
+		Name  string `json:"name"`
+	}
+	if err := r.Decode(&body); err != nil {
+		return ""
+	}
+	if body.Model != "" {
+		return body.Model
+	}
+	return body.Name
+}
+
+// Requests returns every request received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the requests received for an endpoint path
+func (s *Server) RequestsTo(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []Request
+	for _, req := range s.requests {
+		if req.Path == path {
+			matched = append(matched, req)
+		}
+	}
+	return matched
+}
+
+// LastRequest returns the most recent request for an endpoint path
+func (s *Server) LastRequest(path string) (Request, bool) {
+	requests := s.RequestsTo(path)
+	if len(requests) == 0 {
+		return Request{}, false
+	}
+	return requests[len(requests)-1], true
+}
+
+// ResetRequests forgets the recorded requests
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// AssertRequestCount fails the test unless path was requested exactly want times
+func (s *Server) AssertRequestCount(t testing.TB, path string, want int) {
+	t.Helper()
+	if got := len(s.RequestsTo(path)); got != want {
+		t.Errorf("ollamatest: %s requested %d times, want %d", path, got, want)
+	}
+}
+
+// AssertRequested fails the test unless some request to path satisfies match
+func (s *Server) AssertRequested(t testing.TB, path string, match func(Request) bool) {
+	t.Helper()
+	requests := s.RequestsTo(path)
+	for _, req := range requests {
+		if match(req) {
+			return
+		}
+	}
+	t.Errorf("ollamatest: none of the %d requests to %s matched", len(requests), path)
+}
+
+// Fault makes matching requests slow or fail
+type Fault struct {
+	// Path is the endpoint to affect, e.g. "/api/chat"; empty matches all
+	Path string
+	// Model restricts the fault to requests for one model
+	Model string
+	// Times limits the fault to the first n matching requests; 0 means all
+	Times int
+	// Delay is extra latency; a Fault with only Delay set does not fail
+	Delay time.Duration
+	// Status is the HTTP error status (default 500)
+	Status int
+	// Message is the error text (default: the status text)
+	Message string
+	// MidStream fails a streaming reply with an in-stream error after
+	// AfterChunks chunks instead of failing up front
+	MidStream   bool
+	AfterChunks int
+	// Drop closes the connection without answering, like a crashed server;
+	// with MidStream the connection is closed after AfterChunks chunks
+	Drop bool
+}
+
+func (f Fault) fails() bool {
+	return f.Status != 0 || f.Message != "" || f.MidStream || f.Drop || f.Delay == 0
+}
+
+type faultState struct {
+	Fault
+	used int
+}
+
+// Inject adds a fault; faults are checked in the order they were added
+func (s *Server) Inject(fault Fault) {
+	if fault.fails() && fault.Status == 0 {
+		fault.Status = http.StatusInternalServerError
+	}
+	if fault.fails() && fault.Message == "" {
+		fault.Message = strings.ToLower(http.StatusText(fault.Status))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &faultState{Fault: fault})
+}
+
+// ClearFaults removes every injected fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault returns the first active fault matching the request and counts its use
+func (s *Server) takeFault(path, model string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.faults {
+		if f.Path != "" && f.Path != path {
+			continue
+		}
+		if f.Model != "" && normalizeName(f.Model) != normalizeName(model) {
+			continue
+		}
+		if f.Times > 0 && f.used >= f.Times {
+			continue
+		}
+		f.used++
+		fault := f.Fault
+		return &fault
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	if !sleep(r, s.opts.Latency) {
+		return
+	}
+
+	if s.opts.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.opts.Token {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	fault := s.takeFault(r.URL.Path, req.Model())
+	if fault != nil {
+		if !sleep(r, fault.Delay) {
+			return
+		}
+		if fault.Drop && !fault.MidStream {
+			drop(w)
+			return
+		}
+		if !fault.fails() {
+			fault = nil
+		} else if !fault.MidStream {
+			writeError(w, fault.Status, fault.Message)
+			return
+		}
+	}
+
+	s.route(w, r, req, fault)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, req Request, fault *Fault) {
+	switch {
+	case r.URL.Path == "/api/version":
+		writeJSON(w, http.StatusOK, map[string]string{"version": s.opts.Version})
+	case r.URL.Path == "/api/tags":
+		s.handleTags(w)
+	case r.URL.Path == "/api/ps":
+		s.handlePs(w)
+	case r.URL.Path == "/api/show":
+		s.handleShow(w, req)
+	case r.URL.Path == "/api/chat":
+		s.handleChat(w, r, req, fault)
+	case r.URL.Path == "/api/generate":
+		s.handleGenerate(w, r, req, fault)
+	case r.URL.Path == "/api/embed" || r.URL.Path == "/api/embeddings":
+		s.handleEmbed(w, req)
+	case r.URL.Path == "/api/pull":
+		s.handlePull(w, r, req, fault)
+	case r.URL.Path == "/api/create":
+		s.handleCreate(w, r, req, fault)
+	case r.URL.Path == "/api/copy":
+		s.handleCopy(w, req)
+	case r.URL.Path == "/api/delete":
+		s.handleDelete(w, req)
+	case strings.HasPrefix(r.URL.Path, "/api/blobs/"):
+		s.handleBlob(w, r, req)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("404 page not found: %s", r.URL.Path))
+	}
+}
+
+// normalizeName adds the implicit ":latest" tag
+func normalizeName(name string) string {
+	name = strings.TrimSpace(name)
+	if name != "" && strings.LastIndex(name, ":") <= strings.LastIndex(name, "/") {
+		return name + ":latest"
+	}
+	return name
+}
+
+// sleep waits for d unless the client goes away first
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func drop(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, http.StatusBadGateway, "connection dropped")
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
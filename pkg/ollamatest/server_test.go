@@ -0,0 +1,246 @@
+package ollamatest_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"ollamacli/internal/client"
+	"ollamacli/pkg/ollamatest"
+)
+
+func newClient(srv *ollamatest.Server) *client.Client {
+	return client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second, Retries: 1, RetryDelay: time.Millisecond})
+}
+
+func TestScriptedChatStream(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{
+		Models: []ollamatest.Model{{Name: "llama2", Replies: ollamatest.TextReplies("Hello there world", "Second")}},
+	})
+	c := newClient(srv)
+
+	stream, err := c.ChatStream(context.Background(), client.ChatRequest{
+		Model:    "llama2",
+		Messages: []client.ChatMessage{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var chunks []string
+	var final client.ChatResponse
+	for resp := range stream {
+		if resp.Err != nil {
+			t.Fatalf("stream error: %v", resp.Err)
+		}
+		if resp.Done {
+			final = resp
+			continue
+		}
+		chunks = append(chunks, resp.Message.Content)
+	}
+	if want := []string{"Hello ", "there ", "world"}; strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("chunks = %q, want %q", chunks, want)
+	}
+	if final.EvalCount != 3 || final.PromptEvalCount != 1 {
+		t.Errorf("counts = %d/%d, want 3/1", final.EvalCount, final.PromptEvalCount)
+	}
+
+	// Non-streaming calls get the next reply in the script, and the last
+	// reply repeats once it runs out
+	for i := 0; i < 2; i++ {
+		resp, err := c.Chat(context.Background(), client.ChatRequest{
+			Model:    "llama2",
+			Messages: []client.ChatMessage{{Role: "user", Content: "again"}},
+		})
+		if err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+		if resp.Message.Content != "Second" {
+			t.Errorf("reply %d = %q, want %q", i, resp.Message.Content, "Second")
+		}
+	}
+
+	srv.AssertRequestCount(t, "/api/chat", 3)
+	srv.AssertRequested(t, "/api/chat", func(req ollamatest.Request) bool {
+		var body ollamatest.ChatRequest
+		return req.Decode(&body) == nil && body.Stream != nil && !*body.Stream
+	})
+}
+
+func TestGenerateEchoAndUnknownModel(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "llama2"}}})
+	c := newClient(srv)
+
+	resp, err := c.Generate(context.Background(), client.GenerateRequest{Model: "llama2:latest", Prompt: "ping"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Response != "echo: ping" || !resp.Done {
+		t.Errorf("response = %q (done %v), want echo", resp.Response, resp.Done)
+	}
+
+	_, err = c.Generate(context.Background(), client.GenerateRequest{Model: "missing", Prompt: "ping"})
+	if !errors.Is(err, client.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestEmbeddingsAreDeterministic(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Models: []ollamatest.Model{{Name: "embed"}}, EmbeddingDim: 32})
+	c := newClient(srv)
+
+	resp, err := c.Embed(context.Background(), client.EmbedRequest{
+		Model: "embed",
+		Input: []string{"the cat sat", "The cat sat!", "quantum chromodynamics"},
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(resp.Embeddings) != 3 || len(resp.Embeddings[0]) != 32 {
+		t.Fatalf("unexpected embeddings shape: %d", len(resp.Embeddings))
+	}
+
+	dot := func(a, b []float64) float64 {
+		var sum float64
+		for i := range a {
+			sum += a[i] * b[i]
+		}
+		return sum
+	}
+	if same := dot(resp.Embeddings[0], resp.Embeddings[1]); same < 0.999 {
+		t.Errorf("equivalent texts have similarity %f, want 1", same)
+	}
+	if other := dot(resp.Embeddings[0], resp.Embeddings[2]); other > 0.9 {
+		t.Errorf("unrelated texts have similarity %f", other)
+	}
+}
+
+func TestPullProgressAndRegistry(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{
+		Registry: []ollamatest.Model{{Name: "mistral", Size: 4000}},
+	})
+	c := newClient(srv)
+
+	progress, err := c.PullModel(context.Background(), client.PullRequest{Name: "mistral"})
+	if err != nil {
+		t.Fatalf("PullModel failed: %v", err)
+	}
+	var statuses []string
+	var completed int64
+	for p := range progress {
+		if p.Err != nil {
+			t.Fatalf("pull error: %v", p.Err)
+		}
+		statuses = append(statuses, p.Status)
+		if p.Completed > completed {
+			completed = p.Completed
+		}
+	}
+	if statuses[0] != "pulling manifest" || statuses[len(statuses)-2] != "success" || statuses[len(statuses)-1] != client.PullStatusVerified {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+	if completed != 4000 {
+		t.Errorf("completed = %d, want 4000", completed)
+	}
+	if !srv.HasModel("mistral") {
+		t.Error("pulled model should be installed")
+	}
+
+	progress, err = c.PullModel(context.Background(), client.PullRequest{Name: "unknown"})
+	if err != nil {
+		t.Fatalf("PullModel failed: %v", err)
+	}
+	var pullErr error
+	for p := range progress {
+		if p.Err != nil {
+			pullErr = p.Err
+		}
+	}
+	if pullErr == nil {
+		t.Error("pulling a model missing from the registry should fail")
+	}
+}
+
+func TestInjectedFaults(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{
+		Models: []ollamatest.Model{{Name: "llama2", Replies: ollamatest.TextReplies("one two three four")}},
+	})
+	c := newClient(srv)
+	ctx := context.Background()
+
+	// One failure is retried away; two exhaust the retry budget
+	srv.Inject(ollamatest.Fault{Path: "/api/tags", Status: http.StatusServiceUnavailable, Times: 1})
+	if _, err := c.ListModels(ctx); err != nil {
+		t.Errorf("retry should recover from a single fault: %v", err)
+	}
+	srv.AssertRequestCount(t, "/api/tags", 2)
+
+	srv.Inject(ollamatest.Fault{Path: "/api/tags", Status: http.StatusServiceUnavailable, Times: 2})
+	if _, err := c.ListModels(ctx); !errors.Is(err, client.ErrServerUnavailable) {
+		t.Errorf("expected ErrServerUnavailable, got %v", err)
+	}
+
+	srv.Inject(ollamatest.Fault{Path: "/api/chat", MidStream: true, AfterChunks: 2, Message: "out of memory"})
+	stream, err := c.ChatStream(ctx, client.ChatRequest{Model: "llama2", Messages: []client.ChatMessage{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	var content string
+	var streamErr error
+	for resp := range stream {
+		content += resp.Message.Content
+		if resp.Err != nil {
+			streamErr = resp.Err
+		}
+	}
+	if content != "one two " {
+		t.Errorf("content before the fault = %q", content)
+	}
+	if streamErr == nil || !strings.Contains(streamErr.Error(), "out of memory") {
+		t.Errorf("expected mid-stream error, got %v", streamErr)
+	}
+}
+
+func TestBlobsCreateAndAuth(t *testing.T) {
+	srv := ollamatest.New(t, ollamatest.Options{Token: "secret", Models: []ollamatest.Model{{Name: "llama2", Family: "llama"}}})
+	ctx := context.Background()
+
+	if _, err := newClient(srv).ListModels(ctx); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized without a token, got %v", err)
+	}
+
+	c := client.New(client.Options{BaseURL: srv.URL, Token: "secret", Retries: 1, RetryDelay: time.Millisecond})
+
+	data := []byte("GGUF fake weights")
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if exists, err := c.BlobExists(ctx, digest); err != nil || exists {
+		t.Fatalf("BlobExists = %v, %v before upload", exists, err)
+	}
+	if err := c.CreateBlob(ctx, digest, strings.NewReader(string(data))); err != nil {
+		t.Fatalf("CreateBlob failed: %v", err)
+	}
+	if exists, _ := c.BlobExists(ctx, digest); !exists {
+		t.Error("blob should exist after upload")
+	}
+
+	progress, err := c.CreateModel(ctx, client.CreateRequest{Name: "custom", Modelfile: "FROM llama2\nSYSTEM hi"})
+	if err != nil {
+		t.Fatalf("CreateModel failed: %v", err)
+	}
+	for range progress {
+	}
+	show, err := c.ShowModel(ctx, client.ShowRequest{Name: "custom"})
+	if err != nil {
+		t.Fatalf("ShowModel failed: %v", err)
+	}
+	if show.Details.Family != "llama" {
+		t.Errorf("created model family = %q, want llama", show.Details.Family)
+	}
+}